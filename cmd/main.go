@@ -58,6 +58,12 @@ func main() {
 
 	updates := bot.GetUpdatesChan(u)
 
+	// Background jobs
+	go appContainer.GetReminderService().Run(ctx)
+	go appContainer.GetOTPCleanupService().Run(ctx)
+	go appContainer.GetDeliveryTracker().Run(ctx)
+	go appContainer.GetSMSOutbox().Run(ctx)
+
 	// Setup graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -5,6 +5,31 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+)
+
+// Duplicate phone policies: what happens when a verified number already
+// belongs to another Telegram account
+const (
+	DuplicatePhoneReject   = "reject"
+	DuplicatePhoneTransfer = "transfer" // the number moves to the new account after OTP proof
+	DuplicatePhoneAlias    = "alias"    // the new account is linked to the existing one
+)
+
+// OTP delivery channels, see OTP_CHANNELS
+const (
+	OTPChannelSMS      = "sms"
+	OTPChannelTelegram = "telegram"
+	OTPChannelLog      = "log"
+)
+
+// Phone verification policies
+const (
+	// PhonePolicyOTP sends an SMS code for every number
+	PhonePolicyOTP = "otp"
+	// PhonePolicyTrustOwnContact skips the SMS when the user shares their own
+	// Telegram contact, which Telegram has already verified
+	PhonePolicyTrustOwnContact = "trust_own_contact"
 )
 
 type Config struct {
@@ -21,15 +46,86 @@ type Config struct {
 	PostgresDB       string
 	PostgresSSLMode  string
 
+	// SMSProviders are tried in order, failing over when one is unavailable:
+	// etc_uz (sms.etc.uz), eskiz, playmobile
+	SMSProviders            []string
+	SMSTimeoutSecs          int
+	SMSFailoverCooldownSecs int // how long a failed provider is skipped
+
 	// SMS Service (sms.etc.uz)
 	SMSBaseURL  string
 	SMSLogin    string
 	SMSPassword string
 	SMSSender   string // CgPN - Amity
+	SMSBrand    string // {brand} in OTP SMS templates
+
+	// Eskiz
+	EskizBaseURL  string
+	EskizEmail    string
+	EskizPassword string
+	EskizFrom     string
+
+	// Playmobile
+	PlaymobileBaseURL    string
+	PlaymobileLogin      string
+	PlaymobilePassword   string
+	PlaymobileOriginator string
+
+	// SMSSandbox swaps the gateway for an in-memory fake. Off unless set and
+	// refused in production. Codes sent to SMSSandboxPhones (E.164) are always SMSSandboxCode.
+	SMSSandbox       bool
+	SMSSandboxPhones []string
+	SMSSandboxCode   string
+
+	// Delivery reports are polled every SMSStatusCheckSecs for messages
+	// younger than SMSStatusWindowMins
+	SMSStatusCheckSecs  int
+	SMSStatusWindowMins int
+
+	// The SMS outbox is polled every SMSOutboxPollMillis. A failed send is
+	// retried after SMSOutboxBackoffSecs, doubling up to SMSOutboxMaxBackoffSecs,
+	// and given up after SMSOutboxMaxAttempts.
+	SMSOutboxPollMillis     int
+	SMSOutboxMaxAttempts    int
+	SMSOutboxBackoffSecs    int
+	SMSOutboxMaxBackoffSecs int
 
 	// OTP Settings
 	OTPLength      int
 	OTPExpiresMins int
+	OTPSecret      string // HMAC key for stored codes
+	// OTPChannels are tried in order until one delivers the code. By default
+	// SMS falls back to the log in development and to nothing in production.
+	// Only an SMS code proves the phone; codes sent over the other channels
+	// register the user with the chat_otp verification method.
+	OTPChannels []string
+	// Brute-force protection: a code is invalidated after OTPMaxAttempts wrong
+	// guesses, the phone is locked for OTPLockoutMins after OTPPhoneMaxFailures
+	OTPMaxAttempts      int
+	OTPPhoneMaxFailures int
+	OTPLockoutMins      int
+	// SMS budget: seconds between resends and caps per 24 hours; OTPDailyGlobal 0 means no global cap
+	OTPResendCooldownSecs int
+	OTPDailyPerPhone      int
+	OTPDailyPerUser       int
+	OTPDailyGlobal        int
+	// Finished codes are deleted after OTPRetentionDays
+	OTPRetentionDays       int
+	OTPCleanupIntervalMins int
+
+	PhoneVerificationPolicy string
+	DuplicatePhonePolicy    string
+	// PhoneCountryCodes are the calling codes accepted at registration;
+	// the first one is assumed for numbers typed without a code
+	PhoneCountryCodes []string
+
+	// Registration
+	GradeAgeTolerance int // allowed difference in years between age and grade
+
+	// Reminders for abandoned registrations
+	ReminderIntervalMins int // first nudge after this long on one step, then doubling
+	ReminderMaxAttempts  int // 0 disables reminders
+	ReminderCheckMins    int
 }
 
 func Load() (*Config, error) {
@@ -47,17 +143,79 @@ func Load() (*Config, error) {
 		PostgresDB:       getEnv("POSTGRES_DB", "khisobot"),
 		PostgresSSLMode:  getEnv("POSTGRES_SSL_MODE", "disable"),
 
+		// SMS providers
+		SMSProviders:            getEnvList("SMS_PROVIDERS", []string{"etc_uz"}),
+		SMSTimeoutSecs:          getEnvInt("SMS_TIMEOUT_SECS", 10),
+		SMSFailoverCooldownSecs: getEnvInt("SMS_FAILOVER_COOLDOWN_SECS", 60),
+
 		// SMS Service
 		SMSBaseURL:  getEnv("SMS_BASE_URL", "http://sms.etc.uz:8084"),
 		SMSLogin:    getEnv("SMS_LOGIN", ""),
 		SMSPassword: getEnv("SMS_PASSWORD", ""),
 		SMSSender:   getEnv("SMS_SENDER", "Amity"),
+		SMSBrand:    getEnv("SMS_BRAND", "Amity"),
+
+		EskizBaseURL:  getEnv("ESKIZ_BASE_URL", "https://notify.eskiz.uz/api"),
+		EskizEmail:    getEnv("ESKIZ_EMAIL", ""),
+		EskizPassword: getEnv("ESKIZ_PASSWORD", ""),
+		EskizFrom:     getEnv("ESKIZ_FROM", "4546"),
+
+		PlaymobileBaseURL:    getEnv("PLAYMOBILE_BASE_URL", "https://send.smsxabar.uz"),
+		PlaymobileLogin:      getEnv("PLAYMOBILE_LOGIN", ""),
+		PlaymobilePassword:   getEnv("PLAYMOBILE_PASSWORD", ""),
+		PlaymobileOriginator: getEnv("PLAYMOBILE_ORIGINATOR", "3700"),
+
+		SMSStatusCheckSecs:  getEnvInt("SMS_STATUS_CHECK_SECS", 30),
+		SMSStatusWindowMins: getEnvInt("SMS_STATUS_WINDOW_MINS", 30),
+
+		SMSOutboxPollMillis:     getEnvInt("SMS_OUTBOX_POLL_MS", 1000),
+		SMSOutboxMaxAttempts:    getEnvInt("SMS_OUTBOX_MAX_ATTEMPTS", 5),
+		SMSOutboxBackoffSecs:    getEnvInt("SMS_OUTBOX_BACKOFF_SECS", 2),
+		SMSOutboxMaxBackoffSecs: getEnvInt("SMS_OUTBOX_MAX_BACKOFF_SECS", 60),
 
 		// OTP Settings
 		OTPLength:      getEnvInt("OTP_LENGTH", 6),
 		OTPExpiresMins: getEnvInt("OTP_EXPIRES_MINS", 5),
+		OTPSecret:      getEnv("OTP_SECRET", ""),
+
+		OTPMaxAttempts:      getEnvInt("OTP_MAX_ATTEMPTS", 5),
+		OTPPhoneMaxFailures: getEnvInt("OTP_PHONE_MAX_FAILURES", 10),
+		OTPLockoutMins:      getEnvInt("OTP_LOCKOUT_MINS", 60),
+
+		OTPResendCooldownSecs: getEnvInt("OTP_RESEND_COOLDOWN_SECS", 60),
+		OTPDailyPerPhone:      getEnvInt("OTP_DAILY_PER_PHONE", 5),
+		OTPDailyPerUser:       getEnvInt("OTP_DAILY_PER_USER", 10),
+		OTPDailyGlobal:        getEnvInt("OTP_DAILY_GLOBAL", 0),
+
+		OTPRetentionDays:       getEnvInt("OTP_RETENTION_DAYS", 30),
+		OTPCleanupIntervalMins: getEnvInt("OTP_CLEANUP_INTERVAL_MINS", 60),
+
+		PhoneVerificationPolicy: getEnv("PHONE_VERIFICATION_POLICY", PhonePolicyOTP),
+		DuplicatePhonePolicy:    getEnv("DUPLICATE_PHONE_POLICY", DuplicatePhoneReject),
+		PhoneCountryCodes:       getEnvList("PHONE_COUNTRY_CODES", []string{"998", "7", "992", "996"}),
+
+		// Registration
+		GradeAgeTolerance: getEnvInt("GRADE_AGE_TOLERANCE", 1),
+
+		// Reminders
+		ReminderIntervalMins: getEnvInt("REMINDER_INTERVAL_MINS", 60),
+		ReminderMaxAttempts:  getEnvInt("REMINDER_MAX_ATTEMPTS", 3),
+		ReminderCheckMins:    getEnvInt("REMINDER_CHECK_MINS", 5),
 	}
 
+	// Sandbox faqat aniq yoqilganda ishlaydi: ENVIRONMENT ko'rsatilmagan
+	// real serverda SMS jimgina soxtasiga almashmasligi kerak
+	cfg.SMSSandbox = getEnvBool("SMS_SANDBOX", false)
+	cfg.SMSSandboxPhones = getEnvList("SMS_SANDBOX_PHONES", nil)
+	cfg.SMSSandboxCode = getEnv("SMS_SANDBOX_CODE", strings.Repeat("0", cfg.OTPLength))
+
+	// Zaxira kanal standarti muhitga bog'liq
+	defaultChannels := []string{OTPChannelSMS, OTPChannelLog}
+	if cfg.Environment == "production" {
+		defaultChannels = []string{OTPChannelSMS}
+	}
+	cfg.OTPChannels = getEnvList("OTP_CHANNELS", defaultChannels)
+
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
@@ -69,11 +227,111 @@ func (c *Config) validate() error {
 	if c.TelegramBotToken == "" {
 		return fmt.Errorf("TELEGRAM_BOT_TOKEN is required")
 	}
-	if c.SMSLogin == "" {
-		return fmt.Errorf("SMS_LOGIN is required")
+	if c.SMSSandbox && c.Environment == "production" {
+		return fmt.Errorf("SMS_SANDBOX is not allowed in production")
+	}
+	if c.SMSSandbox && len(c.SMSSandboxCode) != c.OTPLength {
+		return fmt.Errorf("SMS_SANDBOX_CODE must have OTP_LENGTH (%d) digits", c.OTPLength)
+	}
+	if err := c.validateOTPChannels(); err != nil {
+		return err
+	}
+	if len(c.OTPSecret) < 32 {
+		return fmt.Errorf("OTP_SECRET is required and must be at least 32 characters, see .env.example")
+	}
+	if c.OTPLength < 4 || c.OTPLength > 12 {
+		return fmt.Errorf("OTP_LENGTH must be between 4 and 12")
+	}
+	if c.PhoneVerificationPolicy != PhonePolicyOTP && c.PhoneVerificationPolicy != PhonePolicyTrustOwnContact {
+		return fmt.Errorf("PHONE_VERIFICATION_POLICY must be %q or %q", PhonePolicyOTP, PhonePolicyTrustOwnContact)
 	}
-	if c.SMSPassword == "" {
-		return fmt.Errorf("SMS_PASSWORD is required")
+	switch c.DuplicatePhonePolicy {
+	case DuplicatePhoneReject, DuplicatePhoneTransfer, DuplicatePhoneAlias:
+	default:
+		return fmt.Errorf("DUPLICATE_PHONE_POLICY must be %q, %q or %q",
+			DuplicatePhoneReject, DuplicatePhoneTransfer, DuplicatePhoneAlias)
+	}
+	if c.OTPMaxAttempts <= 0 || c.OTPPhoneMaxFailures <= 0 || c.OTPLockoutMins <= 0 {
+		return fmt.Errorf("OTP_MAX_ATTEMPTS, OTP_PHONE_MAX_FAILURES and OTP_LOCKOUT_MINS must be positive")
+	}
+	if c.OTPResendCooldownSecs < 0 || c.OTPDailyPerPhone <= 0 || c.OTPDailyPerUser <= 0 || c.OTPDailyGlobal < 0 {
+		return fmt.Errorf("OTP_DAILY_PER_PHONE and OTP_DAILY_PER_USER must be positive, " +
+			"OTP_RESEND_COOLDOWN_SECS and OTP_DAILY_GLOBAL must not be negative")
+	}
+	// Quotalar va qulflash eski kodlarni sanaydi, ularni erta o'chirib bo'lmaydi
+	if c.OTPRetentionDays < 1 || c.OTPRetentionDays*24*60 < c.OTPLockoutMins {
+		return fmt.Errorf("OTP_RETENTION_DAYS must be at least 1 day and cover OTP_LOCKOUT_MINS")
+	}
+	if c.OTPCleanupIntervalMins <= 0 {
+		return fmt.Errorf("OTP_CLEANUP_INTERVAL_MINS must be positive")
+	}
+	if c.SMSStatusCheckSecs <= 0 || c.SMSStatusWindowMins <= 0 {
+		return fmt.Errorf("SMS_STATUS_CHECK_SECS and SMS_STATUS_WINDOW_MINS must be positive")
+	}
+	if c.SMSOutboxPollMillis <= 0 || c.SMSOutboxMaxAttempts <= 0 {
+		return fmt.Errorf("SMS_OUTBOX_POLL_MS and SMS_OUTBOX_MAX_ATTEMPTS must be positive")
+	}
+	if c.SMSOutboxBackoffSecs <= 0 || c.SMSOutboxMaxBackoffSecs < c.SMSOutboxBackoffSecs {
+		return fmt.Errorf("SMS_OUTBOX_BACKOFF_SECS must be positive and not above SMS_OUTBOX_MAX_BACKOFF_SECS")
+	}
+	if len(c.PhoneCountryCodes) == 0 {
+		return fmt.Errorf("PHONE_COUNTRY_CODES must list at least one calling code")
+	}
+	if c.ReminderIntervalMins <= 0 || c.ReminderCheckMins <= 0 {
+		return fmt.Errorf("REMINDER_INTERVAL_MINS and REMINDER_CHECK_MINS must be positive")
+	}
+	return nil
+}
+
+func (c *Config) validateOTPChannels() error {
+	if len(c.OTPChannels) == 0 {
+		return fmt.Errorf("OTP_CHANNELS must list at least one channel")
+	}
+	for _, channel := range c.OTPChannels {
+		switch channel {
+		case OTPChannelSMS:
+			if !c.SMSSandbox {
+				if err := c.validateSMSProviders(); err != nil {
+					return err
+				}
+			}
+		case OTPChannelTelegram:
+		case OTPChannelLog:
+			if c.Environment == "production" {
+				return fmt.Errorf("the log OTP channel is not allowed in production")
+			}
+		default:
+			return fmt.Errorf("unknown OTP channel %q, use %q, %q or %q",
+				channel, OTPChannelSMS, OTPChannelTelegram, OTPChannelLog)
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateSMSProviders() error {
+	if len(c.SMSProviders) == 0 {
+		return fmt.Errorf("SMS_PROVIDERS must list at least one provider")
+	}
+	if c.SMSTimeoutSecs <= 0 || c.SMSFailoverCooldownSecs < 0 {
+		return fmt.Errorf("SMS_TIMEOUT_SECS must be positive and SMS_FAILOVER_COOLDOWN_SECS not negative")
+	}
+	for _, provider := range c.SMSProviders {
+		switch provider {
+		case "etc_uz":
+			if c.SMSLogin == "" || c.SMSPassword == "" {
+				return fmt.Errorf("SMS_LOGIN and SMS_PASSWORD are required for the etc_uz provider")
+			}
+		case "eskiz":
+			if c.EskizEmail == "" || c.EskizPassword == "" {
+				return fmt.Errorf("ESKIZ_EMAIL and ESKIZ_PASSWORD are required for the eskiz provider")
+			}
+		case "playmobile":
+			if c.PlaymobileLogin == "" || c.PlaymobilePassword == "" {
+				return fmt.Errorf("PLAYMOBILE_LOGIN and PLAYMOBILE_PASSWORD are required for the playmobile provider")
+			}
+		default:
+			return fmt.Errorf("unknown SMS provider %q, use etc_uz, eskiz or playmobile", provider)
+		}
 	}
 	return nil
 }
@@ -105,3 +363,28 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvList reads a comma separated list, e.g. "998,7,992"
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,160 @@
+// internal/bot/birthdate.go
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sendBirthYearPicker asks for the birth date: it can be typed as DD.MM.YYYY
+// or picked year, month and day from the keyboards
+func (h *Handler) sendBirthYearPicker(chatID int64, messageID int, user *domain.User) {
+	msgs := i18n.Get(user.LanguageCode)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, year := range h.fsm.BirthYears(user.Grade) {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			strconv.Itoa(year), CallbackBirthYear+strconv.Itoa(year)))
+		if len(row) == 4 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, backRow(user.LanguageCode))
+
+	h.sendOrEdit(chatID, messageID, h.stepPrompt(msgs, user), tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+func (h *Handler) sendBirthMonthPicker(chatID int64, messageID int, langCode string, year int) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for month := 1; month <= 12; month++ {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("%02d", month), fmt.Sprintf("%s%d_%d", CallbackBirthMonth, year, month)))
+		if len(row) == 4 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	rows = append(rows, backRow(langCode))
+
+	text := fmt.Sprintf(i18n.Get(langCode).AskBirthMonth, year)
+	h.sendOrEdit(chatID, messageID, text, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+func (h *Handler) sendBirthDayPicker(chatID int64, messageID int, langCode string, year, month int) {
+	// Oyning oxirgi kuni: keyingi oyning 0-kuni
+	days := time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for day := 1; day <= days; day++ {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			strconv.Itoa(day), fmt.Sprintf("%s%04d-%02d-%02d", CallbackBirthDay, year, month, day)))
+		if len(row) == 7 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, backRow(langCode))
+
+	text := fmt.Sprintf(i18n.Get(langCode).AskBirthDay, month, year)
+	h.sendOrEdit(chatID, messageID, text, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+func (h *Handler) handleBirthYearCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || user.State != domain.StateWaitBirthDate {
+		return
+	}
+
+	year, err := strconv.Atoi(strings.TrimPrefix(callback.Data, CallbackBirthYear))
+	if err != nil {
+		return
+	}
+
+	h.sendBirthMonthPicker(callback.Message.Chat.ID, callback.Message.MessageID, user.LanguageCode, year)
+}
+
+func (h *Handler) handleBirthMonthCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || user.State != domain.StateWaitBirthDate {
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(callback.Data, CallbackBirthMonth), "_", 2)
+	if len(parts) != 2 {
+		return
+	}
+	year, _ := strconv.Atoi(parts[0])
+	month, _ := strconv.Atoi(parts[1])
+	if year == 0 || month < 1 || month > 12 {
+		return
+	}
+
+	h.sendBirthDayPicker(callback.Message.Chat.ID, callback.Message.MessageID, user.LanguageCode, year, month)
+}
+
+func (h *Handler) handleBirthDayCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || user.State != domain.StateWaitBirthDate {
+		return
+	}
+
+	date, err := domain.ParseBirthDate(strings.TrimPrefix(callback.Data, CallbackBirthDay))
+	if err != nil {
+		h.sendMessageHTML(callback.Message.Chat.ID, i18n.Get(user.LanguageCode).InvalidBirthDate)
+		return
+	}
+
+	// Tanlovni xabarda qoldiramiz, tugmalarni olib tashlaymiz
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID,
+		"📅 "+date.Format("02.01.2006"))
+	h.bot.Send(edit)
+
+	h.submitBirthDate(ctx, callback.Message.Chat.ID, user, date)
+}
+
+func (h *Handler) handleBirthDate(ctx context.Context, msg *tgbotapi.Message, user *domain.User, text string) {
+	date, _ := domain.ParseBirthDate(text)
+	h.submitBirthDate(ctx, msg.Chat.ID, user, date)
+}
+
+// submitBirthDate checks the date against the grade before saving it
+func (h *Handler) submitBirthDate(ctx context.Context, chatID int64, user *domain.User, date time.Time) {
+	msgs := i18n.Get(user.LanguageCode)
+
+	if err := h.fsm.CheckAgeForGrade(date, user.Grade); err != nil {
+		if errors.Is(err, domain.ErrAgeGradeMismatch) {
+			h.sendMessageHTML(chatID, fmt.Sprintf(msgs.AgeGradeMismatch, user.Grade))
+		}
+		h.sendBirthYearPicker(chatID, 0, user)
+		return
+	}
+
+	if err := h.userService.UpdateBirthDate(ctx, user.TelegramID, date); err != nil {
+		h.logger.Error("❌ Failed to update birth date", slog.Any("error", err))
+		h.sendMessage(chatID, msgs.Error)
+		return
+	}
+	user.BirthDate = date
+
+	h.advance(ctx, chatID, user)
+}
@@ -0,0 +1,35 @@
+// internal/bot/delivery.go
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/i18n"
+	"khisobot/pkg/phone"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// NotifyDeliveryFailed tells a user waiting for a code that the SMS did not
+// arrive and offers a resend, or going back to fix the number
+func (h *Handler) NotifyDeliveryFailed(ctx context.Context, telegramID int64, number string) error {
+	user, err := h.userService.GetUser(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.State != domain.StateWaitOTP || user.VerificationPhone() != number {
+		return nil
+	}
+
+	msgs := i18n.Get(user.LanguageCode)
+	keyboard := resendKeyboard(user.LanguageCode)
+
+	// Shaxsiy chatda chat ID telegram ID bilan bir xil
+	msg := tgbotapi.NewMessage(telegramID, fmt.Sprintf(msgs.SMSNotDelivered, phone.Mask(number)))
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = keyboard
+	_, err = h.bot.Send(msg)
+	return err
+}
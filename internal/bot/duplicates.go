@@ -0,0 +1,90 @@
+// internal/bot/duplicates.go
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// duplicatesPageSize keeps the report within Telegram's message length limit
+const duplicatesPageSize = 10
+
+func (h *Handler) handleDuplicates(ctx context.Context, msg *tgbotapi.Message) {
+	isAdmin, _ := h.adminRepo.IsAdmin(ctx, msg.From.ID)
+	if !isAdmin {
+		return
+	}
+
+	h.sendDuplicates(ctx, msg.Chat.ID)
+}
+
+// sendDuplicates lists phones shared by several unlinked accounts, each with a merge button
+func (h *Handler) sendDuplicates(ctx context.Context, chatID int64) {
+	groups, err := h.userService.GetSharedPhones(ctx)
+	if err != nil {
+		h.sendMessage(chatID, "❌ Xatolik: "+err.Error())
+		return
+	}
+
+	if len(groups) == 0 {
+		h.sendMessage(chatID, "✅ Bir nechta akkauntda ishlatilgan raqamlar yo'q")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("👥 <b>Umumiy raqamlar: %d</b>\n", len(groups)))
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, g := range groups {
+		if i == duplicatesPageSize {
+			sb.WriteString(fmt.Sprintf("\n<i>... yana %d ta. Birlashtirgandan keyin qayta oching.</i>", len(groups)-i))
+			break
+		}
+
+		sb.WriteString(fmt.Sprintf("\n📱 <b>%s</b>\n", g.Phone))
+		for j, u := range g.Users {
+			mark := "•"
+			if j == 0 {
+				mark = "⭐️"
+			}
+			sb.WriteString(fmt.Sprintf("%s %s %s · %s · <code>%d</code> · %s\n", mark,
+				html.EscapeString(u.FirstName), html.EscapeString(u.LastName),
+				exportRoleNames[u.Role], u.TelegramID, u.CreatedAt.Format("02.01.2006")))
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔗 Birlashtirish: "+g.Phone, CallbackMergePhone+g.Phone),
+		))
+	}
+	sb.WriteString("\n<i>⭐️ — asosiy akkaunt. Qolganlari unga bog'lanadi, farzandlari unga o'tadi.</i>")
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("⬅️ Orqaga", CallbackAdminBack),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.bot.Send(msg)
+}
+
+func (h *Handler) handleMergePhoneCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	isAdmin, _ := h.adminRepo.IsAdmin(ctx, callback.From.ID)
+	if !isAdmin {
+		return
+	}
+
+	phone := strings.TrimPrefix(callback.Data, CallbackMergePhone)
+	merged, err := h.userService.MergePhone(ctx, phone)
+	if err != nil {
+		h.sendMessage(callback.Message.Chat.ID, "❌ Xatolik: "+err.Error())
+		return
+	}
+
+	h.sendMessage(callback.Message.Chat.ID, fmt.Sprintf("✅ %s: %d ta akkaunt birlashtirildi", phone, merged))
+	h.sendDuplicates(ctx, callback.Message.Chat.ID)
+}
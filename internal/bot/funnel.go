@@ -0,0 +1,142 @@
+// internal/bot/funnel.go
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"khisobot/internal/domain"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const funnelDefaultDays = 30
+
+// funnelStepNames are the admin labels of registration states
+var funnelStepNames = map[string]string{
+	domain.StateWaitRole:      "Rol tanlash",
+	domain.StateWaitFullName:  "Ism familiya",
+	domain.StateWaitChildName: "Farzand ismi",
+	domain.StateWaitLocation:  "Viloyat",
+	domain.StateWaitDistrict:  "Tuman",
+	domain.StateWaitSchool:    "Maktab",
+	domain.StateWaitGrade:     "Sinf",
+	domain.StateWaitBirthDate: "Tug'ilgan sana",
+	domain.StateWaitSubject:   "Fan",
+	domain.StateWaitPhone:     "Telefon",
+	domain.StateWaitOTP:       "SMS kod",
+	domain.StateRegistered:    "Ro'yxatdan o'tdi",
+}
+
+// handleFunnel shows the registration funnel, e.g. "/funnel", "/funnel 01.09.2025 30.09.2025"
+// or "/funnel 01.09.2025 30.09.2025 parent"
+func (h *Handler) handleFunnel(ctx context.Context, msg *tgbotapi.Message) {
+	isAdmin, _ := h.adminRepo.IsAdmin(ctx, msg.From.ID)
+	if !isAdmin {
+		return
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -funnelDefaultDays)
+	role := ""
+
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) >= 2 {
+		var err1, err2 error
+		from, err1 = time.ParseInLocation("02.01.2006", args[0], time.Local)
+		to, err2 = time.ParseInLocation("02.01.2006", args[1], time.Local)
+		if err1 != nil || err2 != nil || to.Before(from) {
+			h.sendMessage(msg.Chat.ID, "📉 Foydalanish: /funnel KK.OO.YYYY KK.OO.YYYY [student|teacher|parent]")
+			return
+		}
+	}
+	if len(args) == 1 || len(args) == 3 {
+		role = args[len(args)-1]
+		if !domain.IsValidRole(role) {
+			h.sendMessage(msg.Chat.ID, "📉 Foydalanish: /funnel KK.OO.YYYY KK.OO.YYYY [student|teacher|parent]")
+			return
+		}
+	}
+
+	h.sendFunnel(ctx, msg.Chat.ID, from, to, role)
+}
+
+func (h *Handler) handleFunnelCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	isAdmin, _ := h.adminRepo.IsAdmin(ctx, callback.From.ID)
+	if !isAdmin {
+		return
+	}
+
+	days, err := strconv.Atoi(strings.TrimPrefix(callback.Data, CallbackFunnelDays))
+	if err != nil || days <= 0 {
+		days = funnelDefaultDays
+	}
+
+	to := time.Now()
+	h.sendFunnel(ctx, callback.Message.Chat.ID, to.AddDate(0, 0, -days), to, "")
+}
+
+// sendFunnel reports the steps reached between from and to, both days included
+func (h *Handler) sendFunnel(ctx context.Context, chatID int64, from, to time.Time, role string) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+	until := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+
+	steps, err := h.userService.GetFunnel(ctx, from, until, role)
+	if err != nil {
+		h.sendMessage(chatID, "❌ Xatolik: "+err.Error())
+		return
+	}
+
+	roleLabel := "Hammasi"
+	if role != "" {
+		roleLabel = exportRoleNames[role]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📉 <b>Ro'yxatdan o'tish voronkasi</b>\n📅 %s — %s · %s\n",
+		from.Format("02.01.2006"), to.Format("02.01.2006"), roleLabel))
+
+	for i, step := range steps {
+		sb.WriteString(fmt.Sprintf("\n%d. %s: <b>%d</b>", i+1, funnelStepNames[step.State], step.Users))
+		if i > 0 {
+			sb.WriteString(fmt.Sprintf(" (%.0f%%)", step.Conversion*100))
+		}
+		if step.MedianTime > 0 && step.State != domain.StateRegistered {
+			sb.WriteString(" ⏱ " + formatDuration(step.MedianTime))
+		}
+	}
+	sb.WriteString("\n\n<i>% — oldingi qadamdan o'tganlar, ⏱ — qadamda o'rtacha (mediana) vaqt</i>")
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("7 kun", CallbackFunnelDays+"7"),
+			tgbotapi.NewInlineKeyboardButtonData("30 kun", CallbackFunnelDays+"30"),
+			tgbotapi.NewInlineKeyboardButtonData("90 kun", CallbackFunnelDays+"90"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⬅️ Orqaga", CallbackAdminBack),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = keyboard
+	h.bot.Send(msg)
+}
+
+// formatDuration rounds a step duration to what an admin cares about: 45s, 3m, 2h 15m, 4d 3h
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd %dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
@@ -4,23 +4,26 @@ package bot
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"html"
 	"log/slog"
-	"regexp"
+	"math"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"khisobot/config"
 	"khisobot/internal/domain"
 	"khisobot/internal/service"
 	"khisobot/pkg/i18n"
+	"khisobot/pkg/phone"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/xuri/excelize/v2"
 )
 
-var phoneRegex = regexp.MustCompile(`^998[0-9]{9}$`)
-
 const (
 	CallbackLogin       = "login"
 	CallbackRegister    = "register"
@@ -32,15 +35,60 @@ const (
 	CallbackAdminExport = "admin_export"
 	CallbackAdminBack   = "admin_back"
 	CallbackDelChannel  = "del_ch_"
+
+	CallbackRegion       = "reg_"
+	CallbackRegionPage   = "regp_"
+	CallbackDistrict     = "dist_"
+	CallbackDistrictPage = "distp_"
+
+	CallbackSchool        = "sch_"
+	CallbackSchoolPage    = "schp_"
+	CallbackSchoolMissing = "school_missing"
+	CallbackAdminSchools  = "admin_schools"
+	CallbackSchoolApprove = "adm_sch_ok_"
+	CallbackSchoolReject  = "adm_sch_no_"
+
+	CallbackEditName     = "edit_name"
+	CallbackEditLocation = "edit_location"
+	CallbackEditGrade    = "edit_grade"
+	CallbackEditPhone    = "edit_phone"
+
+	CallbackBack = "step_back"
+
+	CallbackRole        = "role_"
+	CallbackEditSubject = "edit_subject"
+	CallbackEditChild   = "edit_child"
+	CallbackChild       = "child_"
+	CallbackAddChild    = "add_child"
+	CallbackExportRole  = "export_"
+
+	CallbackBirthYear     = "bdy_"
+	CallbackBirthMonth    = "bdm_"
+	CallbackBirthDay      = "bdd_"
+	CallbackEditBirthDate = "edit_birth_date"
+
+	CallbackAdminFunnel = "admin_funnel"
+	CallbackFunnelDays  = "funnel_"
+
+	CallbackAdminDuplicates = "admin_duplicates"
+	CallbackMergePhone      = "merge_"
+
+	CallbackAdminSMSTemplates = "admin_sms_templates"
 )
 
 type Handler struct {
-	bot         *tgbotapi.BotAPI
-	userService *service.UserService
-	otpService  *service.OTPService
-	adminRepo   domain.AdminRepository
-	channelRepo domain.ChannelRepository
-	logger      *slog.Logger
+	bot             *tgbotapi.BotAPI
+	userService     *service.UserService
+	otpService      *service.OTPService
+	locationService *service.LocationService
+	schoolService   *service.SchoolService
+	fsm             *domain.RegistrationFSM
+	adminRepo       domain.AdminRepository
+	channelRepo     domain.ChannelRepository
+	reminderRepo    domain.ReminderRepository
+	sandbox         *service.SandboxSMS // nil unless SMS_SANDBOX is on
+	smsRouter       *service.SMSRouter  // nil in sandbox mode
+	logger          *slog.Logger
 
 	// Admin states (in memory)
 	adminStates map[int64]string
@@ -48,6 +96,13 @@ type Handler struct {
 	// Subscription confirmed (in memory, NO REAL CHECK)
 	subConfirmed map[int64]bool
 
+	// Location a registered user is choosing while editing it (in memory),
+	// saved only once the school is picked
+	locationDrafts map[int64]domain.Location
+
+	// Resend countdown each chat's OTP message is showing (in memory)
+	countdowns map[int64]*resendCountdown
+
 	mu sync.RWMutex
 }
 
@@ -55,19 +110,33 @@ func NewHandler(
 	bot *tgbotapi.BotAPI,
 	userService *service.UserService,
 	otpService *service.OTPService,
+	locationService *service.LocationService,
+	schoolService *service.SchoolService,
+	fsm *domain.RegistrationFSM,
 	adminRepo domain.AdminRepository,
 	channelRepo domain.ChannelRepository,
+	reminderRepo domain.ReminderRepository,
+	sandbox *service.SandboxSMS,
+	smsRouter *service.SMSRouter,
 	logger *slog.Logger,
 ) *Handler {
 	return &Handler{
-		bot:          bot,
-		userService:  userService,
-		otpService:   otpService,
-		adminRepo:    adminRepo,
-		channelRepo:  channelRepo,
-		logger:       logger,
-		adminStates:  make(map[int64]string),
-		subConfirmed: make(map[int64]bool),
+		bot:             bot,
+		userService:     userService,
+		otpService:      otpService,
+		locationService: locationService,
+		schoolService:   schoolService,
+		fsm:             fsm,
+		adminRepo:       adminRepo,
+		channelRepo:     channelRepo,
+		reminderRepo:    reminderRepo,
+		sandbox:         sandbox,
+		smsRouter:       smsRouter,
+		logger:          logger,
+		adminStates:     make(map[int64]string),
+		subConfirmed:    make(map[int64]bool),
+		locationDrafts:  make(map[int64]domain.Location),
+		countdowns:      make(map[int64]*resendCountdown),
 	}
 }
 
@@ -94,8 +163,20 @@ func (h *Handler) handleCommand(ctx context.Context, msg *tgbotapi.Message) {
 		h.handleResendOTP(ctx, msg)
 	case "profile":
 		h.handleProfile(ctx, msg)
+	case "cancel":
+		h.handleCancel(ctx, msg)
 	case "admin":
 		h.handleAdmin(ctx, msg)
+	case "find":
+		h.handleFindUser(ctx, msg)
+	case "funnel":
+		h.handleFunnel(ctx, msg)
+	case "duplicates":
+		h.handleDuplicates(ctx, msg)
+	case "smstemplate":
+		h.handleSMSTemplate(ctx, msg)
+	case "sandbox":
+		h.handleSandbox(ctx, msg)
 	}
 }
 
@@ -122,8 +203,17 @@ func (h *Handler) handleStart(ctx context.Context, msg *tgbotapi.Message) {
 		return
 	}
 
-	msgs := i18n.Get(user.LanguageCode)
-	h.sendMessageHTML(msg.Chat.ID, msgs.Welcome+"\n\n"+msgs.AskFullName)
+	if user.State == domain.StateWaitRole && !user.IsVerified {
+		h.sendMessageHTML(msg.Chat.ID, i18n.Get(user.LanguageCode).Welcome)
+		h.sendRolePicker(msg.Chat.ID, user.LanguageCode)
+		return
+	}
+
+	// Ro'yxatdan o'tish yoki tahrirlash yarmida qolgan bo'lsa, shu qadamdan davom etamiz
+	if !h.applyLocationDraft(ctx, msg.Chat.ID, user) {
+		return
+	}
+	h.sendStepPrompt(ctx, msg.Chat.ID, user)
 }
 
 func (h *Handler) checkSubscription(ctx context.Context, userID int64, chatID int64, lang string) bool {
@@ -195,6 +285,9 @@ func (h *Handler) handleMessage(ctx context.Context, msg *tgbotapi.Message) {
 		h.handleStart(ctx, msg)
 		return
 	}
+	if !h.applyLocationDraft(ctx, msg.Chat.ID, user) {
+		return
+	}
 
 	// Contact yuborilgan bo'lsa (telefon raqam ulashish)
 	if msg.Contact != nil && user.State == domain.StateWaitPhone {
@@ -207,117 +300,127 @@ func (h *Handler) handleMessage(ctx context.Context, msg *tgbotapi.Message) {
 		return
 	}
 
-	switch user.State {
-	case domain.StateWaitFullName:
-		h.handleFullName(ctx, msg, user, text)
-	case domain.StateWaitLocation:
-		h.handleLocation(ctx, msg, user, text)
-	case domain.StateWaitGrade:
-		h.handleGrade(ctx, msg, user, text)
-	case domain.StateWaitPhone:
-		h.handlePhone(ctx, msg, user, text)
-	case domain.StateWaitOTP:
-		h.handleOTPInput(ctx, msg, user, text)
-	case domain.StateRegistered:
-		h.sendMainMenu(msg.Chat.ID, user.LanguageCode)
+	// Telefon qadamidagi reply keyboard'dagi "Orqaga" tugmasi
+	if text == i18n.Get(user.LanguageCode).BtnBack && user.State != domain.StateRegistered {
+		h.goBack(ctx, msg.Chat.ID, user)
+		return
 	}
+
+	h.routeInput(ctx, msg, user, text)
 }
 
 func (h *Handler) handleContact(ctx context.Context, msg *tgbotapi.Message, user *domain.User) {
-	// Kontaktdan telefon raqamni olish
-	phone := msg.Contact.PhoneNumber
-	phone = strings.NewReplacer(" ", "", "+", "", "-", "", "(", "", ")", "").Replace(phone)
-
-	// Agar 998 bilan boshlanmasa, qo'shish
-	if !strings.HasPrefix(phone, "998") && len(phone) == 9 {
-		phone = "998" + phone
-	}
-
-	if !phoneRegex.MatchString(phone) {
+	phone, err := h.fsm.ParsePhone(msg.Contact.PhoneNumber)
+	if err != nil {
 		h.sendMessageHTML(msg.Chat.ID, i18n.Get(user.LanguageCode).InvalidPhone)
 		return
 	}
 
-	if err := h.userService.UpdatePhone(ctx, user.TelegramID, phone); err != nil {
-		h.sendMessage(msg.Chat.ID, i18n.Get(user.LanguageCode).Error)
-		return
-	}
-
-	updatedUser, _ := h.userService.GetUser(ctx, user.TelegramID)
-
-	if err := h.otpService.GenerateAndSendOTP(ctx, updatedUser.ID, phone); err != nil {
-		h.logger.Error("❌ Failed to send OTP", slog.Any("error", err))
-		h.sendMessage(msg.Chat.ID, i18n.Get(user.LanguageCode).Error)
-		return
+	// Telegram faqat foydalanuvchining o'z kontaktidagi raqamni tasdiqlagan bo'ladi.
+	// Boshqa odamning kontakti qo'lda yozilgan raqam kabi SMS orqali tekshiriladi.
+	if msg.Contact.UserID == msg.From.ID && h.otpService.TrustOwnContact() {
+		// Raqamni boshqa akkauntdan olib o'tish uchun SMS orqali isbot kerak
+		owner, err := h.userService.PhoneConflict(ctx, user, phone)
+		if err == nil && (owner == nil || h.userService.DuplicatePhonePolicy() == config.DuplicatePhoneAlias) {
+			h.verifyByContact(ctx, msg.Chat.ID, user, phone)
+			return
+		}
 	}
 
-	h.userService.UpdateUserState(ctx, user.TelegramID, domain.StateWaitOTP)
-
-	// Keyboard'ni olib tashlash
-	removeKeyboard := tgbotapi.NewRemoveKeyboard(true)
-	msgRemove := tgbotapi.NewMessage(msg.Chat.ID, "✅")
-	msgRemove.ReplyMarkup = removeKeyboard
-	h.bot.Send(msgRemove)
-
-	maskedPhone := phone[:6] + "****" + phone[len(phone)-2:]
-	h.sendOTPMessage(msg.Chat.ID, user.LanguageCode, maskedPhone)
+	h.submitPhone(ctx, msg.Chat.ID, user, phone)
 }
 
-func (h *Handler) handleFullName(ctx context.Context, msg *tgbotapi.Message, user *domain.User, text string) {
-	parts := strings.Fields(text)
-	if len(parts) < 2 {
-		h.sendMessageHTML(msg.Chat.ID, i18n.Get(user.LanguageCode).InvalidFullName)
-		return
-	}
+// verifyByContact accepts a number from the user's own shared contact without an OTP
+func (h *Handler) verifyByContact(ctx context.Context, chatID int64, user *domain.User, phone string) {
+	h.removeReplyKeyboard(chatID)
 
-	firstName := parts[0]
-	lastName := strings.Join(parts[1:], " ")
-
-	if err := h.userService.UpdateFullName(ctx, user.TelegramID, firstName, lastName); err != nil {
-		h.logger.Error("❌ Failed to update full name", slog.Any("error", err))
-		h.sendMessage(msg.Chat.ID, i18n.Get(user.LanguageCode).Error)
+	if user.IsVerified {
+		if phone == user.Phone && domain.ProvesPhone(user.VerificationMethod) {
+			h.finishEdit(ctx, chatID, user)
+			return
+		}
+		if err := h.userService.UpdatePendingPhone(ctx, user.TelegramID, phone); err != nil {
+			h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+			return
+		}
+		if err := h.userService.ConfirmPendingPhone(ctx, user.TelegramID, domain.VerificationMethodContact); err != nil {
+			h.logger.Error("❌ Failed to confirm new phone", slog.Any("error", err))
+			h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+			return
+		}
+		h.resolvePhoneConflict(ctx, chatID, user, phone)
+		h.finishEdit(ctx, chatID, user)
 		return
 	}
 
-	if err := h.userService.UpdateUserState(ctx, user.TelegramID, domain.StateWaitLocation); err != nil {
+	if err := h.userService.UpdatePhone(ctx, user.TelegramID, phone); err != nil {
+		h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
 		return
 	}
-
-	h.sendMessageHTML(msg.Chat.ID, i18n.Get(user.LanguageCode).AskLocation)
+	h.completeRegistration(ctx, chatID, user, domain.VerificationMethodContact)
 }
 
-func (h *Handler) handleLocation(ctx context.Context, msg *tgbotapi.Message, user *domain.User, text string) {
-	parts := strings.Split(text, ",")
-	if len(parts) < 3 {
-		h.sendMessageHTML(msg.Chat.ID, i18n.Get(user.LanguageCode).InvalidLocation)
+// routeInput validates text against the user's current step and hands it to
+// the step handler. Which steps exist and what they accept lives in domain.RegistrationFSM.
+func (h *Handler) routeInput(ctx context.Context, msg *tgbotapi.Message, user *domain.User, text string) {
+	msgs := i18n.Get(user.LanguageCode)
+
+	if _, ok := h.fsm.Step(user.State); !ok {
+		h.sendMessage(msg.Chat.ID, msgs.Error)
 		return
 	}
 
-	region := strings.TrimSpace(parts[0])
-	district := strings.TrimSpace(parts[1])
-	school := strings.TrimSpace(strings.Join(parts[2:], ","))
-
-	if region == "" || district == "" || school == "" {
-		h.sendMessageHTML(msg.Chat.ID, i18n.Get(user.LanguageCode).InvalidLocation)
+	if err := h.fsm.Validate(user.State, text); err != nil {
+		h.sendMessageHTML(msg.Chat.ID, h.invalidInput(msgs, user.State))
+		if errors.Is(err, domain.ErrChoiceExpected) {
+			h.sendPicker(ctx, msg.Chat.ID, user)
+		}
 		return
 	}
 
-	if err := h.userService.UpdateLocation(ctx, user.TelegramID, region, district, school); err != nil {
-		h.sendMessage(msg.Chat.ID, i18n.Get(user.LanguageCode).Error)
-		return
+	switch user.State {
+	case domain.StateWaitFullName:
+		h.handleFullName(ctx, msg, user, text)
+	case domain.StateWaitChildName:
+		h.handleChildName(ctx, msg, user, text)
+	case domain.StateWaitSchool:
+		h.handleSchool(ctx, msg, user, text)
+	case domain.StateWaitSchoolName:
+		h.handleSchoolName(ctx, msg, user, text)
+	case domain.StateWaitGrade:
+		h.handleGrade(ctx, msg, user, text)
+	case domain.StateWaitBirthDate:
+		h.handleBirthDate(ctx, msg, user, text)
+	case domain.StateWaitSubject:
+		h.handleSubject(ctx, msg, user, text)
+	case domain.StateWaitPhone:
+		h.handlePhone(ctx, msg, user, text)
+	case domain.StateWaitOTP:
+		h.handleOTPInput(ctx, msg, user, text)
+	default:
+		h.sendMainMenu(msg.Chat.ID, user.LanguageCode)
 	}
+}
+
+func (h *Handler) handleFullName(ctx context.Context, msg *tgbotapi.Message, user *domain.User, text string) {
+	name, _ := domain.ParseFullName(text)
 
-	if err := h.userService.UpdateUserState(ctx, user.TelegramID, domain.StateWaitGrade); err != nil {
+	if err := h.userService.UpdateFullName(ctx, user.TelegramID, name); err != nil {
+		h.logger.Error("❌ Failed to update full name", slog.Any("error", err))
+		h.sendMessage(msg.Chat.ID, i18n.Get(user.LanguageCode).Error)
 		return
 	}
 
-	h.sendMessageHTML(msg.Chat.ID, i18n.Get(user.LanguageCode).AskGrade)
+	h.advance(ctx, msg.Chat.ID, user)
 }
 
 func (h *Handler) handleGrade(ctx context.Context, msg *tgbotapi.Message, user *domain.User, text string) {
-	grade, err := strconv.Atoi(text)
-	if err != nil || grade < 1 || grade > 11 {
-		h.sendMessageHTML(msg.Chat.ID, i18n.Get(user.LanguageCode).InvalidGrade)
+	grade, _ := domain.ParseGrade(text)
+
+	// Sinf tahrirlanganda u avval kiritilgan tug'ilgan sanaga mos bo'lishi kerak
+	if err := h.fsm.CheckAgeForGrade(user.BirthDate, grade); err != nil {
+		h.sendMessageHTML(msg.Chat.ID, fmt.Sprintf(i18n.Get(user.LanguageCode).AgeGradeMismatch, grade))
+		h.sendStepPrompt(ctx, msg.Chat.ID, user)
 		return
 	}
 
@@ -325,13 +428,9 @@ func (h *Handler) handleGrade(ctx context.Context, msg *tgbotapi.Message, user *
 		h.sendMessage(msg.Chat.ID, i18n.Get(user.LanguageCode).Error)
 		return
 	}
+	user.Grade = grade
 
-	if err := h.userService.UpdateUserState(ctx, user.TelegramID, domain.StateWaitPhone); err != nil {
-		return
-	}
-
-	// Telefon so'rash - contact button bilan
-	h.sendPhoneRequest(msg.Chat.ID, user.LanguageCode)
+	h.advance(ctx, msg.Chat.ID, user)
 }
 
 func (h *Handler) sendPhoneRequest(chatID int64, langCode string) {
@@ -340,7 +439,10 @@ func (h *Handler) sendPhoneRequest(chatID int64, langCode string) {
 	// ReplyKeyboard bilan "Share Contact" tugmasi
 	keyboard := tgbotapi.NewReplyKeyboard(
 		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButtonContact("📱 Telefon raqamni ulashish"),
+			tgbotapi.NewKeyboardButtonContact(msgs.BtnShareContact),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(msgs.BtnBack),
 		),
 	)
 	keyboard.OneTimeKeyboard = true
@@ -353,129 +455,330 @@ func (h *Handler) sendPhoneRequest(chatID int64, langCode string) {
 }
 
 func (h *Handler) handlePhone(ctx context.Context, msg *tgbotapi.Message, user *domain.User, text string) {
-	phone := strings.NewReplacer(" ", "", "+", "", "-", "", "(", "", ")", "").Replace(text)
+	phone, _ := h.fsm.ParsePhone(text)
+	h.submitPhone(ctx, msg.Chat.ID, user, phone)
+}
 
-	if !phoneRegex.MatchString(phone) {
-		h.sendMessageHTML(msg.Chat.ID, i18n.Get(user.LanguageCode).InvalidPhone)
+// submitPhone stores the number and sends an OTP to it. While a verified user
+// changes their number it is only kept as pending until the OTP is confirmed.
+func (h *Handler) submitPhone(ctx context.Context, chatID int64, user *domain.User, phone string) {
+	// Isbotlanmagan raqamni SMS orqali qayta tasdiqlash mumkin
+	if user.IsVerified && phone == user.Phone && domain.ProvesPhone(user.VerificationMethod) {
+		h.finishEdit(ctx, chatID, user)
 		return
 	}
 
-	if err := h.userService.UpdatePhone(ctx, user.TelegramID, phone); err != nil {
-		h.sendMessage(msg.Chat.ID, i18n.Get(user.LanguageCode).Error)
+	owner, err := h.userService.PhoneConflict(ctx, user, phone)
+	if err != nil {
+		h.logger.Error("❌ Failed to check phone owner", slog.Any("error", err))
+		h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+	if owner != nil && h.userService.DuplicatePhonePolicy() == config.DuplicatePhoneReject {
+		h.sendMessageHTML(chatID, i18n.Get(user.LanguageCode).PhoneTaken)
+		h.sendPhoneRequest(chatID, user.LanguageCode)
 		return
 	}
 
-	updatedUser, _ := h.userService.GetUser(ctx, user.TelegramID)
+	if user.IsVerified {
+		err = h.userService.UpdatePendingPhone(ctx, user.TelegramID, phone)
+	} else {
+		err = h.userService.UpdatePhone(ctx, user.TelegramID, phone)
+	}
+	if err != nil {
+		h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
 
-	if err := h.otpService.GenerateAndSendOTP(ctx, updatedUser.ID, phone); err != nil {
-		h.logger.Error("❌ Failed to send OTP", slog.Any("error", err))
-		h.sendMessage(msg.Chat.ID, i18n.Get(user.LanguageCode).Error)
+	if err := h.otpService.GenerateAndSendOTP(ctx, user, phone); err != nil {
+		h.sendOTPError(ctx, chatID, user.LanguageCode, err)
 		return
 	}
 
-	h.userService.UpdateUserState(ctx, user.TelegramID, domain.StateWaitOTP)
+	if err := h.userService.Transition(ctx, user, domain.StateWaitOTP); err != nil {
+		h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
 
 	// Keyboard'ni olib tashlash
 	removeKeyboard := tgbotapi.NewRemoveKeyboard(true)
-	msgRemove := tgbotapi.NewMessage(msg.Chat.ID, "✅")
+	msgRemove := tgbotapi.NewMessage(chatID, "✅")
 	msgRemove.ReplyMarkup = removeKeyboard
 	h.bot.Send(msgRemove)
 
-	maskedPhone := phone[:6] + "****" + phone[len(phone)-2:]
-	h.sendOTPMessage(msg.Chat.ID, user.LanguageCode, maskedPhone)
+	h.sendOTPMessage(ctx, chatID, user.LanguageCode, phone)
 }
 
 func (h *Handler) handleOTPInput(ctx context.Context, message *tgbotapi.Message, user *domain.User, text string) {
 	currentUser, _ := h.userService.GetUser(ctx, user.TelegramID)
-	if currentUser == nil || currentUser.Phone == "" {
+	if currentUser == nil || currentUser.VerificationPhone() == "" {
 		h.sendMessage(message.Chat.ID, i18n.Get(user.LanguageCode).Error)
 		return
 	}
 
-	valid, _ := h.otpService.VerifyOTP(ctx, currentUser.Phone, strings.TrimSpace(text))
-	if !valid {
+	res, err := h.otpService.VerifyOTP(ctx, currentUser.ID, currentUser.VerificationPhone(), strings.TrimSpace(text))
+	if err != nil {
+		h.logger.Error("❌ Failed to verify OTP", slog.Any("error", err))
+		h.sendMessage(message.Chat.ID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+	if !res.Valid {
+		msgs := i18n.Get(user.LanguageCode)
+		if !res.LockedUntil.IsZero() {
+			h.sendMessageHTML(message.Chat.ID, fmt.Sprintf(msgs.OTPLocked, minutesUntil(res.LockedUntil)))
+			return
+		}
+
 		// Noto'g'ri kod - qayta yuborish tugmasi bilan
+		text := msgs.OTPCodeExpired
+		if res.AttemptsLeft > 0 {
+			text = fmt.Sprintf(msgs.InvalidOTP, res.AttemptsLeft)
+		}
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData(i18n.Get(user.LanguageCode).ResendOTP, CallbackResendOTP),
+				tgbotapi.NewInlineKeyboardButtonData(msgs.ResendOTP, CallbackResendOTP),
 			),
 		)
-		msg := tgbotapi.NewMessage(message.Chat.ID, i18n.Get(user.LanguageCode).InvalidOTP)
+		msg := tgbotapi.NewMessage(message.Chat.ID, text)
 		msg.ParseMode = tgbotapi.ModeHTML
 		msg.ReplyMarkup = keyboard
 		h.bot.Send(msg)
 		return
 	}
 
-	h.userService.VerifyUser(ctx, user.TelegramID)
+	// Ro'yxatdan o'tgan foydalanuvchi raqamini almashtirmoqda
+	if currentUser.IsVerified {
+		if err := h.userService.ConfirmPendingPhone(ctx, user.TelegramID, res.Method); err != nil {
+			h.logger.Error("❌ Failed to confirm new phone", slog.Any("error", err))
+			h.sendMessage(message.Chat.ID, i18n.Get(user.LanguageCode).Error)
+			return
+		}
+		if domain.ProvesPhone(res.Method) {
+			h.resolvePhoneConflict(ctx, message.Chat.ID, currentUser, currentUser.VerificationPhone())
+		}
+		h.finishEdit(ctx, message.Chat.ID, currentUser)
+		return
+	}
+
+	h.completeRegistration(ctx, message.Chat.ID, user, res.Method)
+}
+
+// completeRegistration marks the user verified and shows what they registered with
+func (h *Handler) completeRegistration(ctx context.Context, chatID int64, user *domain.User, method string) {
+	if err := h.userService.VerifyUser(ctx, user.TelegramID, method); err != nil {
+		h.logger.Error("❌ Failed to verify user", slog.Any("error", err))
+		h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+
+	// Isbotlanmagan raqam boshqa akkauntdan olib o'tilmaydi va bog'lanmaydi
+	if verified, _ := h.userService.GetUser(ctx, user.TelegramID); verified != nil && domain.ProvesPhone(method) {
+		h.resolvePhoneConflict(ctx, chatID, verified, verified.Phone)
+	}
 
 	finalUser, _ := h.userService.GetUser(ctx, user.TelegramID)
-	successMsg := fmt.Sprintf(i18n.Get(user.LanguageCode).RegistrationDone,
-		finalUser.FirstName, finalUser.LastName, finalUser.Region,
-		finalUser.District, finalUser.School, finalUser.Grade, finalUser.Phone)
-	h.sendMessageHTML(message.Chat.ID, successMsg)
+	msgs := i18n.Get(user.LanguageCode)
+	profile, _ := h.profileText(ctx, finalUser, msgs)
+	h.sendMessageHTML(chatID, msgs.RegistrationDone+"\n\n"+profile)
+
+	h.sendMainMenu(chatID, user.LanguageCode)
+}
+
+// resolvePhoneConflict applies the duplicate phone policy after the user proved
+// the number and tells both sides what happened
+func (h *Handler) resolvePhoneConflict(ctx context.Context, chatID int64, user *domain.User, number string) {
+	res, err := h.userService.ResolvePhoneConflict(ctx, user, number)
+	if err != nil {
+		h.logger.Error("❌ Failed to resolve duplicate phone", slog.Any("error", err))
+		return
+	}
 
-	h.sendMainMenu(message.Chat.ID, user.LanguageCode)
+	if res.LinkedTo != nil {
+		h.sendMessageHTML(chatID, i18n.Get(user.LanguageCode).PhoneLinked)
+	}
+	if res.Released != nil {
+		// Shaxsiy chatda chat ID telegram ID bilan bir xil
+		h.sendMessageHTML(res.Released.TelegramID,
+			fmt.Sprintf(i18n.Get(res.Released.LanguageCode).PhoneTransferred, phone.Mask(number)))
+		h.sendPhoneRequest(res.Released.TelegramID, res.Released.LanguageCode)
+	}
 }
 
 func (h *Handler) handleResendOTP(ctx context.Context, msg *tgbotapi.Message) {
 	user, _ := h.userService.GetUser(ctx, msg.From.ID)
-	if user == nil || user.Phone == "" || user.State != domain.StateWaitOTP {
+	if user == nil || user.VerificationPhone() == "" || user.State != domain.StateWaitOTP {
 		return
 	}
 
-	if err := h.otpService.GenerateAndSendOTP(ctx, user.ID, user.Phone); err != nil {
-		h.logger.Error("❌ Failed to resend OTP", slog.Any("error", err))
-		h.sendMessage(msg.Chat.ID, i18n.Get(user.LanguageCode).Error)
+	phone := user.VerificationPhone()
+	if err := h.otpService.GenerateAndSendOTP(ctx, user, phone); err != nil {
+		h.sendOTPError(ctx, msg.Chat.ID, user.LanguageCode, err)
 		return
 	}
 
-	maskedPhone := user.Phone[:6] + "****" + user.Phone[len(user.Phone)-2:]
-	h.sendOTPMessage(msg.Chat.ID, user.LanguageCode, maskedPhone)
+	h.sendOTPMessage(ctx, msg.Chat.ID, user.LanguageCode, phone)
 }
 
 func (h *Handler) handleResendOTPCallback(ctx context.Context, chatID int64, userID int64) {
 	user, _ := h.userService.GetUser(ctx, userID)
-	if user == nil || user.Phone == "" || user.State != domain.StateWaitOTP {
+	if user == nil || user.VerificationPhone() == "" || user.State != domain.StateWaitOTP {
 		return
 	}
 
-	if err := h.otpService.GenerateAndSendOTP(ctx, user.ID, user.Phone); err != nil {
-		h.logger.Error("❌ Failed to resend OTP", slog.Any("error", err))
-		h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+	phone := user.VerificationPhone()
+	if err := h.otpService.GenerateAndSendOTP(ctx, user, phone); err != nil {
+		h.sendOTPError(ctx, chatID, user.LanguageCode, err)
 		return
 	}
 
-	maskedPhone := user.Phone[:6] + "****" + user.Phone[len(user.Phone)-2:]
-	h.sendOTPMessage(chatID, user.LanguageCode, maskedPhone)
+	h.sendOTPMessage(ctx, chatID, user.LanguageCode, phone)
 }
 
-func (h *Handler) sendOTPMessage(chatID int64, langCode string, maskedPhone string) {
+// sendOTPError explains why no code was sent: a locked phone, the resend
+// cooldown and the SMS quotas get their own messages, anything else is a generic error
+func (h *Handler) sendOTPError(ctx context.Context, chatID int64, langCode string, err error) {
 	msgs := i18n.Get(langCode)
 
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(msgs.ResendOTP, CallbackResendOTP),
-		),
-	)
+	var locked *domain.OTPLockedError
+	var cooldown *domain.OTPCooldownError
+	switch {
+	case errors.As(err, &locked):
+		h.sendMessageHTML(chatID, fmt.Sprintf(msgs.OTPLocked, minutesUntil(locked.Until)))
+	case errors.As(err, &cooldown):
+		h.showResendCountdown(ctx, chatID, langCode, "", cooldown.Until)
+	case errors.Is(err, domain.ErrOTPDailyLimit):
+		h.sendMessageHTML(chatID, msgs.OTPDailyLimit)
+	case errors.Is(err, domain.ErrOTPGlobalLimit):
+		h.sendMessageHTML(chatID, msgs.OTPUnavailable)
+	default:
+		h.logger.Error("❌ Failed to send OTP", slog.Any("error", err))
+		h.sendMessage(chatID, msgs.Error)
+	}
+}
+
+// minutesUntil rounds up so a lockout never shows "0 minutes"
+func minutesUntil(t time.Time) int {
+	return max(int(math.Ceil(time.Until(t).Minutes())), 1)
+}
+
+func secondsUntil(t time.Time) int {
+	return max(int(math.Ceil(time.Until(t).Seconds())), 1)
+}
+
+// sendOTPMessage tells where the code went, showing the number masked
+func (h *Handler) sendOTPMessage(ctx context.Context, chatID int64, langCode string, number string) {
+	msgs := i18n.Get(langCode)
+
+	text := fmt.Sprintf(msgs.OTPSent, phone.Mask(number)) + "\n\n" + msgs.AskOTP
+	if cooldown := h.otpService.ResendCooldown(); cooldown > 0 {
+		h.showResendCountdown(ctx, chatID, langCode, text, time.Now().Add(cooldown))
+		return
+	}
 
-	text := fmt.Sprintf(msgs.OTPSent, maskedPhone) + "\n\n" + msgs.AskOTP
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = tgbotapi.ModeHTML
-	msg.ReplyMarkup = keyboard
+	msg.ReplyMarkup = resendKeyboard(langCode)
 	h.bot.Send(msg)
 }
 
-func (h *Handler) handleProfile(ctx context.Context, msg *tgbotapi.Message) {
-	user, _ := h.userService.GetUser(ctx, msg.From.ID)
-	if user == nil || !user.IsVerified {
-		h.sendMessage(msg.Chat.ID, i18n.Get("uz").NotRegistered)
+// resendCountdownTick is how often the resend countdown is edited; Telegram
+// rate-limits edits, so it does not tick every second
+const resendCountdownTick = 5 * time.Second
+
+// resendCountdown is the countdown running in a chat
+type resendCountdown struct {
+	cancel context.CancelFunc
+}
+
+// showResendCountdown sends text with the seconds left until a new code can be
+// requested, and edits the message until the cooldown ends. A chat has one
+// countdown: a new one retires the previous message, and all stop with ctx.
+func (h *Handler) showResendCountdown(ctx context.Context, chatID int64, langCode string, text string, until time.Time) {
+	msgs := i18n.Get(langCode)
+	keyboard := resendKeyboard(langCode)
+
+	render := func(line string) string {
+		if text == "" {
+			return line
+		}
+		return text + "\n\n" + line
+	}
+
+	cdCtx, cancel := context.WithCancel(ctx)
+	cd := &resendCountdown{cancel: cancel}
+	h.mu.Lock()
+	previous := h.countdowns[chatID]
+	h.countdowns[chatID] = cd
+	h.mu.Unlock()
+	if previous != nil {
+		previous.cancel()
+	}
+
+	msg := tgbotapi.NewMessage(chatID, render(fmt.Sprintf(msgs.OTPResendIn, secondsUntil(until))))
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = keyboard
+	sent, err := h.bot.Send(msg)
+	if err != nil {
+		h.endCountdown(chatID, cd)
 		return
 	}
 
-	profileMsg := fmt.Sprintf(i18n.Get(user.LanguageCode).RegistrationDone,
-		user.FirstName, user.LastName, user.Region, user.District, user.School, user.Grade, user.Phone)
-	h.sendMessageHTML(msg.Chat.ID, profileMsg)
+	go func() {
+		defer h.endCountdown(chatID, cd)
+
+		ticker := time.NewTicker(resendCountdownTick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-cdCtx.Done():
+				// Yangi hisoblagich boshlandi - eskisidagi soniyalar endi noto'g'ri
+				if ctx.Err() == nil {
+					h.retireCountdown(chatID, sent.MessageID, text, keyboard)
+				}
+				return
+			case <-ticker.C:
+			}
+
+			line := msgs.OTPResendReady
+			if time.Now().Before(until) {
+				line = fmt.Sprintf(msgs.OTPResendIn, secondsUntil(until))
+			}
+			h.sendOrEdit(chatID, sent.MessageID, render(line), keyboard)
+
+			if !time.Now().Before(until) {
+				return
+			}
+		}
+	}()
+}
+
+func (h *Handler) endCountdown(chatID int64, cd *resendCountdown) {
+	h.mu.Lock()
+	if h.countdowns[chatID] == cd {
+		delete(h.countdowns, chatID)
+	}
+	h.mu.Unlock()
+	cd.cancel()
+}
+
+// retireCountdown removes the countdown from a replaced message, or the whole
+// message when it was only the countdown
+func (h *Handler) retireCountdown(chatID int64, messageID int, text string, keyboard tgbotapi.InlineKeyboardMarkup) {
+	if text == "" {
+		h.bot.Request(tgbotapi.NewDeleteMessage(chatID, messageID))
+		return
+	}
+	h.sendOrEdit(chatID, messageID, text, keyboard)
+}
+
+func resendKeyboard(langCode string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.Get(langCode).ResendOTP, CallbackResendOTP),
+		),
+		backRow(langCode),
+	)
 }
 
 // ==================== ADMIN ====================
@@ -489,6 +792,42 @@ func (h *Handler) handleAdmin(ctx context.Context, msg *tgbotapi.Message) {
 	h.sendAdminPanel(ctx, msg.Chat.ID)
 }
 
+// handleFindUser searches registered users by name, e.g. "/find Karimov" or "/find Каримов"
+func (h *Handler) handleFindUser(ctx context.Context, msg *tgbotapi.Message) {
+	isAdmin, _ := h.adminRepo.IsAdmin(ctx, msg.From.ID)
+	if !isAdmin {
+		return
+	}
+
+	query := strings.TrimSpace(msg.CommandArguments())
+	if query == "" {
+		h.sendMessage(msg.Chat.ID, "🔎 Foydalanish: /find Ism yoki Familiya")
+		return
+	}
+
+	users, err := h.userService.SearchUsers(ctx, query)
+	if err != nil {
+		h.sendMessage(msg.Chat.ID, "❌ Xatolik: "+err.Error())
+		return
+	}
+
+	if len(users) == 0 {
+		h.sendMessage(msg.Chat.ID, "🔎 Hech kim topilmadi")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔎 <b>Topildi: %d</b>\n", len(users)))
+	for _, u := range users {
+		sb.WriteString(fmt.Sprintf("\n<b>%s %s</b> (%s %s)\n🏫 %s, %d-sinf\n📱 %s · <code>%d</code>\n",
+			html.EscapeString(u.FirstName), html.EscapeString(u.LastName),
+			html.EscapeString(u.FirstNameLatin), html.EscapeString(u.LastNameLatin),
+			html.EscapeString(u.School), u.Grade, u.Phone, u.TelegramID))
+	}
+
+	h.sendMessageHTML(msg.Chat.ID, sb.String())
+}
+
 func (h *Handler) sendAdminPanel(ctx context.Context, chatID int64) {
 	stats, _ := h.userService.GetStats(ctx)
 
@@ -501,15 +840,64 @@ func (h *Handler) sendAdminPanel(ctx context.Context, chatID int64) {
 📢 Faol kanallar: <b>%d</b>`,
 		stats.TotalUsers, stats.VerifiedUsers, stats.TodayUsers, stats.TotalChannels)
 
+	if len(stats.ByRegion) > 0 {
+		text += "\n\n🗺 <b>Viloyatlar bo'yicha:</b>"
+		for _, rs := range stats.ByRegion {
+			name := rs.Region
+			if rs.RegionID == 0 {
+				name = "Aniqlanmagan"
+			}
+			text += fmt.Sprintf("\n• %s: <b>%d</b>", name, rs.Users)
+		}
+	}
+
+	if rs, err := h.reminderRepo.GetStats(ctx); err == nil && rs.Sent > 0 {
+		text += fmt.Sprintf("\n\n🔔 <b>Eslatmalar:</b> %d ta yuborildi, %d kishiga"+
+			"\n↩️ Qaytib ro'yxatdan o'tdi: <b>%d</b>", rs.Sent, rs.RemindedUsers, rs.RecoveredUsers)
+	}
+
+	if ds, err := h.otpService.DeliveryStats(ctx, time.Now().AddDate(0, 0, -7)); err == nil && ds.Sent > 0 {
+		text += fmt.Sprintf("\n\n📨 <b>SMS (7 kun):</b> %d ta yuborildi"+
+			"\n✅ Yetkazildi: <b>%d</b> (%.0f%%), ❌ yetkazilmadi: <b>%d</b>",
+			ds.Sent, ds.Delivered, ds.SuccessRate()*100, ds.Failed)
+	}
+
+	obs, err := h.otpService.OutboxStats(ctx, time.Now().AddDate(0, 0, -7))
+	if err == nil && obs.Pending+obs.Dead > 0 {
+		text += fmt.Sprintf("\n📤 Navbatda: <b>%d</b>, 🪦 yuborib bo'lmadi: <b>%d</b>", obs.Pending, obs.Dead)
+	}
+
+	if h.smsRouter != nil {
+		text += "\n\n📡 <b>SMS provayderlar:</b>"
+		for _, ps := range h.smsRouter.Stats() {
+			icon := "🟢"
+			if !ps.Healthy {
+				icon = "🔴"
+			}
+			text += fmt.Sprintf("\n%s %s: %d ta yuborildi, %d ta xato", icon, ps.Name, ps.Sent, ps.Failed)
+			if !ps.Healthy {
+				text += fmt.Sprintf(" (%s gacha o'tkazib yuboriladi)", ps.DownUntil.Format("15:04:05"))
+			}
+		}
+	}
+
+	pendingSchools, _ := h.schoolService.CountPending(ctx)
+
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("📊 Statistika", CallbackAdminStats),
+			tgbotapi.NewInlineKeyboardButtonData("📉 Voronka", CallbackAdminFunnel),
 		),
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("➕ Kanal qo'shish", CallbackAdminAdd),
 			tgbotapi.NewInlineKeyboardButtonData("➖ Kanal o'chirish", CallbackAdminRemove),
 		),
 		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🏫 Maktablar (%d)", pendingSchools), CallbackAdminSchools),
+			tgbotapi.NewInlineKeyboardButtonData("👥 Dublikatlar", CallbackAdminDuplicates),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✉️ SMS shablonlari", CallbackAdminSMSTemplates),
 			tgbotapi.NewInlineKeyboardButtonData("📥 Excel yuklab olish", CallbackAdminExport),
 		),
 	)
@@ -572,12 +960,96 @@ func (h *Handler) handleCallback(ctx context.Context, callback *tgbotapi.Callbac
 		h.sendChannelList(ctx, callback.Message.Chat.ID)
 
 	case CallbackAdminExport:
-		h.exportToExcel(ctx, callback.Message.Chat.ID)
+		h.sendExportMenu(callback.Message.Chat.ID)
 
 	case CallbackAdminBack:
 		h.sendAdminPanel(ctx, callback.Message.Chat.ID)
 
+	case CallbackAdminFunnel:
+		h.handleFunnelCallback(ctx, callback)
+
+	case CallbackAdminDuplicates:
+		if isAdmin, _ := h.adminRepo.IsAdmin(ctx, callback.From.ID); isAdmin {
+			h.sendDuplicates(ctx, callback.Message.Chat.ID)
+		}
+
+	case CallbackAdminSMSTemplates:
+		if isAdmin, _ := h.adminRepo.IsAdmin(ctx, callback.From.ID); isAdmin {
+			h.sendSMSTemplates(ctx, callback.Message.Chat.ID, callback.From.LanguageCode)
+		}
+
+	case CallbackAdminSchools:
+		if isAdmin, _ := h.adminRepo.IsAdmin(ctx, callback.From.ID); isAdmin {
+			h.sendPendingSchools(ctx, callback.Message.Chat.ID)
+		}
+
+	case CallbackSchoolMissing:
+		h.handleSchoolMissingCallback(ctx, callback)
+
+	case CallbackEditName, CallbackEditLocation, CallbackEditGrade, CallbackEditPhone,
+		CallbackEditSubject, CallbackEditChild, CallbackEditBirthDate:
+		h.handleEditCallback(ctx, callback)
+
+	case CallbackAddChild:
+		h.handleAddChildCallback(ctx, callback)
+
+	case CallbackBack:
+		if user, _ := h.userService.GetUser(ctx, callback.From.ID); user != nil && user.State != domain.StateRegistered {
+			h.goBack(ctx, callback.Message.Chat.ID, user)
+		}
+
 	default:
+		switch {
+		case strings.HasPrefix(callback.Data, CallbackRole):
+			h.handleRoleCallback(ctx, callback)
+			return
+		case strings.HasPrefix(callback.Data, CallbackBirthYear):
+			h.handleBirthYearCallback(ctx, callback)
+			return
+		case strings.HasPrefix(callback.Data, CallbackBirthMonth):
+			h.handleBirthMonthCallback(ctx, callback)
+			return
+		case strings.HasPrefix(callback.Data, CallbackBirthDay):
+			h.handleBirthDayCallback(ctx, callback)
+			return
+		case strings.HasPrefix(callback.Data, CallbackChild):
+			h.handleChildCallback(ctx, callback)
+			return
+		case strings.HasPrefix(callback.Data, CallbackMergePhone):
+			h.handleMergePhoneCallback(ctx, callback)
+			return
+		case strings.HasPrefix(callback.Data, CallbackFunnelDays):
+			h.handleFunnelCallback(ctx, callback)
+			return
+		case strings.HasPrefix(callback.Data, CallbackExportRole):
+			if isAdmin, _ := h.adminRepo.IsAdmin(ctx, callback.From.ID); isAdmin {
+				h.exportToExcel(ctx, callback.Message.Chat.ID, strings.TrimPrefix(callback.Data, CallbackExportRole))
+			}
+			return
+		case strings.HasPrefix(callback.Data, CallbackRegionPage):
+			h.handleRegionPageCallback(ctx, callback)
+			return
+		case strings.HasPrefix(callback.Data, CallbackRegion):
+			h.handleRegionCallback(ctx, callback)
+			return
+		case strings.HasPrefix(callback.Data, CallbackDistrictPage):
+			h.handleDistrictPageCallback(ctx, callback)
+			return
+		case strings.HasPrefix(callback.Data, CallbackDistrict):
+			h.handleDistrictCallback(ctx, callback)
+			return
+		case strings.HasPrefix(callback.Data, CallbackSchoolPage):
+			h.handleSchoolPageCallback(ctx, callback)
+			return
+		case strings.HasPrefix(callback.Data, CallbackSchool):
+			h.handleSchoolCallback(ctx, callback)
+			return
+		case strings.HasPrefix(callback.Data, CallbackSchoolApprove),
+			strings.HasPrefix(callback.Data, CallbackSchoolReject):
+			h.handleSchoolReviewCallback(ctx, callback)
+			return
+		}
+
 		if strings.HasPrefix(callback.Data, CallbackDelChannel) {
 			idStr := strings.TrimPrefix(callback.Data, CallbackDelChannel)
 			id, _ := strconv.ParseInt(idStr, 10, 64)
@@ -622,8 +1094,53 @@ func (h *Handler) sendChannelList(ctx context.Context, chatID int64) {
 	h.bot.Send(msg)
 }
 
-func (h *Handler) exportToExcel(ctx context.Context, chatID int64) {
-	users, err := h.userService.GetAllVerified(ctx)
+// verificationMethodNames label how a phone number was confirmed in the export
+var verificationMethodNames = map[string]string{
+	domain.VerificationMethodOTP:     "SMS",
+	domain.VerificationMethodContact: "Telegram kontakt",
+	domain.VerificationMethodChatOTP: "Telegram xabar (raqam isbotlanmagan)",
+}
+
+// birthDateColumn is the 1-based position of "Tug'ilgan sana" in the export headers
+const birthDateColumn = 12
+
+// exportRoleNames are the role labels used in the admin panel and Excel export
+var exportRoleNames = map[string]string{
+	domain.RoleStudent: "O'quvchi",
+	domain.RoleTeacher: "O'qituvchi",
+	domain.RoleParent:  "Ota-ona",
+}
+
+func (h *Handler) sendExportMenu(chatID int64) {
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👥 Hammasi", CallbackExportRole+"all"),
+		),
+	}
+	for _, role := range domain.Roles {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(exportRoleNames[role], CallbackExportRole+role),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("⬅️ Orqaga", CallbackAdminBack),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, "📥 Kimlarni yuklab olamiz?")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.bot.Send(msg)
+}
+
+// exportToExcel sends verified users as an .xlsx file; role "all" exports everyone
+func (h *Handler) exportToExcel(ctx context.Context, chatID int64, role string) {
+	if role == "all" {
+		role = ""
+	}
+	if role != "" && !domain.IsValidRole(role) {
+		return
+	}
+
+	users, err := h.userService.GetVerifiedByRole(ctx, role)
 	if err != nil {
 		h.sendMessage(chatID, "❌ Xatolik: "+err.Error())
 		return
@@ -634,32 +1151,107 @@ func (h *Handler) exportToExcel(ctx context.Context, chatID int64) {
 	f.SetSheetName("Sheet1", sheet)
 
 	// Headers
-	headers := []string{"#", "Ism", "Familiya", "Viloyat", "Tuman", "Maktab", "Sinf", "Telefon", "Username", "Sana"}
+	headers := []string{"#", "Rol", "Ism", "Familiya", "Ism (lotin)", "Familiya (lotin)", "Farzand", "Viloyat", "Tuman", "Maktab", "Sinf", "Tug'ilgan sana", "Fan", "Telefon", "Tasdiqlash", "Username", "Sana"}
 	for i, h := range headers {
 		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
 		f.SetCellValue(sheet, cell, h)
 	}
 
+	// Ota-onalar har bir farzandi uchun alohida qatorda chiqadi
+	children, err := h.userService.GetChildrenOf(ctx, users)
+	if err != nil {
+		h.sendMessage(chatID, "❌ Xatolik: "+err.Error())
+		return
+	}
+
 	// Data
-	for i, u := range users {
-		row := i + 2
-		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), i+1)
-		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), u.FirstName)
-		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), u.LastName)
-		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), u.Region)
-		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), u.District)
-		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), u.School)
-		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), u.Grade)
-		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), u.Phone)
-		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), u.Username)
-		f.SetCellValue(sheet, fmt.Sprintf("J%d", row), u.CreatedAt.Format("02.01.2006"))
+	row := 1
+	writeRow := func(u domain.User, child string, region, district, school string, grade int, birthDate time.Time) {
+		row++
+		var gradeValue, birthDateValue any
+		if grade > 0 {
+			gradeValue = grade
+		}
+		// Excel sanani son sifatida saqlaydi, shunda saralash va filtrlash ishlaydi
+		if !birthDate.IsZero() {
+			birthDateValue = birthDate
+		}
+
+		values := []any{
+			row - 1, exportRoleNames[u.Role], u.FirstName, u.LastName, u.FirstNameLatin, u.LastNameLatin,
+			child, region, district, school, gradeValue, birthDateValue, u.Subject,
+			u.Phone, verificationMethodNames[u.VerificationMethod], u.Username, u.CreatedAt.Format("02.01.2006"),
+		}
+		for j, v := range values {
+			cell, _ := excelize.CoordinatesToCellName(j+1, row)
+			f.SetCellValue(sheet, cell, v)
+		}
+	}
+
+	for _, u := range users {
+		if u.Role != domain.RoleParent {
+			writeRow(u, "", u.Region, u.District, u.School, u.Grade, u.BirthDate)
+			continue
+		}
+		if len(children[u.ID]) == 0 {
+			writeRow(u, "", "", "", "", 0, time.Time{})
+		}
+		for _, c := range children[u.ID] {
+			writeRow(u, c.FullName(), c.Region, c.District, c.School, c.Grade, c.BirthDate)
+		}
+	}
+	lastCell, _ := excelize.CoordinatesToCellName(len(headers), row)
+
+	dateFormat := "dd.mm.yyyy"
+	if dateStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat}); err == nil && row > 1 {
+		col, _ := excelize.ColumnNumberToName(birthDateColumn)
+		f.SetCellStyle(sheet, col+"2", fmt.Sprintf("%s%d", col, row), dateStyle)
+	}
+	f.AutoFilter(sheet, "A1:"+lastCell, nil)
+
+	// Viloyatlar kesimida
+	if stats, err := h.userService.GetStats(ctx); err == nil {
+		regionSheet := "Viloyatlar"
+		f.NewSheet(regionSheet)
+		f.SetCellValue(regionSheet, "A1", "Viloyat")
+		f.SetCellValue(regionSheet, "B1", "Foydalanuvchilar")
+		for i, rs := range stats.ByRegion {
+			name := rs.Region
+			if rs.RegionID == 0 {
+				name = "Aniqlanmagan"
+			}
+			f.SetCellValue(regionSheet, fmt.Sprintf("A%d", i+2), name)
+			f.SetCellValue(regionSheet, fmt.Sprintf("B%d", i+2), rs.Users)
+		}
+	}
+
+	// Maktablar kesimida
+	if schoolStats, err := h.schoolService.GetStats(ctx); err == nil {
+		schoolSheet := "Maktablar"
+		f.NewSheet(schoolSheet)
+		for i, title := range []string{"Viloyat", "Tuman", "Maktab", "Foydalanuvchilar"} {
+			cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+			f.SetCellValue(schoolSheet, cell, title)
+		}
+		for i, st := range schoolStats {
+			row := i + 2
+			f.SetCellValue(schoolSheet, fmt.Sprintf("A%d", row), st.Region)
+			f.SetCellValue(schoolSheet, fmt.Sprintf("B%d", row), st.District)
+			f.SetCellValue(schoolSheet, fmt.Sprintf("C%d", row), st.School)
+			f.SetCellValue(schoolSheet, fmt.Sprintf("D%d", row), st.Users)
+		}
 	}
 
 	var buf bytes.Buffer
 	f.Write(&buf)
 
+	name := "users.xlsx"
+	if role != "" {
+		name = "users_" + role + ".xlsx"
+	}
+
 	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
-		Name:  "users.xlsx",
+		Name:  name,
 		Bytes: buf.Bytes(),
 	})
 	doc.Caption = fmt.Sprintf("📊 Jami %d ta foydalanuvchi", len(users))
@@ -0,0 +1,69 @@
+// internal/bot/keyboards.go
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const pickerPageSize = 10
+
+type keyboardItem struct {
+	Text string
+	Data string
+}
+
+// paginatedKeyboard lays items out in rows of `columns` buttons and appends
+// ◀️/▶️ navigation when there is more than one page
+func paginatedKeyboard(items []keyboardItem, page, columns int, pageData func(page int) string) tgbotapi.InlineKeyboardMarkup {
+	pages := (len(items) + pickerPageSize - 1) / pickerPageSize
+	if page < 0 || page >= pages {
+		page = 0
+	}
+
+	start := page * pickerPageSize
+	end := start + pickerPageSize
+	if end > len(items) {
+		end = len(items)
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, item := range items[start:end] {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(item.Text, item.Data))
+		if len(row) == columns {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	if pages > 1 {
+		var nav []tgbotapi.InlineKeyboardButton
+		if page > 0 {
+			nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("◀️", pageData(page-1)))
+		}
+		if page < pages-1 {
+			nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("▶️", pageData(page+1)))
+		}
+		rows = append(rows, nav)
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// sendOrEdit edits the given message in place, or sends a new one when messageID is 0
+func (h *Handler) sendOrEdit(chatID int64, messageID int, text string, keyboard tgbotapi.InlineKeyboardMarkup) {
+	if messageID != 0 {
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, keyboard)
+		edit.ParseMode = tgbotapi.ModeHTML
+		h.bot.Send(edit)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = keyboard
+	h.bot.Send(msg)
+}
@@ -0,0 +1,220 @@
+// internal/bot/location.go
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func (h *Handler) sendRegionPicker(ctx context.Context, chatID int64, messageID int, langCode string, page int) {
+	regions, err := h.locationService.GetRegions(ctx)
+	if err != nil || len(regions) == 0 {
+		h.logger.Error("❌ Failed to load regions", slog.Any("error", err))
+		h.sendMessage(chatID, i18n.Get(langCode).Error)
+		return
+	}
+
+	items := make([]keyboardItem, 0, len(regions))
+	for _, r := range regions {
+		items = append(items, keyboardItem{
+			Text: r.Name(langCode),
+			Data: CallbackRegion + strconv.FormatInt(r.ID, 10),
+		})
+	}
+
+	keyboard := paginatedKeyboard(items, page, 2, func(p int) string {
+		return CallbackRegionPage + strconv.Itoa(p)
+	})
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, backRow(langCode))
+	h.sendOrEdit(chatID, messageID, i18n.Get(langCode).AskLocation, keyboard)
+}
+
+func (h *Handler) sendDistrictPicker(ctx context.Context, chatID int64, messageID int, langCode string, region *domain.Region, page int) {
+	districts, err := h.locationService.GetDistricts(ctx, region.ID)
+	if err != nil || len(districts) == 0 {
+		h.logger.Error("❌ Failed to load districts",
+			slog.Int64("region_id", region.ID),
+			slog.Any("error", err))
+		h.sendMessage(chatID, i18n.Get(langCode).Error)
+		return
+	}
+
+	items := make([]keyboardItem, 0, len(districts))
+	for _, d := range districts {
+		items = append(items, keyboardItem{
+			Text: d.Name(langCode),
+			Data: CallbackDistrict + strconv.FormatInt(d.ID, 10),
+		})
+	}
+
+	keyboard := paginatedKeyboard(items, page, 2, func(p int) string {
+		return fmt.Sprintf("%s%d_%d", CallbackDistrictPage, region.ID, p)
+	})
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, backRow(langCode))
+	text := fmt.Sprintf(i18n.Get(langCode).AskDistrict, region.Name(langCode))
+	h.sendOrEdit(chatID, messageID, text, keyboard)
+}
+
+func (h *Handler) handleRegionCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || user.State != domain.StateWaitLocation {
+		return
+	}
+
+	id, _ := strconv.ParseInt(strings.TrimPrefix(callback.Data, CallbackRegion), 10, 64)
+	region, err := h.locationService.GetRegion(ctx, id)
+	if err != nil || region == nil {
+		return
+	}
+
+	loc := domain.Location{RegionID: region.ID, Region: region.NameUz}
+	if err := h.stageLocation(ctx, user, loc); err != nil {
+		h.logger.Error("❌ Failed to update region", slog.Any("error", err))
+		h.sendMessage(callback.Message.Chat.ID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+
+	if err := h.userService.Transition(ctx, user, domain.StateWaitDistrict); err != nil {
+		return
+	}
+
+	h.sendDistrictPicker(ctx, callback.Message.Chat.ID, callback.Message.MessageID, user.LanguageCode, region, 0)
+}
+
+func (h *Handler) handleDistrictCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || user.State != domain.StateWaitDistrict {
+		return
+	}
+	if !h.applyLocationDraft(ctx, callback.Message.Chat.ID, user) {
+		return
+	}
+
+	id, _ := strconv.ParseInt(strings.TrimPrefix(callback.Data, CallbackDistrict), 10, 64)
+	district, err := h.locationService.GetDistrict(ctx, id)
+	if err != nil || district == nil || district.RegionID != user.RegionID {
+		return
+	}
+
+	loc := domain.Location{
+		RegionID:   user.RegionID,
+		Region:     user.Region,
+		DistrictID: district.ID,
+		District:   district.NameUz,
+	}
+	if err := h.stageLocation(ctx, user, loc); err != nil {
+		h.logger.Error("❌ Failed to update district", slog.Any("error", err))
+		h.sendMessage(callback.Message.Chat.ID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+
+	if err := h.userService.Transition(ctx, user, domain.StateWaitSchool); err != nil {
+		return
+	}
+
+	// Tanlovni xabarda qoldiramiz, tugmalarni olib tashlaymiz
+	region, _ := h.locationService.GetRegion(ctx, user.RegionID)
+	summary := "📍 " + district.Name(user.LanguageCode)
+	if region != nil {
+		summary = "📍 " + region.Name(user.LanguageCode) + ", " + district.Name(user.LanguageCode)
+	}
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, summary)
+	h.bot.Send(edit)
+
+	user.DistrictID = district.ID
+	h.sendSchoolPicker(ctx, callback.Message.Chat.ID, 0, user, 0)
+}
+
+func (h *Handler) handleRegionPageCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || user.State != domain.StateWaitLocation {
+		return
+	}
+
+	page, _ := strconv.Atoi(strings.TrimPrefix(callback.Data, CallbackRegionPage))
+	h.sendRegionPicker(ctx, callback.Message.Chat.ID, callback.Message.MessageID, user.LanguageCode, page)
+}
+
+func (h *Handler) handleDistrictPageCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || user.State != domain.StateWaitDistrict {
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(callback.Data, CallbackDistrictPage), "_")
+	if len(parts) != 2 {
+		return
+	}
+	regionID, _ := strconv.ParseInt(parts[0], 10, 64)
+	page, _ := strconv.Atoi(parts[1])
+
+	region, _ := h.locationService.GetRegion(ctx, regionID)
+	if region == nil {
+		return
+	}
+
+	h.sendDistrictPicker(ctx, callback.Message.Chat.ID, callback.Message.MessageID, user.LanguageCode, region, page)
+}
+
+// stageLocation saves one step of the region, district and school choice.
+// A registered user editing their location gets a draft instead, so the
+// profile is never left with a region but no district or school: the draft
+// is saved together with the school, and dropped on back or /cancel.
+func (h *Handler) stageLocation(ctx context.Context, user *domain.User, loc domain.Location) error {
+	if !user.IsVerified {
+		return h.userService.UpdateLocation(ctx, user.TelegramID, loc)
+	}
+
+	h.mu.Lock()
+	h.locationDrafts[user.TelegramID] = loc
+	h.mu.Unlock()
+	return nil
+}
+
+// applyLocationDraft shows the location being edited on user in place of the
+// saved one. Drafts live only in memory, so an edit that a restart left past
+// the region step starts over from the region picker; false means the user
+// has been sent there.
+func (h *Handler) applyLocationDraft(ctx context.Context, chatID int64, user *domain.User) bool {
+	if !user.IsVerified {
+		return true
+	}
+
+	h.mu.RLock()
+	loc, ok := h.locationDrafts[user.TelegramID]
+	h.mu.RUnlock()
+	if ok {
+		user.RegionID, user.Region = loc.RegionID, loc.Region
+		user.DistrictID, user.District = loc.DistrictID, loc.District
+		return true
+	}
+
+	switch user.State {
+	case domain.StateWaitDistrict, domain.StateWaitSchool, domain.StateWaitSchoolName:
+	default:
+		return true
+	}
+
+	// Qoralama yo'qolgan: saqlangan hudud bilan davom etmaymiz, viloyatdan qayta boshlaymiz
+	if err := h.userService.Transition(ctx, user, domain.StateWaitLocation); err != nil {
+		h.logger.Error("❌ Failed to restart location edit", slog.Any("error", err))
+		h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+		return false
+	}
+	h.sendRegionPicker(ctx, chatID, 0, user.LanguageCode, 0)
+	return false
+}
+
+func (h *Handler) dropLocationDraft(telegramID int64) {
+	h.mu.Lock()
+	delete(h.locationDrafts, telegramID)
+	h.mu.Unlock()
+}
@@ -0,0 +1,34 @@
+// internal/bot/otp_deliverer.go
+package bot
+
+import (
+	"context"
+	"strconv"
+
+	"khisobot/internal/domain"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TelegramDeliverer sends the OTP to the user's own Telegram chat. It does not
+// prove the user owns the phone, so it is meant as a fallback when SMS fails.
+type TelegramDeliverer struct {
+	bot *tgbotapi.BotAPI
+}
+
+func NewTelegramDeliverer(bot *tgbotapi.BotAPI) *TelegramDeliverer {
+	return &TelegramDeliverer{bot: bot}
+}
+
+func (d *TelegramDeliverer) Channel() string {
+	return domain.OTPChannelTelegram
+}
+
+func (d *TelegramDeliverer) Deliver(_ context.Context, msg domain.OTPMessage) (string, error) {
+	// Shaxsiy chatda chat ID telegram ID bilan bir xil
+	sent, err := d.bot.Send(tgbotapi.NewMessage(msg.TelegramID, msg.Text))
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(sent.MessageID), nil
+}
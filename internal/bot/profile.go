@@ -0,0 +1,252 @@
+// internal/bot/profile.go
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// formatProfile renders the user's registration data, one labelled line per field.
+// Parents get their children listed instead of a school and grade of their own.
+func formatProfile(user *domain.User, children []domain.Participant, msgs i18n.Messages) string {
+	var sb strings.Builder
+
+	line := func(label, value string) {
+		sb.WriteString(fmt.Sprintf("%s: <b>%s</b>\n", label, html.EscapeString(value)))
+	}
+
+	line(msgs.LabelRole, roleName(msgs, user.Role))
+	line(msgs.LabelFirstName, user.FirstName)
+	line(msgs.LabelLastName, user.LastName)
+
+	if user.Role == domain.RoleParent {
+		line(msgs.LabelPhone, user.Phone)
+
+		sb.WriteString("\n" + msgs.LabelChildren + "\n")
+		if len(children) == 0 {
+			sb.WriteString(msgs.NoChildren + "\n")
+		}
+		for i, c := range children {
+			sb.WriteString(fmt.Sprintf(msgs.ChildLine, i+1,
+				html.EscapeString(c.FullName()), html.EscapeString(c.School), c.Grade, formatDate(c.BirthDate)) + "\n")
+		}
+		return strings.TrimSuffix(sb.String(), "\n")
+	}
+
+	line(msgs.LabelRegion, user.Region)
+	line(msgs.LabelDistrict, user.District)
+	line(msgs.LabelSchool, user.School)
+	if user.Role == domain.RoleTeacher {
+		line(msgs.LabelSubject, user.Subject)
+	} else {
+		line(msgs.LabelGrade, fmt.Sprintf("%d", user.Grade))
+		line(msgs.LabelBirthDate, formatDate(user.BirthDate))
+	}
+	line(msgs.LabelPhone, user.Phone)
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// formatDate shows a birth date as DD.MM.YYYY, or a dash for users registered before it was asked
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	return t.Format("02.01.2006")
+}
+
+func (h *Handler) handleProfile(ctx context.Context, msg *tgbotapi.Message) {
+	user, _ := h.userService.GetUser(ctx, msg.From.ID)
+	if user == nil || !user.IsVerified {
+		lang := "uz"
+		if user != nil {
+			lang = user.LanguageCode
+		}
+		h.sendMessage(msg.Chat.ID, i18n.Get(lang).NotRegistered)
+		return
+	}
+
+	h.sendProfile(ctx, msg.Chat.ID, user)
+}
+
+// profileText is the profile body, with the children loaded for parents
+func (h *Handler) profileText(ctx context.Context, user *domain.User, msgs i18n.Messages) (string, []domain.Participant) {
+	var children []domain.Participant
+	if user.Role == domain.RoleParent {
+		var err error
+		children, err = h.userService.GetChildren(ctx, user.OwnerID())
+		if err != nil {
+			h.logger.Error("❌ Failed to load children", slog.Any("error", err))
+		}
+	}
+	return formatProfile(user, children, msgs), children
+}
+
+func (h *Handler) sendProfile(ctx context.Context, chatID int64, user *domain.User) {
+	msgs := i18n.Get(user.LanguageCode)
+	text, children := h.profileText(ctx, user, msgs)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	switch user.Role {
+	case domain.RoleParent:
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(msgs.BtnEditName, CallbackEditName),
+			tgbotapi.NewInlineKeyboardButtonData(msgs.BtnEditPhone, CallbackEditPhone),
+		))
+		for _, c := range children {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("👶 "+c.FullName(), CallbackChild+strconv.FormatInt(c.ID, 10)),
+			))
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(msgs.BtnAddChild, CallbackAddChild),
+		))
+	default:
+		// O'qituvchida sinf o'rniga fan tahrirlanadi
+		roleButton := tgbotapi.NewInlineKeyboardButtonData(msgs.BtnEditGrade, CallbackEditGrade)
+		if user.Role == domain.RoleTeacher {
+			roleButton = tgbotapi.NewInlineKeyboardButtonData(msgs.BtnEditSubject, CallbackEditSubject)
+		}
+		rows = append(rows,
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(msgs.BtnEditName, CallbackEditName),
+				tgbotapi.NewInlineKeyboardButtonData(msgs.BtnEditLocation, CallbackEditLocation),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				roleButton,
+				tgbotapi.NewInlineKeyboardButtonData(msgs.BtnEditPhone, CallbackEditPhone),
+			),
+		)
+		if user.Role == domain.RoleStudent {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(msgs.BtnEditBirthDate, CallbackEditBirthDate),
+			))
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, msgs.ProfileTitle+"\n\n"+text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.bot.Send(msg)
+}
+
+// handleChildCallback makes the chosen child active and offers its fields for editing
+func (h *Handler) handleChildCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || !user.IsVerified || user.Role != domain.RoleParent || user.State != domain.StateRegistered {
+		return
+	}
+
+	id, _ := strconv.ParseInt(strings.TrimPrefix(callback.Data, CallbackChild), 10, 64)
+	if err := h.userService.SelectChild(ctx, user, id); err != nil {
+		h.logger.Warn("⚠️ Failed to select child", slog.Any("error", err))
+		return
+	}
+
+	user, _ = h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil {
+		return
+	}
+
+	msgs := i18n.Get(user.LanguageCode)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(msgs.BtnEditName, CallbackEditChild),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(msgs.BtnEditLocation, CallbackEditLocation),
+			tgbotapi.NewInlineKeyboardButtonData(msgs.BtnEditGrade, CallbackEditGrade),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(msgs.BtnEditBirthDate, CallbackEditBirthDate),
+		),
+	)
+
+	text := fmt.Sprintf(msgs.ChildTitle, html.EscapeString(user.ChildName))
+	h.sendOrEdit(callback.Message.Chat.ID, 0, text, keyboard)
+}
+
+func (h *Handler) handleAddChildCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || !user.IsVerified || user.Role != domain.RoleParent {
+		return
+	}
+
+	if err := h.userService.StartAddChild(ctx, user); err != nil {
+		h.sendMessage(callback.Message.Chat.ID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+
+	h.sendStepPrompt(ctx, callback.Message.Chat.ID, user)
+}
+
+// handleEditCallback puts a registered user back into the state of a single field.
+// Buttons of an old profile message are ignored while another edit, e.g. a
+// phone change waiting for its code, is still open.
+func (h *Handler) handleEditCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || !user.IsVerified || user.State != domain.StateRegistered {
+		return
+	}
+
+	var state string
+	switch callback.Data {
+	case CallbackEditName:
+		state = domain.StateWaitFullName
+	case CallbackEditLocation:
+		state = domain.StateWaitLocation
+	case CallbackEditGrade:
+		state = domain.StateWaitGrade
+	case CallbackEditPhone:
+		state = domain.StateWaitPhone
+	case CallbackEditSubject:
+		state = domain.StateWaitSubject
+	case CallbackEditChild:
+		state = domain.StateWaitChildName
+	case CallbackEditBirthDate:
+		state = domain.StateWaitBirthDate
+	default:
+		return
+	}
+
+	// Ota-ona maktab va sinfni faqat tanlangan farzand uchun o'zgartiradi
+	if user.Role == domain.RoleParent && state != domain.StateWaitFullName && state != domain.StateWaitPhone &&
+		user.ActiveParticipantID == 0 {
+		h.sendProfile(ctx, callback.Message.Chat.ID, user)
+		return
+	}
+
+	if err := h.userService.Transition(ctx, user, state); err != nil {
+		h.sendMessage(callback.Message.Chat.ID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+
+	h.dropLocationDraft(user.TelegramID)
+	h.sendStepPrompt(ctx, callback.Message.Chat.ID, user)
+}
+
+// finishEdit returns a verified user to the registered state and shows the updated profile
+func (h *Handler) finishEdit(ctx context.Context, chatID int64, user *domain.User) {
+	if err := h.userService.Transition(ctx, user, domain.StateRegistered); err != nil {
+		h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+
+	updated, _ := h.userService.GetUser(ctx, user.TelegramID)
+	if updated == nil {
+		return
+	}
+
+	h.sendMessageHTML(chatID, i18n.Get(updated.LanguageCode).ProfileUpdated)
+	h.sendProfile(ctx, chatID, updated)
+}
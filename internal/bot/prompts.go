@@ -0,0 +1,21 @@
+// internal/bot/prompts.go
+package bot
+
+import (
+	"khisobot/internal/domain"
+	"khisobot/pkg/i18n"
+)
+
+// stepPrompt is the question the FSM names for the user's current step.
+// Picker steps build their own keyboards around it.
+func (h *Handler) stepPrompt(msgs i18n.Messages, user *domain.User) string {
+	return msgs.Text(h.fsm.PromptKey(user))
+}
+
+// invalidInput is shown when the FSM rejects text typed at a step
+func (h *Handler) invalidInput(msgs i18n.Messages, state string) string {
+	if text := msgs.Text(h.fsm.InvalidKey(state)); text != "" {
+		return text
+	}
+	return msgs.Error
+}
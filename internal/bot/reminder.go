@@ -0,0 +1,41 @@
+// internal/bot/reminder.go
+package bot
+
+import (
+	"context"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SendReminder nudges a user who stopped on a registration step and asks that
+// step again. Users who moved on since the reminder was scheduled are skipped.
+func (h *Handler) SendReminder(ctx context.Context, telegramID int64, state string) error {
+	user, err := h.userService.GetUser(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.IsVerified || user.State != state {
+		return nil
+	}
+
+	msgs := i18n.Get(user.LanguageCode)
+	text := msgs.ReminderNudge
+	if state == domain.StateWaitOTP {
+		text = msgs.ReminderNudgeOTP
+	}
+
+	// Shaxsiy chatda chat ID telegram ID bilan bir xil
+	msg := tgbotapi.NewMessage(telegramID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	if _, err := h.bot.Send(msg); err != nil {
+		return err
+	}
+
+	if state != domain.StateWaitOTP {
+		h.sendStepPrompt(ctx, telegramID, user)
+	}
+	return nil
+}
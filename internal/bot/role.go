@@ -0,0 +1,89 @@
+// internal/bot/role.go
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// roleName is the localized label of a participant role
+func roleName(msgs i18n.Messages, role string) string {
+	switch role {
+	case domain.RoleTeacher:
+		return msgs.RoleTeacher
+	case domain.RoleParent:
+		return msgs.RoleParent
+	case domain.RoleStudent:
+		return msgs.RoleStudent
+	}
+	return role
+}
+
+func (h *Handler) sendRolePicker(chatID int64, langCode string) {
+	msgs := i18n.Get(langCode)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, role := range domain.Roles {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(roleName(msgs, role), CallbackRole+role),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, msgs.AskRole)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.bot.Send(msg)
+}
+
+func (h *Handler) handleRoleCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || user.State != domain.StateWaitRole {
+		return
+	}
+
+	role := strings.TrimPrefix(callback.Data, CallbackRole)
+	if err := h.userService.UpdateRole(ctx, user.TelegramID, role); err != nil {
+		h.logger.Error("❌ Failed to update role", slog.Any("error", err))
+		h.sendMessage(callback.Message.Chat.ID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+	user.Role = role
+
+	// Tanlovni xabarda qoldiramiz, tugmalarni olib tashlaymiz
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID,
+		roleName(i18n.Get(user.LanguageCode), role))
+	h.bot.Send(edit)
+
+	h.advance(ctx, callback.Message.Chat.ID, user)
+}
+
+func (h *Handler) handleSubject(ctx context.Context, msg *tgbotapi.Message, user *domain.User, text string) {
+	subject, _ := domain.ParseSubject(text)
+
+	if err := h.userService.UpdateSubject(ctx, user.TelegramID, subject); err != nil {
+		h.sendMessage(msg.Chat.ID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+	user.Subject = subject
+
+	h.advance(ctx, msg.Chat.ID, user)
+}
+
+func (h *Handler) handleChildName(ctx context.Context, msg *tgbotapi.Message, user *domain.User, text string) {
+	name, _ := domain.ParseFullName(text)
+
+	if err := h.userService.UpdateChildName(ctx, user, name); err != nil {
+		h.logger.Error("❌ Failed to save child name", slog.Any("error", err))
+		h.sendMessage(msg.Chat.ID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+	user.ChildName = name.First + " " + name.Last
+
+	h.advance(ctx, msg.Chat.ID, user)
+}
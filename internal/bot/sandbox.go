@@ -0,0 +1,44 @@
+// internal/bot/sandbox.go
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sandboxListSize keeps the list within Telegram's message length limit
+const sandboxListSize = 10
+
+// handleSandbox shows the SMS the sandbox pretended to send, e.g. "/sandbox"
+// or "/sandbox 901234567" for one number
+func (h *Handler) handleSandbox(ctx context.Context, msg *tgbotapi.Message) {
+	isAdmin, _ := h.adminRepo.IsAdmin(ctx, msg.From.ID)
+	if !isAdmin {
+		return
+	}
+
+	if h.sandbox == nil {
+		h.sendMessage(msg.Chat.ID, "🧪 SMS sandbox o'chirilgan: SMS'lar haqiqiy shlyuz orqali yuboriladi")
+		return
+	}
+
+	filter := strings.TrimPrefix(strings.TrimSpace(msg.CommandArguments()), "+")
+	messages := h.sandbox.Messages(filter, sandboxListSize)
+	if len(messages) == 0 {
+		h.sendMessage(msg.Chat.ID, "🧪 Sandbox'da SMS yo'q")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🧪 <b>Sandbox SMS</b> (oxirgi %d ta)\n", len(messages)))
+	for _, m := range messages {
+		sb.WriteString(fmt.Sprintf("\n📱 <b>%s</b> · <code>%d</code> · %s\n%s\n",
+			m.Phone, m.TelegramID, m.SentAt.Format("02.01 15:04:05"), html.EscapeString(m.Text)))
+	}
+
+	h.sendMessageHTML(msg.Chat.ID, sb.String())
+}
@@ -0,0 +1,251 @@
+// internal/bot/school.go
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func (h *Handler) sendSchoolPicker(ctx context.Context, chatID int64, messageID int, user *domain.User, page int) {
+	schools, err := h.schoolService.GetByDistrict(ctx, user.DistrictID)
+	if err != nil {
+		h.logger.Error("❌ Failed to load schools",
+			slog.Int64("district_id", user.DistrictID),
+			slog.Any("error", err))
+		h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+
+	items := make([]keyboardItem, 0, len(schools))
+	for _, s := range schools {
+		items = append(items, keyboardItem{
+			Text: s.Name,
+			Data: CallbackSchool + strconv.FormatInt(s.ID, 10),
+		})
+	}
+
+	keyboard := paginatedKeyboard(items, page, 2, func(p int) string {
+		return CallbackSchoolPage + strconv.Itoa(p)
+	})
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard,
+		h.schoolMissingRow(user.LanguageCode),
+		backRow(user.LanguageCode),
+	)
+
+	h.sendOrEdit(chatID, messageID, i18n.Get(user.LanguageCode).AskSchool, keyboard)
+}
+
+func (h *Handler) schoolMissingRow(langCode string) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.Get(langCode).BtnSchoolMissing, CallbackSchoolMissing),
+	)
+}
+
+// handleSchool treats typed text as a search by school number or name
+func (h *Handler) handleSchool(ctx context.Context, msg *tgbotapi.Message, user *domain.User, text string) {
+	msgs := i18n.Get(user.LanguageCode)
+
+	schools, err := h.schoolService.Search(ctx, user.DistrictID, text)
+	if err != nil {
+		h.logger.Error("❌ Failed to search schools", slog.Any("error", err))
+		h.sendMessage(msg.Chat.ID, msgs.Error)
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, s := range schools {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(s.Name, CallbackSchool+strconv.FormatInt(s.ID, 10)),
+		))
+	}
+	rows = append(rows, h.schoolMissingRow(user.LanguageCode), backRow(user.LanguageCode))
+
+	text = msgs.SchoolResults
+	if len(schools) == 0 {
+		text = msgs.SchoolNotFound
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ParseMode = tgbotapi.ModeHTML
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.bot.Send(reply)
+}
+
+func (h *Handler) handleSchoolCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || user.State != domain.StateWaitSchool {
+		return
+	}
+	if !h.applyLocationDraft(ctx, callback.Message.Chat.ID, user) {
+		return
+	}
+
+	id, _ := strconv.ParseInt(strings.TrimPrefix(callback.Data, CallbackSchool), 10, 64)
+	school, err := h.schoolService.GetSchool(ctx, id)
+	if err != nil || school == nil || school.Status != domain.SchoolStatusApproved || school.DistrictID != user.DistrictID {
+		return
+	}
+
+	if !h.saveSchool(ctx, callback.Message.Chat.ID, user, school) {
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, "🏫 "+school.Name)
+	h.bot.Send(edit)
+
+	user.SchoolID = school.ID
+	h.advance(ctx, callback.Message.Chat.ID, user)
+}
+
+func (h *Handler) handleSchoolPageCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || user.State != domain.StateWaitSchool {
+		return
+	}
+	if !h.applyLocationDraft(ctx, callback.Message.Chat.ID, user) {
+		return
+	}
+
+	page, _ := strconv.Atoi(strings.TrimPrefix(callback.Data, CallbackSchoolPage))
+	h.sendSchoolPicker(ctx, callback.Message.Chat.ID, callback.Message.MessageID, user, page)
+}
+
+func (h *Handler) handleSchoolMissingCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, _ := h.userService.GetUser(ctx, callback.From.ID)
+	if user == nil || user.State != domain.StateWaitSchool {
+		return
+	}
+	if !h.applyLocationDraft(ctx, callback.Message.Chat.ID, user) {
+		return
+	}
+
+	if err := h.userService.Transition(ctx, user, domain.StateWaitSchoolName); err != nil {
+		return
+	}
+
+	h.sendWithBack(callback.Message.Chat.ID, user.LanguageCode, i18n.Get(user.LanguageCode).AskSchoolName)
+}
+
+// handleSchoolName registers a school that is not in the directory yet
+func (h *Handler) handleSchoolName(ctx context.Context, msg *tgbotapi.Message, user *domain.User, text string) {
+	school, err := h.schoolService.SubmitPending(ctx, user.DistrictID, text, user.TelegramID)
+	if err != nil {
+		h.logger.Error("❌ Failed to submit school", slog.Any("error", err))
+		h.sendMessage(msg.Chat.ID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+
+	if !h.saveSchool(ctx, msg.Chat.ID, user, school) {
+		return
+	}
+
+	h.sendMessageHTML(msg.Chat.ID, i18n.Get(user.LanguageCode).SchoolSubmitted)
+	user.SchoolID = school.ID
+	h.advance(ctx, msg.Chat.ID, user)
+}
+
+func (h *Handler) saveSchool(ctx context.Context, chatID int64, user *domain.User, school *domain.School) bool {
+	loc := domain.Location{
+		RegionID:   user.RegionID,
+		Region:     user.Region,
+		DistrictID: user.DistrictID,
+		District:   user.District,
+		SchoolID:   school.ID,
+		School:     school.Name,
+	}
+	if err := h.userService.UpdateLocation(ctx, user.TelegramID, loc); err != nil {
+		h.logger.Error("❌ Failed to update school", slog.Any("error", err))
+		h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+		return false
+	}
+
+	h.dropLocationDraft(user.TelegramID)
+	return true
+}
+
+// ==================== ADMIN: SCHOOL REVIEW ====================
+
+func (h *Handler) sendPendingSchools(ctx context.Context, chatID int64) {
+	schools, err := h.schoolService.GetPending(ctx)
+	if err != nil {
+		h.sendMessage(chatID, "❌ Xatolik: "+err.Error())
+		return
+	}
+
+	if len(schools) == 0 {
+		h.sendMessage(chatID, "🏫 Ko'rib chiqilishi kerak bo'lgan maktablar yo'q")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🏫 <b>Tasdiqlanmagan maktablar:</b>\n")
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, s := range schools {
+		sb.WriteString(fmt.Sprintf("\n<b>#%d</b> %s\n<i>%s, %s</i>\n", s.ID, html.EscapeString(s.Name), s.Region, s.District))
+
+		id := strconv.FormatInt(s.ID, 10)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ #"+id, CallbackSchoolApprove+id),
+			tgbotapi.NewInlineKeyboardButtonData("❌ #"+id, CallbackSchoolReject+id),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("⬅️ Orqaga", CallbackAdminBack),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.bot.Send(msg)
+}
+
+func (h *Handler) handleSchoolReviewCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	isAdmin, _ := h.adminRepo.IsAdmin(ctx, callback.From.ID)
+	if !isAdmin {
+		return
+	}
+
+	chatID := callback.Message.Chat.ID
+	approve := strings.HasPrefix(callback.Data, CallbackSchoolApprove)
+
+	idStr := strings.TrimPrefix(strings.TrimPrefix(callback.Data, CallbackSchoolApprove), CallbackSchoolReject)
+	id, _ := strconv.ParseInt(idStr, 10, 64)
+
+	if !approve {
+		if _, err := h.schoolService.Reject(ctx, id); err != nil {
+			h.sendMessage(chatID, "❌ Xatolik: "+err.Error())
+			return
+		}
+		h.sendMessage(chatID, fmt.Sprintf("❌ Maktab #%d rad etildi", id))
+		h.sendPendingSchools(ctx, chatID)
+		return
+	}
+
+	school, err := h.schoolService.Approve(ctx, id)
+	if err != nil {
+		h.sendMessage(chatID, "❌ Xatolik: "+err.Error())
+		return
+	}
+	h.sendMessage(chatID, fmt.Sprintf("✅ Maktab #%d tasdiqlandi", id))
+
+	// Maktabni taklif qilgan foydalanuvchiga xabar beramiz
+	if school.SubmittedBy != 0 {
+		lang := "uz"
+		if user, _ := h.userService.GetUser(ctx, school.SubmittedBy); user != nil {
+			lang = user.LanguageCode
+		}
+		h.sendMessageHTML(school.SubmittedBy, fmt.Sprintf(i18n.Get(lang).SchoolApproved, html.EscapeString(school.Name)))
+	}
+
+	h.sendPendingSchools(ctx, chatID)
+}
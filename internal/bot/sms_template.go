@@ -0,0 +1,108 @@
+// internal/bot/sms_template.go
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/i18n"
+	"khisobot/pkg/sms"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleSMSTemplate shows or changes the OTP SMS templates, e.g.
+// "/smstemplate", "/smstemplate ru Код: {code}" or "/smstemplate ru reset"
+func (h *Handler) handleSMSTemplate(ctx context.Context, msg *tgbotapi.Message) {
+	isAdmin, _ := h.adminRepo.IsAdmin(ctx, msg.From.ID)
+	if !isAdmin {
+		return
+	}
+
+	usage := i18n.Get(msg.From.LanguageCode).SMSTemplateUsage
+	args := strings.SplitN(strings.TrimSpace(msg.CommandArguments()), " ", 2)
+	if args[0] == "" {
+		h.sendSMSTemplates(ctx, msg.Chat.ID, msg.From.LanguageCode)
+		return
+	}
+	if len(args) < 2 {
+		h.sendMessage(msg.Chat.ID, usage)
+		return
+	}
+
+	lang, template := args[0], strings.TrimSpace(args[1])
+	if template == "reset" {
+		if err := h.otpService.ResetSMSTemplate(ctx, lang); err != nil {
+			h.sendSMSTemplateError(msg.Chat.ID, err, sms.Segment{})
+			return
+		}
+		h.sendMessage(msg.Chat.ID, "✅ Standart shablon tiklandi: "+lang)
+		h.sendSMSTemplates(ctx, msg.Chat.ID, msg.From.LanguageCode)
+		return
+	}
+
+	segment, err := h.otpService.SetSMSTemplate(ctx, lang, template, msg.From.ID)
+	if err != nil {
+		h.sendSMSTemplateError(msg.Chat.ID, err, segment)
+		return
+	}
+	h.sendMessage(msg.Chat.ID, "✅ Shablon saqlandi: "+lang)
+	h.sendSMSTemplates(ctx, msg.Chat.ID, msg.From.LanguageCode)
+}
+
+func (h *Handler) sendSMSTemplateError(chatID int64, err error, segment sms.Segment) {
+	switch {
+	case errors.Is(err, domain.ErrSMSTemplateLanguage):
+		h.sendMessage(chatID, "❌ Til noma'lum. Mavjud tillar: "+strings.Join(i18n.Languages, ", "))
+	case errors.Is(err, domain.ErrSMSTemplateNoCode):
+		h.sendMessage(chatID, "❌ Shablonda {code} bo'lishi shart")
+	case errors.Is(err, domain.ErrSMSTemplateTooLong):
+		h.sendMessage(chatID, fmt.Sprintf("❌ Matn bitta SMS'ga sig'maydi: %s, %d/%d belgi",
+			segment.Encoding, segment.Length, segment.Limit))
+	default:
+		h.sendMessage(chatID, "❌ Xatolik: "+err.Error())
+	}
+}
+
+// sendSMSTemplates lists the template of every language with a rendered sample
+// and its size in the SMS encoding it needs. The help is in the admin's language.
+func (h *Handler) sendSMSTemplates(ctx context.Context, chatID int64, langCode string) {
+	var sb strings.Builder
+	sb.WriteString("✉️ <b>SMS shablonlari</b>\n")
+
+	for _, lang := range i18n.Languages {
+		template, custom, err := h.otpService.SMSTemplate(ctx, lang)
+		if err != nil {
+			h.sendMessage(chatID, "❌ Xatolik: "+err.Error())
+			return
+		}
+
+		kind := "standart"
+		if custom {
+			kind = "o'zgartirilgan"
+		}
+		text, segment := h.otpService.PreviewSMS(template)
+
+		sb.WriteString(fmt.Sprintf("\n<b>%s</b> (%s)\n<code>%s</code>\n📱 %s\n📏 %s, %d/%d\n",
+			lang, kind, html.EscapeString(template), html.EscapeString(text),
+			segment.Encoding, segment.Length, segment.Limit))
+	}
+
+	sb.WriteString("\n<i>{code} — kod, {minutes} — amal qilish muddati, {brand} — brend nomi</i>\n\n")
+	sb.WriteString(html.EscapeString(i18n.Get(langCode).SMSTemplateUsage))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⬅️ Orqaga", CallbackAdminBack),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = keyboard
+	h.bot.Send(msg)
+}
@@ -0,0 +1,210 @@
+// internal/bot/steps.go
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"strconv"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// advance moves the user to the next registration step of their role and asks for it.
+// Verified users only get here while editing a single profile field, so
+// they go straight back to their profile, unless a parent is still filling
+// in a newly added child.
+func (h *Handler) advance(ctx context.Context, chatID int64, user *domain.User) {
+	if user.IsVerified && !h.fsm.ContinuesEdit(user) {
+		h.finishEdit(ctx, chatID, user)
+		return
+	}
+
+	if err := h.userService.Transition(ctx, user, h.fsm.NextState(user)); err != nil {
+		h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+
+	h.sendStepPrompt(ctx, chatID, user)
+}
+
+// sendStepPrompt (re)sends the question for the user's current state.
+// The FSM step names the prompt; pickers build their own keyboards.
+func (h *Handler) sendStepPrompt(ctx context.Context, chatID int64, user *domain.User) {
+	msgs := i18n.Get(user.LanguageCode)
+
+	step, ok := h.fsm.Step(user.State)
+	if !ok {
+		h.sendMessage(chatID, msgs.Error)
+		return
+	}
+
+	switch step.Input {
+	case domain.InputText:
+		// Birinchi qadamdan orqaga yo'l yo'q, faqat tahrirlashda profilga qaytiladi
+		if h.fsm.IsFirst(user) && !user.IsVerified {
+			h.sendMessageHTML(chatID, h.stepPrompt(msgs, user))
+			return
+		}
+		h.sendWithBack(chatID, user.LanguageCode, h.stepPrompt(msgs, user))
+	case domain.InputContact:
+		h.sendPhoneRequest(chatID, user.LanguageCode)
+	case domain.InputChoice:
+		h.sendPicker(ctx, chatID, user)
+	case domain.InputDate:
+		h.sendBirthYearPicker(chatID, 0, user)
+	default:
+		h.sendMainMenu(chatID, user.LanguageCode)
+	}
+}
+
+func (h *Handler) sendPicker(ctx context.Context, chatID int64, user *domain.User) {
+	switch user.State {
+	case domain.StateWaitRole:
+		h.sendRolePicker(chatID, user.LanguageCode)
+	case domain.StateWaitDistrict:
+		region, _ := h.locationService.GetRegion(ctx, user.RegionID)
+		if region == nil {
+			h.sendRegionPicker(ctx, chatID, 0, user.LanguageCode, 0)
+			return
+		}
+		h.sendDistrictPicker(ctx, chatID, 0, user.LanguageCode, region, 0)
+	case domain.StateWaitSchool:
+		h.sendSchoolPicker(ctx, chatID, 0, user, 0)
+	default:
+		h.sendRegionPicker(ctx, chatID, 0, user.LanguageCode, 0)
+	}
+}
+
+// goBack returns the user to the previous step and shows what they entered there.
+// For verified users "back" means leaving the edit and returning to the profile.
+func (h *Handler) goBack(ctx context.Context, chatID int64, user *domain.User) {
+	if user.IsVerified {
+		h.cancelEdit(ctx, chatID, user)
+		return
+	}
+
+	prev := h.fsm.PreviousState(user)
+	if prev == "" {
+		h.sendStepPrompt(ctx, chatID, user)
+		return
+	}
+
+	// Telefon qadamidagi reply keyboard'ni yopamiz
+	if user.State == domain.StateWaitPhone {
+		h.removeReplyKeyboard(chatID)
+	}
+
+	if err := h.userService.Transition(ctx, user, prev); err != nil {
+		h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+
+	if value := previousValue(user, i18n.Get(user.LanguageCode)); value != "" {
+		h.sendMessageHTML(chatID, fmt.Sprintf(i18n.Get(user.LanguageCode).PreviousValue, html.EscapeString(value)))
+	}
+	h.sendStepPrompt(ctx, chatID, user)
+}
+
+// handleCancel wipes partial registration data, or aborts a profile edit
+func (h *Handler) handleCancel(ctx context.Context, msg *tgbotapi.Message) {
+	user, _ := h.userService.GetUser(ctx, msg.From.ID)
+	if user == nil {
+		h.handleStart(ctx, msg)
+		return
+	}
+
+	if user.IsVerified {
+		h.cancelEdit(ctx, msg.Chat.ID, user)
+		return
+	}
+
+	if err := h.userService.ResetRegistration(ctx, user.TelegramID); err != nil {
+		h.logger.Error("❌ Failed to reset registration", slog.Any("error", err))
+		h.sendMessage(msg.Chat.ID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+
+	h.removeReplyKeyboard(msg.Chat.ID)
+
+	h.sendMessageHTML(msg.Chat.ID, i18n.Get(user.LanguageCode).Cancelled)
+	h.sendRolePicker(msg.Chat.ID, user.LanguageCode)
+}
+
+func (h *Handler) cancelEdit(ctx context.Context, chatID int64, user *domain.User) {
+	if err := h.userService.CancelEdit(ctx, user.TelegramID); err != nil {
+		h.sendMessage(chatID, i18n.Get(user.LanguageCode).Error)
+		return
+	}
+	h.dropLocationDraft(user.TelegramID)
+
+	if user.State == domain.StateWaitPhone {
+		h.removeReplyKeyboard(chatID)
+	}
+
+	updated, _ := h.userService.GetUser(ctx, user.TelegramID)
+	if updated == nil {
+		return
+	}
+
+	h.sendMessageHTML(chatID, i18n.Get(updated.LanguageCode).EditCancelled)
+	h.sendProfile(ctx, chatID, updated)
+}
+
+// previousValue is the value the user already gave for their current step
+func previousValue(user *domain.User, msgs i18n.Messages) string {
+	switch user.State {
+	case domain.StateWaitRole:
+		if user.Role != "" {
+			return roleName(msgs, user.Role)
+		}
+	case domain.StateWaitChildName:
+		return user.ChildName
+	case domain.StateWaitSubject:
+		return user.Subject
+	case domain.StateWaitFullName:
+		if user.FirstName != "" {
+			return user.FirstName + " " + user.LastName
+		}
+	case domain.StateWaitLocation:
+		return user.Region
+	case domain.StateWaitDistrict:
+		return user.District
+	case domain.StateWaitSchool, domain.StateWaitSchoolName:
+		return user.School
+	case domain.StateWaitGrade:
+		if user.Grade > 0 {
+			return strconv.Itoa(user.Grade)
+		}
+	case domain.StateWaitBirthDate:
+		if !user.BirthDate.IsZero() {
+			return user.BirthDate.Format("02.01.2006")
+		}
+	case domain.StateWaitPhone:
+		return user.Phone
+	}
+	return ""
+}
+
+func backRow(langCode string) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.Get(langCode).BtnBack, CallbackBack),
+	)
+}
+
+func (h *Handler) sendWithBack(chatID int64, langCode, text string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(backRow(langCode))
+	h.bot.Send(msg)
+}
+
+func (h *Handler) removeReplyKeyboard(chatID int64) {
+	msg := tgbotapi.NewMessage(chatID, "↩️")
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+	h.bot.Send(msg)
+}
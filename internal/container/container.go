@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"khisobot/config"
@@ -12,6 +13,7 @@ import (
 	"khisobot/internal/domain"
 	"khisobot/internal/repository/postgres"
 	"khisobot/internal/service"
+	"khisobot/pkg/phone"
 	"khisobot/pkg/storage"
 )
 
@@ -23,15 +25,33 @@ type Container struct {
 	bot     *tgbotapi.BotAPI
 
 	// Repos
-	userRepo    domain.UserRepository
-	otpRepo     domain.OTPRepository
-	adminRepo   domain.AdminRepository
-	channelRepo domain.ChannelRepository
+	userRepo     domain.UserRepository
+	otpRepo      domain.OTPRepository
+	adminRepo    domain.AdminRepository
+	channelRepo  domain.ChannelRepository
+	locationRepo domain.LocationRepository
+	schoolRepo   domain.SchoolRepository
+
+	participantRepo domain.ParticipantRepository
+	reminderRepo    domain.ReminderRepository
+	smsTemplateRepo domain.SMSTemplateRepository
+	smsOutboxRepo   domain.SMSOutboxRepository
+
+	// Registration flow
+	fsm *domain.RegistrationFSM
 
 	// Services
-	userService *service.UserService
-	otpService  *service.OTPService
-	smsService  *service.SMSService
+	userService     *service.UserService
+	otpService      *service.OTPService
+	smsGateway      service.SMSGateway
+	sandboxSMS      *service.SandboxSMS // only in sandbox mode
+	smsRouter       *service.SMSRouter  // only outside sandbox mode
+	locationService *service.LocationService
+	schoolService   *service.SchoolService
+	reminderService *service.ReminderService
+	otpCleanup      *service.OTPCleanupService
+	deliveryTracker *service.DeliveryTracker
+	smsOutbox       *service.SMSOutbox
 
 	// Bot Handler
 	botHandler *bot.Handler
@@ -52,8 +72,11 @@ func NewContainer(ctx context.Context, cfg *config.Config, logger *slog.Logger)
 	}
 
 	c.initRepositories()
-	c.initServices()
+	if err := c.initServices(); err != nil {
+		return nil, fmt.Errorf("init services: %w", err)
+	}
 	c.initBotHandler()
+	c.initReminders()
 
 	logger.Info("✅ Container initialized successfully")
 	return c, nil
@@ -87,14 +110,83 @@ func (c *Container) initRepositories() {
 	c.otpRepo = postgres.NewOTPRepository(c.storage)
 	c.adminRepo = postgres.NewAdminRepository(c.storage)
 	c.channelRepo = postgres.NewChannelRepository(c.storage)
+	c.locationRepo = postgres.NewLocationRepository(c.storage)
+	c.schoolRepo = postgres.NewSchoolRepository(c.storage)
+	c.participantRepo = postgres.NewParticipantRepository(c.storage)
+	c.reminderRepo = postgres.NewReminderRepository(c.storage)
+	c.smsTemplateRepo = postgres.NewSMSTemplateRepository(c.storage)
+	c.smsOutboxRepo = postgres.NewSMSOutboxRepository(c.storage)
 	c.logger.Info("✅ Repositories initialized")
 }
 
-func (c *Container) initServices() {
-	c.smsService = service.NewSMSService(c.config, c.logger)
-	c.userService = service.NewUserService(c.userRepo, c.logger)
-	c.otpService = service.NewOTPService(c.otpRepo, c.smsService, c.config, c.logger)
+func (c *Container) initServices() error {
+	phones, err := phone.NewParser(c.config.PhoneCountryCodes)
+	if err != nil {
+		return fmt.Errorf("phone country codes: %w", err)
+	}
+
+	if c.config.SMSSandbox {
+		c.sandboxSMS = service.NewSandboxSMS(c.logger)
+		c.smsGateway = c.sandboxSMS
+		c.logger.Warn("🧪 SMS sandbox is on: no real SMS will be sent")
+	} else {
+		c.smsRouter = service.NewSMSRouter(c.smsProviders(),
+			time.Duration(c.config.SMSFailoverCooldownSecs)*time.Second, c.logger)
+		c.smsGateway = c.smsRouter
+	}
+	c.fsm = domain.NewRegistrationFSM(c.config.GradeAgeTolerance, phones)
+	c.userService = service.NewUserService(c.userRepo, c.participantRepo, c.fsm, c.config, c.logger)
+	c.otpService = service.NewOTPService(c.otpRepo, c.smsOutboxRepo, c.smsTemplateRepo, c.otpDeliverers(), c.config,
+		c.logger)
+	c.otpCleanup = service.NewOTPCleanupService(c.otpRepo, c.config, c.logger)
+	c.locationService = service.NewLocationService(c.locationRepo, c.logger)
+	c.schoolService = service.NewSchoolService(c.schoolRepo, c.logger)
 	c.logger.Info("✅ Services initialized")
+	return nil
+}
+
+// smsProviders builds the SMS gateways in the order SMS_PROVIDERS lists them
+func (c *Container) smsProviders() []service.SMSProvider {
+	providers := make([]service.SMSProvider, 0, len(c.config.SMSProviders))
+	for _, name := range c.config.SMSProviders {
+		switch name {
+		case service.SMSProviderEtcUz:
+			providers = append(providers, service.NewSMSService(c.config, c.logger))
+		case service.SMSProviderEskiz:
+			providers = append(providers, service.NewEskizProvider(c.config, c.logger))
+		case service.SMSProviderPlaymobile:
+			providers = append(providers, service.NewPlaymobileProvider(c.config, c.logger))
+		}
+	}
+	return providers
+}
+
+// otpDeliverers builds the OTP channels in the order OTP_CHANNELS lists them
+func (c *Container) otpDeliverers() []domain.OTPDeliverer {
+	deliverers := make([]domain.OTPDeliverer, 0, len(c.config.OTPChannels))
+	for _, channel := range c.config.OTPChannels {
+		switch channel {
+		case config.OTPChannelSMS:
+			deliverers = append(deliverers, c.smsGateway)
+		case config.OTPChannelTelegram:
+			deliverers = append(deliverers, bot.NewTelegramDeliverer(c.bot))
+		case config.OTPChannelLog:
+			deliverers = append(deliverers, service.NewLogDeliverer(c.logger))
+		}
+	}
+	return deliverers
+}
+
+// smsFallback is the channels OTP_CHANNELS lists after sms: the outbox moves
+// on to them when it gives up on an SMS
+func (c *Container) smsFallback() []domain.OTPDeliverer {
+	deliverers := c.otpDeliverers()
+	for i, d := range deliverers {
+		if d.Channel() == domain.OTPChannelSMS {
+			return deliverers[i+1:]
+		}
+	}
+	return nil
 }
 
 func (c *Container) initBotHandler() {
@@ -102,13 +194,28 @@ func (c *Container) initBotHandler() {
 		c.bot,
 		c.userService,
 		c.otpService,
+		c.locationService,
+		c.schoolService,
+		c.fsm,
 		c.adminRepo,
 		c.channelRepo,
+		c.reminderRepo,
+		c.sandboxSMS,
+		c.smsRouter,
 		c.logger,
 	)
 	c.logger.Info("✅ Bot handler initialized")
 }
 
+// initReminders runs after the bot handler because the handler delivers the reminders
+// and the notices about undelivered SMS
+func (c *Container) initReminders() {
+	c.reminderService = service.NewReminderService(c.reminderRepo, c.botHandler, c.config, c.logger)
+	c.deliveryTracker = service.NewDeliveryTracker(c.otpRepo, c.smsGateway, c.botHandler, c.config, c.logger)
+	c.smsOutbox = service.NewSMSOutbox(c.smsOutboxRepo, c.otpRepo, c.otpService, c.smsGateway, c.smsFallback(),
+		c.botHandler, c.config, c.logger)
+}
+
 func (c *Container) GetBot() *tgbotapi.BotAPI {
 	return c.bot
 }
@@ -117,6 +224,22 @@ func (c *Container) GetBotHandler() *bot.Handler {
 	return c.botHandler
 }
 
+func (c *Container) GetReminderService() *service.ReminderService {
+	return c.reminderService
+}
+
+func (c *Container) GetOTPCleanupService() *service.OTPCleanupService {
+	return c.otpCleanup
+}
+
+func (c *Container) GetDeliveryTracker() *service.DeliveryTracker {
+	return c.deliveryTracker
+}
+
+func (c *Container) GetSMSOutbox() *service.SMSOutbox {
+	return c.smsOutbox
+}
+
 func (c *Container) Close() error {
 	c.logger.Info("🔴 Closing container resources...")
 
@@ -0,0 +1,71 @@
+// internal/domain/delivery.go
+package domain
+
+import "context"
+
+// OTP delivery channels
+const (
+	OTPChannelSMS      = "sms"
+	OTPChannelTelegram = "telegram" // a message to the user's own chat; does not prove the phone
+	OTPChannelLog      = "log"      // development only: the code is written to the log
+)
+
+// OTPVerificationMethod is the verification method a code delivered over the
+// channel gives: only an SMS proves the phone
+func OTPVerificationMethod(channel string) string {
+	if channel == OTPChannelSMS {
+		return VerificationMethodOTP
+	}
+	return VerificationMethodChatOTP
+}
+
+// OTPMessage is a code ready to be delivered to a user
+type OTPMessage struct {
+	// ID is the same on every attempt to send this message; channels whose
+	// gateway takes a client message ID pass it on so retries are not doubled
+	ID         string
+	TelegramID int64
+	Phone      string
+	Text       string
+}
+
+// OTPDeliverer sends an OTP message over one channel and returns the
+// channel's message ID, if it has one
+type OTPDeliverer interface {
+	Channel() string
+	Deliver(ctx context.Context, msg OTPMessage) (string, error)
+}
+
+// SMS delivery statuses of an OTP message, as reported by the SMS gateway
+const (
+	DeliveryStatusPending   = "pending" // no final report yet
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusFailed    = "failed"  // rejected, undelivered or expired at the operator
+	DeliveryStatusUnknown   = "unknown" // no report will come: sent before tracking or by a gateway without reports
+)
+
+// DeliveryCheck is a sent OTP whose delivery report has not come in yet
+type DeliveryCheck struct {
+	OTP        OTPCode
+	TelegramID int64
+}
+
+// DeliveryStats counts tracked OTP messages sent since some time by delivery status
+type DeliveryStats struct {
+	Sent      int64
+	Delivered int64
+	Failed    int64
+}
+
+// SuccessRate is the share of delivered messages among those with a final report
+func (s DeliveryStats) SuccessRate() float64 {
+	if s.Delivered+s.Failed == 0 {
+		return 0
+	}
+	return float64(s.Delivered) / float64(s.Delivered+s.Failed)
+}
+
+// DeliveryNotifier tells a user that the SMS with their code did not arrive
+type DeliveryNotifier interface {
+	NotifyDeliveryFailed(ctx context.Context, telegramID int64, phone string) error
+}
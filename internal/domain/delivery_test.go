@@ -0,0 +1,28 @@
+package domain
+
+import "testing"
+
+func TestOTPVerificationMethod(t *testing.T) {
+	tests := []struct {
+		channel string
+		method  string
+		proves  bool
+	}{
+		{OTPChannelSMS, VerificationMethodOTP, true},
+		{OTPChannelTelegram, VerificationMethodChatOTP, false},
+		{OTPChannelLog, VerificationMethodChatOTP, false},
+		{"", VerificationMethodChatOTP, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			method := OTPVerificationMethod(tt.channel)
+			if method != tt.method {
+				t.Fatalf("OTPVerificationMethod(%q) = %q, want %q", tt.channel, method, tt.method)
+			}
+			if got := ProvesPhone(method); got != tt.proves {
+				t.Errorf("ProvesPhone(%q) = %v, want %v", method, got, tt.proves)
+			}
+		})
+	}
+}
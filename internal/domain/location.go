@@ -0,0 +1,58 @@
+// internal/domain/location.go
+package domain
+
+import "context"
+
+type Region struct {
+	ID        int64  `db:"id"`
+	NameUz    string `db:"name_uz"`
+	NameRu    string `db:"name_ru"`
+	SortOrder int    `db:"sort_order"`
+}
+
+// Name returns the region name for the given language (Latin Uzbek by default)
+func (r Region) Name(lang string) string {
+	if lang == "ru" && r.NameRu != "" {
+		return r.NameRu
+	}
+	return r.NameUz
+}
+
+type District struct {
+	ID       int64  `db:"id"`
+	RegionID int64  `db:"region_id"`
+	NameUz   string `db:"name_uz"`
+	NameRu   string `db:"name_ru"`
+}
+
+// Name returns the district name for the given language (Latin Uzbek by default)
+func (d District) Name(lang string) string {
+	if lang == "ru" && d.NameRu != "" {
+		return d.NameRu
+	}
+	return d.NameUz
+}
+
+// Location is what gets stored on a user: canonical IDs plus display names
+type Location struct {
+	RegionID   int64
+	Region     string
+	DistrictID int64
+	District   string
+	SchoolID   int64
+	School     string
+}
+
+type RegionStat struct {
+	RegionID int64
+	Region   string
+	Users    int64
+}
+
+// LocationRepository interface
+type LocationRepository interface {
+	GetRegions(ctx context.Context) ([]Region, error)
+	GetRegionByID(ctx context.Context, id int64) (*Region, error)
+	GetDistrictsByRegion(ctx context.Context, regionID int64) ([]District, error)
+	GetDistrictByID(ctx context.Context, id int64) (*District, error)
+}
@@ -0,0 +1,48 @@
+// internal/domain/participant.go
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Participant is a child registered by a parent. A parent's Telegram account
+// can hold several of them, each with their own school and grade.
+type Participant struct {
+	ID             int64     `db:"id"`
+	UserID         int64     `db:"user_id"`
+	FirstName      string    `db:"first_name"`
+	LastName       string    `db:"last_name"`
+	FirstNameLatin string    `db:"first_name_latin"`
+	LastNameLatin  string    `db:"last_name_latin"`
+	RegionID       int64     `db:"region_id"`
+	Region         string    `db:"region"`
+	DistrictID     int64     `db:"district_id"`
+	District       string    `db:"district"`
+	SchoolID       int64     `db:"school_id"`
+	School         string    `db:"school"`
+	Grade          int       `db:"grade"`
+	BirthDate      time.Time `db:"birth_date"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+func (p *Participant) FullName() string {
+	if p.LastName == "" {
+		return p.FirstName
+	}
+	return p.FirstName + " " + p.LastName
+}
+
+// ParticipantRepository interface
+type ParticipantRepository interface {
+	Create(ctx context.Context, p *Participant) error
+	GetByID(ctx context.Context, id int64) (*Participant, error)
+	GetByUserID(ctx context.Context, userID int64) ([]Participant, error)
+	GetByUserIDs(ctx context.Context, userIDs []int64) ([]Participant, error)
+	UpdateName(ctx context.Context, id int64, name FullName) error
+	UpdateLocation(ctx context.Context, id int64, loc Location) error
+	UpdateGrade(ctx context.Context, id int64, grade int) error
+	UpdateBirthDate(ctx context.Context, id int64, birthDate time.Time) error
+	DeleteUnverified(ctx context.Context, telegramID int64) error
+}
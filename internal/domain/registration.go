@@ -0,0 +1,562 @@
+// internal/domain/registration.go
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"khisobot/pkg/phone"
+	"khisobot/pkg/translit"
+)
+
+var (
+	ErrUnknownState      = errors.New("unknown state")
+	ErrIllegalTransition = errors.New("illegal state transition")
+	ErrChoiceExpected    = errors.New("step expects a button choice")
+	ErrInvalidFullName   = errors.New("invalid full name")
+	ErrInvalidGrade      = errors.New("invalid grade")
+	ErrInvalidPhone      = errors.New("invalid phone")
+	ErrInvalidSchoolName = errors.New("invalid school name")
+	ErrInvalidSubject    = errors.New("invalid subject")
+	ErrInvalidBirthDate  = errors.New("invalid birth date")
+	ErrAgeGradeMismatch  = errors.New("age does not match grade")
+)
+
+// InputKind tells the bot how a step collects its answer
+type InputKind int
+
+const (
+	InputNone    InputKind = iota // no input expected (registered)
+	InputText                     // free text message
+	InputChoice                   // inline keyboard picker
+	InputContact                  // shared contact or typed phone
+	InputDate                     // typed date or year/month/day pickers
+)
+
+// Step describes one state of the registration conversation. Texts are named
+// by their i18n keys so the domain stays free of languages.
+type Step struct {
+	State    string
+	Input    InputKind
+	Validate func(text string) error
+	// Prompt is the question of the step, ChildPrompt replaces it when a
+	// parent answers for their child
+	Prompt      string
+	ChildPrompt string
+	// Invalid is the reply to text the step rejects
+	Invalid string
+	// Missing reports whether the user has not answered this step yet
+	Missing func(user *User) bool
+}
+
+// registrationPaths is the order of steps for each role. Everyone picks a
+// role first and finishes with the phone number and its OTP.
+var registrationPaths = map[string][]string{
+	RoleStudent: {
+		StateWaitRole, StateWaitFullName, StateWaitLocation, StateWaitDistrict, StateWaitSchool,
+		StateWaitGrade, StateWaitBirthDate, StateWaitPhone, StateWaitOTP,
+	},
+	RoleTeacher: {
+		StateWaitRole, StateWaitFullName, StateWaitLocation, StateWaitDistrict, StateWaitSchool,
+		StateWaitSubject, StateWaitPhone, StateWaitOTP,
+	},
+	RoleParent: {
+		StateWaitRole, StateWaitFullName, StateWaitChildName, StateWaitLocation, StateWaitDistrict,
+		StateWaitSchool, StateWaitGrade, StateWaitBirthDate, StateWaitPhone, StateWaitOTP,
+	},
+}
+
+// sideSteps are detours that are not part of a path: they come back to the
+// path right after their parent step
+var sideSteps = map[string]string{
+	StateWaitSchoolName: StateWaitSchool,
+}
+
+// childSteps describe a parent's child rather than the parent
+var childSteps = map[string]bool{
+	StateWaitChildName:  true,
+	StateWaitLocation:   true,
+	StateWaitDistrict:   true,
+	StateWaitSchool:     true,
+	StateWaitSchoolName: true,
+	StateWaitGrade:      true,
+	StateWaitBirthDate:  true,
+}
+
+// RegistrationFSM is the declarative description of the registration flow.
+// It knows nothing about Telegram: the bot only routes input through it.
+type RegistrationFSM struct {
+	steps map[string]*Step
+	// editable are the states a verified user may jump into from the profile
+	editable map[string]bool
+	// gradeAgeTolerance is how many years a participant's age may differ from
+	// the usual age for their grade
+	gradeAgeTolerance int
+	// phones accepts numbers from the allowed countries only
+	phones *phone.Parser
+}
+
+func NewRegistrationFSM(gradeAgeTolerance int, phones *phone.Parser) *RegistrationFSM {
+	steps := []*Step{
+		{
+			State: StateStart,
+			Input: InputNone,
+		},
+		{
+			State:    StateWaitRole,
+			Prompt:   "AskRole",
+			Invalid:  "InvalidRole",
+			Input:    InputChoice,
+			Validate: rejectText,
+			Missing:  func(u *User) bool { return !IsValidRole(u.Role) },
+		},
+		{
+			State:    StateWaitFullName,
+			Prompt:   "AskFullName",
+			Invalid:  "InvalidFullName",
+			Input:    InputText,
+			Validate: validateFullName,
+			Missing:  func(u *User) bool { return u.FirstName == "" },
+		},
+		{
+			State:    StateWaitChildName,
+			Prompt:   "AskChildName",
+			Invalid:  "InvalidFullName",
+			Input:    InputText,
+			Validate: validateFullName,
+			Missing:  func(u *User) bool { return u.ChildName == "" },
+		},
+		{
+			State:    StateWaitLocation,
+			Prompt:   "AskLocation",
+			Invalid:  "InvalidLocation",
+			Input:    InputChoice,
+			Validate: rejectText,
+			Missing:  func(u *User) bool { return u.RegionID == 0 },
+		},
+		{
+			State:    StateWaitDistrict,
+			Prompt:   "AskDistrict",
+			Invalid:  "InvalidLocation",
+			Input:    InputChoice,
+			Validate: rejectText,
+			Missing:  func(u *User) bool { return u.DistrictID == 0 },
+		},
+		{
+			// Matn yozilsa maktab qidiriladi, shuning uchun validator yo'q
+			State:   StateWaitSchool,
+			Prompt:  "AskSchool",
+			Input:   InputChoice,
+			Missing: func(u *User) bool { return u.SchoolID == 0 },
+		},
+		{
+			State:    StateWaitSchoolName,
+			Prompt:   "AskSchoolName",
+			Invalid:  "AskSchoolName",
+			Input:    InputText,
+			Validate: validateSchoolName,
+		},
+		{
+			State:       StateWaitGrade,
+			Prompt:      "AskGrade",
+			ChildPrompt: "AskChildGrade",
+			Invalid:     "InvalidGrade",
+			Input:       InputText,
+			Validate:    validateGrade,
+			Missing:     func(u *User) bool { return u.Grade == 0 },
+		},
+		{
+			State:       StateWaitBirthDate,
+			Prompt:      "AskBirthDate",
+			ChildPrompt: "AskChildBirthDate",
+			Invalid:     "InvalidBirthDate",
+			Input:       InputDate,
+			Validate:    validateBirthDate,
+			Missing:     func(u *User) bool { return u.BirthDate.IsZero() },
+		},
+		{
+			State:    StateWaitSubject,
+			Prompt:   "AskSubject",
+			Invalid:  "InvalidSubject",
+			Input:    InputText,
+			Validate: validateSubject,
+			Missing:  func(u *User) bool { return u.Subject == "" },
+		},
+		{
+			State:   StateWaitPhone,
+			Prompt:  "AskPhone",
+			Invalid: "InvalidPhone",
+			Input:   InputContact,
+			Validate: func(text string) error {
+				_, err := phones.Parse(text)
+				return err
+			},
+			// Telefon kiritilgan bo'lsa ham yangi kod yuborish uchun qayta so'raymiz
+			Missing: func(u *User) bool { return !u.IsVerified },
+		},
+		{
+			// Kodning to'g'riligini faqat OTP servisi biladi
+			State:  StateWaitOTP,
+			Prompt: "AskOTP",
+			Input:  InputText,
+		},
+		{
+			State: StateRegistered,
+			Input: InputNone,
+		},
+	}
+
+	f := &RegistrationFSM{
+		steps: make(map[string]*Step, len(steps)),
+		editable: map[string]bool{
+			StateWaitFullName:  true,
+			StateWaitChildName: true,
+			StateWaitLocation:  true,
+			StateWaitGrade:     true,
+			StateWaitBirthDate: true,
+			StateWaitSubject:   true,
+			StateWaitPhone:     true,
+		},
+		gradeAgeTolerance: gradeAgeTolerance,
+		phones:            phones,
+	}
+	for _, s := range steps {
+		f.steps[s.State] = s
+	}
+
+	return f
+}
+
+// Step returns the definition of a state, or false if the state is unknown
+func (f *RegistrationFSM) Step(state string) (*Step, bool) {
+	s, ok := f.steps[state]
+	return s, ok
+}
+
+// PromptKey is the i18n key of the question for the user's current step, or
+// "" if the step asks nothing
+func (f *RegistrationFSM) PromptKey(user *User) string {
+	s, ok := f.steps[user.State]
+	if !ok {
+		return ""
+	}
+	if user.Role == RoleParent && s.ChildPrompt != "" {
+		return s.ChildPrompt
+	}
+	return s.Prompt
+}
+
+// InvalidKey is the i18n key of the reply to text rejected at state
+func (f *RegistrationFSM) InvalidKey(state string) string {
+	if s, ok := f.steps[state]; ok {
+		return s.Invalid
+	}
+	return ""
+}
+
+// path is the step order for the user's role. Until a role is chosen only
+// the role step is known.
+func (f *RegistrationFSM) path(user *User) []string {
+	if p, ok := registrationPaths[user.Role]; ok {
+		return p
+	}
+	return []string{StateWaitRole}
+}
+
+// NextState returns the step after the user's current one, or StateRegistered
+// once the path is complete
+func (f *RegistrationFSM) NextState(user *User) string {
+	state := user.State
+	if parent, ok := sideSteps[state]; ok {
+		state = parent
+	}
+
+	path := f.path(user)
+	for i, s := range path {
+		if s == state && i+1 < len(path) {
+			return path[i+1]
+		}
+	}
+	return StateRegistered
+}
+
+// PreviousState returns the step before the user's current one, or "" for the first step
+func (f *RegistrationFSM) PreviousState(user *User) string {
+	if parent, ok := sideSteps[user.State]; ok {
+		return parent
+	}
+
+	path := f.path(user)
+	for i, s := range path {
+		if s == user.State && i > 0 {
+			return path[i-1]
+		}
+	}
+	return ""
+}
+
+// FunnelStates is the order of steps in the funnel report for a role. Without a
+// role only the steps every role goes through are listed, so that conversion
+// between neighbouring steps stays meaningful.
+func (f *RegistrationFSM) FunnelStates(role string) []string {
+	if path, ok := registrationPaths[role]; ok {
+		return append(append([]string{}, path...), StateRegistered)
+	}
+
+	var states []string
+	for _, state := range registrationPaths[RoleStudent] {
+		common := true
+		for _, path := range registrationPaths {
+			if !slices.Contains(path, state) {
+				common = false
+				break
+			}
+		}
+		if common {
+			states = append(states, state)
+		}
+	}
+	return append(states, StateRegistered)
+}
+
+// IsFirst reports whether the user is on the first step of their path
+func (f *RegistrationFSM) IsFirst(user *User) bool {
+	return user.State == f.path(user)[0]
+}
+
+// ContinuesEdit reports whether a verified user should go on to the next step
+// instead of returning to the profile. This is only the case while a parent
+// fills in the details of a newly added child.
+func (f *RegistrationFSM) ContinuesEdit(user *User) bool {
+	if user.Role != RoleParent || !childSteps[user.State] {
+		return false
+	}
+
+	next := f.NextState(user)
+	step, ok := f.steps[next]
+	return ok && childSteps[next] && step.Missing != nil && step.Missing(user)
+}
+
+// Validate checks text input for the user's current step
+func (f *RegistrationFSM) Validate(state, text string) error {
+	s, ok := f.steps[state]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownState, state)
+	}
+	if s.Validate == nil {
+		return nil
+	}
+	return s.Validate(text)
+}
+
+// CanTransition reports whether the user may move to the given state.
+// Besides the next and previous steps of their path (and detours such as
+// typing a missing school name), an unverified user may always restart from
+// the first step (/cancel), and a verified user may always return to the
+// profile or jump into another editable field.
+func (f *RegistrationFSM) CanTransition(user *User, to string) bool {
+	if _, ok := f.steps[user.State]; !ok {
+		return false
+	}
+	if _, ok := f.steps[to]; !ok {
+		return false
+	}
+
+	if to == f.NextState(user) || to == f.PreviousState(user) {
+		return true
+	}
+	if parent, ok := sideSteps[to]; ok && parent == user.State {
+		return true
+	}
+
+	if user.IsVerified {
+		return to == StateRegistered || f.editable[to]
+	}
+	return to == StateWaitRole
+}
+
+// Transition returns an error if the user may not move to the given state
+func (f *RegistrationFSM) Transition(user *User, to string) error {
+	if !f.CanTransition(user, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrIllegalTransition, user.State, to)
+	}
+	return nil
+}
+
+// Repair returns a valid state for a user whose persisted state is unknown or
+// contradicts their data. The second result is false if nothing had to change.
+func (f *RegistrationFSM) Repair(user *User) (string, bool) {
+	if _, ok := f.steps[user.State]; ok {
+		// Tasdiqlanmagan foydalanuvchi "registered" holatida bo'lishi mumkin emas
+		if user.State != StateRegistered || user.IsVerified {
+			return user.State, false
+		}
+	}
+
+	if user.IsVerified {
+		return StateRegistered, true
+	}
+
+	// Birinchi to'ldirilmagan qadamdan davom ettiramiz
+	for _, state := range f.path(user) {
+		if step := f.steps[state]; step.Missing == nil || step.Missing(user) {
+			return state, true
+		}
+	}
+	return StateWaitPhone, true
+}
+
+// CheckAgeForGrade reports ErrAgeGradeMismatch when a participant born on
+// birthDate is too young or too old for the grade. Children usually start
+// the 1st grade at 6, so the expected age on 1 September is grade+5 or grade+6.
+// Nothing is checked while either value is still unknown.
+func (f *RegistrationFSM) CheckAgeForGrade(birthDate time.Time, grade int) error {
+	if birthDate.IsZero() || grade == 0 {
+		return nil
+	}
+
+	age := ageAt(birthDate, schoolYearStart(time.Now()))
+	if age < grade+5-f.gradeAgeTolerance || age > grade+6+f.gradeAgeTolerance {
+		return fmt.Errorf("%w: age %d, grade %d", ErrAgeGradeMismatch, age, grade)
+	}
+	return nil
+}
+
+// BirthYears lists the birth years the year picker offers for a grade, oldest first
+func (f *RegistrationFSM) BirthYears(grade int) []int {
+	minAge, maxAge := 6, 18
+	if grade > 0 {
+		minAge, maxAge = grade+5-f.gradeAgeTolerance, grade+6+f.gradeAgeTolerance
+	}
+
+	// 1-sentabrgacha tug'ilganlar bir yil kattaroq bo'ladi, shuning uchun yana bir yil qo'shamiz
+	start := schoolYearStart(time.Now()).Year()
+	years := make([]int, 0, maxAge-minAge+2)
+	for y := start - maxAge - 1; y <= start-minAge; y++ {
+		years = append(years, y)
+	}
+	return years
+}
+
+// schoolYearStart is 1 September of the school year that now belongs to
+func schoolYearStart(now time.Time) time.Time {
+	year := now.Year()
+	if now.Month() < time.September {
+		year--
+	}
+	return time.Date(year, time.September, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ageAt is the age in full years on the given day
+func ageAt(birthDate, day time.Time) int {
+	age := day.Year() - birthDate.Year()
+	if day.Month() < birthDate.Month() || (day.Month() == birthDate.Month() && day.Day() < birthDate.Day()) {
+		age--
+	}
+	return age
+}
+
+// ParseFullName splits "Anvar Karimov" into first and last name, normalizes
+// both and adds the Latin spelling for names typed in Cyrillic
+func ParseFullName(text string) (FullName, error) {
+	parts := strings.Fields(text)
+	if len(parts) < 2 {
+		return FullName{}, ErrInvalidFullName
+	}
+
+	first, err := translit.NormalizeName(parts[0])
+	if err != nil {
+		return FullName{}, fmt.Errorf("%w: %v", ErrInvalidFullName, err)
+	}
+	last, err := translit.NormalizeName(strings.Join(parts[1:], " "))
+	if err != nil {
+		return FullName{}, fmt.Errorf("%w: %v", ErrInvalidFullName, err)
+	}
+
+	return FullName{
+		First:      first,
+		Last:       last,
+		FirstLatin: translit.ToLatin(first),
+		LastLatin:  translit.ToLatin(last),
+	}, nil
+}
+
+func ParseGrade(text string) (int, error) {
+	grade, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || grade < 1 || grade > 11 {
+		return 0, ErrInvalidGrade
+	}
+	return grade, nil
+}
+
+// ParsePhone normalizes a typed or shared number to E.164, e.g. +998901234567
+func (f *RegistrationFSM) ParsePhone(text string) (string, error) {
+	number, err := f.phones.Parse(text)
+	if err != nil {
+		return "", ErrInvalidPhone
+	}
+	return number, nil
+}
+
+var birthDateLayouts = []string{"02.01.2006", "2.1.2006", "02/01/2006", "2/1/2006", "02-01-2006", "2-1-2006", "2006-01-02"}
+
+// ParseBirthDate accepts DD.MM.YYYY (also with / or - separators) and rejects
+// dates in the future or implausibly far in the past
+func ParseBirthDate(text string) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	for _, layout := range birthDateLayouts {
+		date, err := time.Parse(layout, text)
+		if err != nil {
+			continue
+		}
+		if date.After(time.Now()) || date.Year() < 1900 {
+			return time.Time{}, ErrInvalidBirthDate
+		}
+		return date, nil
+	}
+	return time.Time{}, ErrInvalidBirthDate
+}
+
+func validateFullName(text string) error {
+	_, err := ParseFullName(text)
+	return err
+}
+
+func validateGrade(text string) error {
+	_, err := ParseGrade(text)
+	return err
+}
+
+func validateBirthDate(text string) error {
+	_, err := ParseBirthDate(text)
+	return err
+}
+
+// ParseSubject cleans up the subject a teacher teaches, e.g. "matematika" -> "Matematika"
+func ParseSubject(text string) (string, error) {
+	subject, err := translit.NormalizeName(text)
+	if err != nil || len([]rune(subject)) < 2 {
+		return "", ErrInvalidSubject
+	}
+	return subject, nil
+}
+
+func validateSubject(text string) error {
+	_, err := ParseSubject(text)
+	return err
+}
+
+func validateSchoolName(text string) error {
+	if len([]rune(strings.TrimSpace(text))) < 3 {
+		return ErrInvalidSchoolName
+	}
+	return nil
+}
+
+func rejectText(string) error {
+	return ErrChoiceExpected
+}
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"khisobot/pkg/i18n"
+	"khisobot/pkg/phone"
+)
+
+func newTestFSM(t *testing.T) *RegistrationFSM {
+	t.Helper()
+	phones, err := phone.NewParser([]string{"998"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewRegistrationFSM(1, phones)
+}
+
+func TestCanTransition(t *testing.T) {
+	f := newTestFSM(t)
+
+	tests := []struct {
+		name string
+		user User
+		to   string
+		want bool
+	}{
+		{"student next step", User{Role: RoleStudent, State: StateWaitFullName}, StateWaitLocation, true},
+		{"student previous step", User{Role: RoleStudent, State: StateWaitFullName}, StateWaitRole, true},
+		{"student skips a step", User{Role: RoleStudent, State: StateWaitFullName}, StateWaitDistrict, false},
+		{"student grade after school", User{Role: RoleStudent, State: StateWaitSchool}, StateWaitGrade, true},
+		{"student has no subject", User{Role: RoleStudent, State: StateWaitSchool}, StateWaitSubject, false},
+		{"student birth date after grade", User{Role: RoleStudent, State: StateWaitGrade}, StateWaitBirthDate, true},
+		{"teacher subject after school", User{Role: RoleTeacher, State: StateWaitSchool}, StateWaitSubject, true},
+		{"teacher has no grade", User{Role: RoleTeacher, State: StateWaitSchool}, StateWaitGrade, false},
+		{"teacher phone after subject", User{Role: RoleTeacher, State: StateWaitSubject}, StateWaitPhone, true},
+		{"parent child name after own name", User{Role: RoleParent, State: StateWaitFullName}, StateWaitChildName, true},
+		{"parent cannot skip child name", User{Role: RoleParent, State: StateWaitFullName}, StateWaitLocation, false},
+		{"school name detour", User{Role: RoleStudent, State: StateWaitSchool}, StateWaitSchoolName, true},
+		{"detour returns to path", User{Role: RoleStudent, State: StateWaitSchoolName}, StateWaitGrade, true},
+		{"detour back to picker", User{Role: RoleStudent, State: StateWaitSchoolName}, StateWaitSchool, true},
+		{"detour only from school", User{Role: RoleStudent, State: StateWaitGrade}, StateWaitSchoolName, false},
+		{"cancel restarts", User{Role: RoleStudent, State: StateWaitGrade}, StateWaitRole, true},
+		{"unverified cannot register", User{Role: RoleStudent, State: StateWaitGrade}, StateRegistered, false},
+		{"otp completes", User{Role: RoleStudent, State: StateWaitOTP}, StateRegistered, true},
+		{"unknown from state", User{Role: RoleStudent, State: "wait_nothing"}, StateWaitRole, false},
+		{"unknown to state", User{Role: RoleStudent, State: StateWaitFullName}, "wait_nothing", false},
+
+		{"edit name", User{Role: RoleStudent, State: StateRegistered, IsVerified: true}, StateWaitFullName, true},
+		{"edit location", User{Role: RoleStudent, State: StateRegistered, IsVerified: true}, StateWaitLocation, true},
+		{"edit phone", User{Role: RoleTeacher, State: StateRegistered, IsVerified: true}, StateWaitPhone, true},
+		{"edit subject", User{Role: RoleTeacher, State: StateRegistered, IsVerified: true}, StateWaitSubject, true},
+		{"school is not edited alone", User{Role: RoleStudent, State: StateRegistered, IsVerified: true}, StateWaitSchool, false},
+		{"otp is not editable", User{Role: RoleStudent, State: StateRegistered, IsVerified: true}, StateWaitOTP, false},
+		{"role is not editable", User{Role: RoleStudent, State: StateRegistered, IsVerified: true}, StateWaitRole, false},
+		{"edit continues location", User{Role: RoleStudent, State: StateWaitLocation, IsVerified: true}, StateWaitDistrict, true},
+		{"edit returns to profile", User{Role: RoleStudent, State: StateWaitGrade, IsVerified: true}, StateRegistered, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.CanTransition(&tt.user, tt.to); got != tt.want {
+				t.Errorf("CanTransition(%s -> %s) = %v, want %v", tt.user.State, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepair(t *testing.T) {
+	f := newTestFSM(t)
+	born := time.Date(2012, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		user        User
+		wantState   string
+		wantChanged bool
+	}{
+		{"valid state kept", User{Role: RoleStudent, State: StateWaitGrade}, StateWaitGrade, false},
+		{"verified user kept", User{Role: RoleStudent, State: StateRegistered, IsVerified: true}, StateRegistered, false},
+		{"verified unknown state", User{Role: RoleStudent, State: "old_state", IsVerified: true}, StateRegistered, true},
+		{"no role", User{State: "old_state"}, StateWaitRole, true},
+		{"unverified cannot be registered", User{Role: RoleStudent, State: StateRegistered, FirstName: "Anvar"},
+			StateWaitLocation, true},
+		{"first missing student step",
+			User{Role: RoleStudent, State: "old_state", FirstName: "Anvar", RegionID: 1},
+			StateWaitDistrict, true},
+		{"teacher missing subject",
+			User{Role: RoleTeacher, State: "old_state", FirstName: "Anvar", RegionID: 1, DistrictID: 2, SchoolID: 3},
+			StateWaitSubject, true},
+		{"parent missing child name",
+			User{Role: RoleParent, State: "old_state", FirstName: "Anvar"},
+			StateWaitChildName, true},
+		{"everything filled in",
+			User{Role: RoleStudent, State: "old_state", FirstName: "Anvar", RegionID: 1, DistrictID: 2, SchoolID: 3,
+				Grade: 7, BirthDate: born},
+			StateWaitPhone, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, changed := f.Repair(&tt.user)
+			if state != tt.wantState || changed != tt.wantChanged {
+				t.Errorf("Repair() = %s, %v, want %s, %v", state, changed, tt.wantState, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestStepTexts(t *testing.T) {
+	f := newTestFSM(t)
+
+	for _, lang := range []string{"uz", "ru", "en"} {
+		msgs := i18n.Get(lang)
+		for state, s := range f.steps {
+			for _, key := range []string{s.Prompt, s.ChildPrompt, s.Invalid} {
+				if key != "" && msgs.Text(key) == "" {
+					t.Errorf("%s: step %s names unknown text %q", lang, state, key)
+				}
+			}
+			if s.Input == InputText && s.Prompt == "" {
+				t.Errorf("text step %s has no prompt", state)
+			}
+		}
+	}
+}
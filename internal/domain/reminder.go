@@ -0,0 +1,46 @@
+// internal/domain/reminder.go
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Reminder is a nudge sent to a user who stopped in the middle of registration
+type Reminder struct {
+	ID      int64     `db:"id"`
+	UserID  int64     `db:"user_id"`
+	State   string    `db:"state"`
+	Attempt int       `db:"attempt"`
+	SentAt  time.Time `db:"sent_at"`
+}
+
+// ReminderCandidate is an unverified user stuck on a step, together with the
+// reminders already sent since they reached that step
+type ReminderCandidate struct {
+	UserID     int64
+	TelegramID int64
+	State      string
+	StuckSince time.Time
+	Attempts   int
+	LastSentAt time.Time // zero if no reminder was sent yet
+}
+
+type ReminderStats struct {
+	Sent          int64 // reminders sent in total
+	RemindedUsers int64
+	// RecoveredUsers finished registration after at least one reminder
+	RecoveredUsers int64
+}
+
+// ReminderRepository interface
+type ReminderRepository interface {
+	Create(ctx context.Context, reminder *Reminder) error
+	GetCandidates(ctx context.Context, stuckBefore time.Time, maxAttempts, limit int) ([]ReminderCandidate, error)
+	GetStats(ctx context.Context) (*ReminderStats, error)
+}
+
+// ReminderSender delivers the nudge and re-asks the user's current step
+type ReminderSender interface {
+	SendReminder(ctx context.Context, telegramID int64, state string) error
+}
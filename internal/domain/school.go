@@ -0,0 +1,48 @@
+// internal/domain/school.go
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// School statuses
+const (
+	SchoolStatusApproved = "approved"
+	SchoolStatusPending  = "pending"
+	SchoolStatusRejected = "rejected"
+)
+
+type School struct {
+	ID          int64     `db:"id"`
+	DistrictID  int64     `db:"district_id"`
+	Number      int       `db:"number"`
+	Name        string    `db:"name"`
+	Status      string    `db:"status"`
+	SubmittedBy int64     `db:"submitted_by"` // telegram_id, only for user submissions
+	CreatedAt   time.Time `db:"created_at"`
+
+	// Filled by joined queries (admin review queue)
+	Region   string `db:"-"`
+	District string `db:"-"`
+}
+
+type SchoolStat struct {
+	SchoolID int64
+	School   string
+	District string
+	Region   string
+	Users    int64
+}
+
+// SchoolRepository interface
+type SchoolRepository interface {
+	Create(ctx context.Context, school *School) error
+	GetByID(ctx context.Context, id int64) (*School, error)
+	GetByDistrict(ctx context.Context, districtID int64) ([]School, error)
+	Search(ctx context.Context, districtID int64, query string, number int, limit int) ([]School, error)
+	GetPending(ctx context.Context) ([]School, error)
+	CountPending(ctx context.Context) (int64, error)
+	UpdateStatus(ctx context.Context, id int64, status string) error
+	GetStats(ctx context.Context) ([]SchoolStat, error)
+}
@@ -0,0 +1,66 @@
+// internal/domain/sms_outbox.go
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// SMS outbox statuses
+const (
+	SMSOutboxPending   = "pending" // waiting for its first or next attempt
+	SMSOutboxSent      = "sent"
+	SMSOutboxDead      = "dead"      // gave up: out of attempts or the gateway rejected it
+	SMSOutboxCancelled = "cancelled" // the code was used, replaced or expired before it went out
+)
+
+// SMSOutboxMessage is an OTP SMS queued for the outbox worker
+type SMSOutboxMessage struct {
+	ID int64 `db:"id"`
+	// MessageID is generated once and reused on every attempt, so a gateway
+	// that accepts client IDs can drop a duplicate of a send that timed out
+	MessageID    string `db:"message_id"`
+	OTPID        int64  `db:"otp_id"`
+	TelegramID   int64  `db:"telegram_id"`
+	Phone        string `db:"phone"`
+	LanguageCode string `db:"language_code"`
+	// SealedCode is the code encrypted for this message; the text is built
+	// from it at send time, so no plaintext code is ever stored
+	SealedCode    []byte    `db:"sealed_code"`
+	Status        string    `db:"status"`
+	Attempts      int       `db:"attempts"`
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+	LastError     string    `db:"last_error"`
+	CreatedAt     time.Time `db:"created_at"`
+
+	// OTPStatus and OTPExpiresAt describe the code the message carries
+	OTPStatus    string    `db:"-"`
+	OTPExpiresAt time.Time `db:"-"`
+}
+
+// SMSOutboxStats counts queued messages by status
+type SMSOutboxStats struct {
+	Pending int64
+	Sent    int64
+	Dead    int64
+}
+
+// SMSOutboxRepository interface
+type SMSOutboxRepository interface {
+	// Enqueue saves the code and queues its SMS in one transaction, so a code
+	// is never stored without its message or the other way round. The quota
+	// is checked in the same transaction, see OTPQuota.
+	Enqueue(ctx context.Context, otp *OTPCode, msg *SMSOutboxMessage, quota OTPQuota) error
+	// ClaimDue takes up to limit messages whose attempt is due, counts the
+	// attempt and hides them from other workers for the lease. A worker that
+	// dies mid-send leaves the message to be retried once the lease runs out.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]SMSOutboxMessage, error)
+	// MarkSent also stores the gateway's message ID and the initial delivery
+	// status on the code: pending, or unknown when the gateway has no reports
+	MarkSent(ctx context.Context, id int64, providerMessageID, deliveryStatus string) error
+	Retry(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error
+	// MarkDead also marks the code's delivery as failed
+	MarkDead(ctx context.Context, id int64, lastError string) error
+	Cancel(ctx context.Context, id int64) error
+	GetStats(ctx context.Context, since time.Time) (SMSOutboxStats, error)
+}
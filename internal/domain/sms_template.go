@@ -0,0 +1,37 @@
+// internal/domain/sms_template.go
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Placeholders an OTP SMS template may use
+const (
+	SMSPlaceholderCode    = "{code}"
+	SMSPlaceholderMinutes = "{minutes}"
+	SMSPlaceholderBrand   = "{brand}"
+)
+
+var (
+	ErrSMSTemplateNoCode   = errors.New("sms template has no {code} placeholder")
+	ErrSMSTemplateTooLong  = errors.New("sms template does not fit in a single sms")
+	ErrSMSTemplateLanguage = errors.New("unknown sms template language")
+)
+
+// SMSTemplate is an admin override of the OTP SMS text for one language.
+// Languages without one use the default text from i18n.
+type SMSTemplate struct {
+	LanguageCode string    `db:"language_code"`
+	Template     string    `db:"template"`
+	UpdatedBy    int64     `db:"updated_by"` // admin's Telegram ID
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+// SMSTemplateRepository interface
+type SMSTemplateRepository interface {
+	Get(ctx context.Context, langCode string) (*SMSTemplate, error)
+	Upsert(ctx context.Context, t *SMSTemplate) error
+	Delete(ctx context.Context, langCode string) error
+}
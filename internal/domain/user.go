@@ -3,20 +3,62 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
 // User states
 const (
-	StateStart        = "start"
-	StateWaitFullName = "wait_full_name"
-	StateWaitLocation = "wait_location"
-	StateWaitGrade    = "wait_grade"
-	StateWaitPhone    = "wait_phone"
-	StateWaitOTP      = "wait_otp"
-	StateRegistered   = "registered"
+	StateStart          = "start"
+	StateWaitRole       = "wait_role"
+	StateWaitFullName   = "wait_full_name"
+	StateWaitChildName  = "wait_child_name"
+	StateWaitLocation   = "wait_location"
+	StateWaitDistrict   = "wait_district"
+	StateWaitSchool     = "wait_school"
+	StateWaitSchoolName = "wait_school_name"
+	StateWaitGrade      = "wait_grade"
+	StateWaitBirthDate  = "wait_birth_date"
+	StateWaitSubject    = "wait_subject"
+	StateWaitPhone      = "wait_phone"
+	StateWaitOTP        = "wait_otp"
+	StateRegistered     = "registered"
 )
 
+// Participant roles
+const (
+	RoleStudent = "student"
+	RoleTeacher = "teacher"
+	RoleParent  = "parent"
+)
+
+// Roles lists the roles in the order they are offered to the user
+var Roles = []string{RoleStudent, RoleTeacher, RoleParent}
+
+func IsValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Verification methods: how a user's phone number was confirmed
+const (
+	VerificationMethodOTP     = "sms_otp"
+	VerificationMethodContact = "telegram_contact"
+	// VerificationMethodChatOTP is a code that reached the user over a
+	// fallback channel, not the phone: the number is not proven
+	VerificationMethodChatOTP = "chat_otp"
+)
+
+// ProvesPhone reports whether the verification method shows the user owns
+// the number. Only such users may claim a number another account has.
+func ProvesPhone(method string) bool {
+	return method == VerificationMethodOTP || method == VerificationMethodContact
+}
+
 // Admin states
 const (
 	AdminStateNone          = ""
@@ -25,32 +67,142 @@ const (
 )
 
 type User struct {
-	ID           int64     `db:"id"`
-	TelegramID   int64     `db:"telegram_id"`
-	Username     string    `db:"username"`
-	LanguageCode string    `db:"language_code"`
-	FirstName    string    `db:"first_name"`
-	LastName     string    `db:"last_name"`
-	Region       string    `db:"region"`
-	District     string    `db:"district"`
-	School       string    `db:"school"`
-	Grade        int       `db:"grade"`
-	Phone        string    `db:"phone"`
-	IsVerified   bool      `db:"is_verified"`
-	State        string    `db:"state"`
-	CreatedAt    time.Time `db:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at"`
+	ID           int64  `db:"id"`
+	TelegramID   int64  `db:"telegram_id"`
+	Username     string `db:"username"`
+	LanguageCode string `db:"language_code"`
+	FirstName    string `db:"first_name"`
+	LastName     string `db:"last_name"`
+	// Canonical Latin spelling used by search and the Excel export
+	FirstNameLatin string    `db:"first_name_latin"`
+	LastNameLatin  string    `db:"last_name_latin"`
+	Role           string    `db:"role"`
+	RegionID       int64     `db:"region_id"`
+	Region         string    `db:"region"`
+	DistrictID     int64     `db:"district_id"`
+	District       string    `db:"district"`
+	SchoolID       int64     `db:"school_id"`
+	School         string    `db:"school"`
+	Grade          int       `db:"grade"`
+	Subject        string    `db:"subject"`    // teachers only
+	BirthDate      time.Time `db:"birth_date"` // zero when not given
+	// Parents only: the child currently being added or edited. While it is set,
+	// ChildName and the location and grade fields above describe that child.
+	ActiveParticipantID int64  `db:"active_participant_id"`
+	ChildName           string `db:"-"`
+	// Set when this account is an alias of another account with the same phone
+	PrimaryUserID      int64     `db:"primary_user_id"`
+	Phone              string    `db:"phone"`
+	PendingPhone       string    `db:"pending_phone"`
+	IsVerified         bool      `db:"is_verified"`
+	VerificationMethod string    `db:"verification_method"`
+	State              string    `db:"state"`
+	CreatedAt          time.Time `db:"created_at"`
+	UpdatedAt          time.Time `db:"updated_at"`
 }
 
+// OwnerID is the account that owns shared data such as children: the primary
+// account for an alias, otherwise the user themselves
+func (u *User) OwnerID() int64 {
+	if u.PrimaryUserID != 0 {
+		return u.PrimaryUserID
+	}
+	return u.ID
+}
+
+// VerificationPhone is the number an OTP is currently being checked against:
+// a pending new number while a verified user changes it, otherwise the main one
+func (u *User) VerificationPhone() string {
+	if u.IsVerified && u.PendingPhone != "" {
+		return u.PendingPhone
+	}
+	return u.Phone
+}
+
+// FullName is a normalized name as typed by the user plus its Latin transliteration
+type FullName struct {
+	First      string
+	Last       string
+	FirstLatin string
+	LastLatin  string
+}
+
+// OTP code statuses. Only a pending code that has not expired can be verified.
+const (
+	OTPStatusPending    = "pending"
+	OTPStatusVerified   = "verified"
+	OTPStatusSuperseded = "superseded" // a newer code was sent to the same user and phone
+	OTPStatusExhausted  = "exhausted"  // too many wrong guesses
+)
+
 type OTPCode struct {
-	ID        int64     `db:"id"`
-	UserID    int64     `db:"user_id"`
-	Phone     string    `db:"phone"`
-	Code      string    `db:"code"`
-	MessageID string    `db:"message_id"`
-	IsUsed    bool      `db:"is_used"`
-	ExpiresAt time.Time `db:"expires_at"`
-	CreatedAt time.Time `db:"created_at"`
+	ID             int64     `db:"id"`
+	UserID         int64     `db:"user_id"`
+	Phone          string    `db:"phone"`
+	CodeHash       string    `db:"code_hash"` // HMAC of the code, never the code itself
+	MessageID      string    `db:"message_id"`
+	Status         string    `db:"status"`
+	FailedAttempts int       `db:"failed_attempts"`
+	Channel        string    `db:"channel"`
+	DeliveryStatus string    `db:"delivery_status"`
+	ExpiresAt      time.Time `db:"expires_at"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// OTPVerification is the outcome of checking a code. A wrong code reports how
+// many guesses the current code has left; LockedUntil is set while the phone
+// is locked after too many wrong codes.
+type OTPVerification struct {
+	Valid        bool
+	AttemptsLeft int
+	LockedUntil  time.Time
+	// Method is how a valid code verifies the phone, see OTPVerificationMethod
+	Method string
+}
+
+// OTPLockedError is returned when a code is requested for a locked phone
+type OTPLockedError struct {
+	Until time.Time
+}
+
+func (e *OTPLockedError) Error() string {
+	return "phone is locked until " + e.Until.Format(time.RFC3339)
+}
+
+// OTPCooldownError is returned when a new code is requested too soon after the last one
+type OTPCooldownError struct {
+	Until time.Time
+}
+
+func (e *OTPCooldownError) Error() string {
+	return "otp resend cooldown until " + e.Until.Format(time.RFC3339)
+}
+
+var (
+	// ErrOTPDailyLimit means the phone or the Telegram user used up their SMS codes for the day
+	ErrOTPDailyLimit = errors.New("daily otp limit reached")
+	// ErrOTPGlobalLimit means the bot as a whole used up its daily SMS budget
+	ErrOTPGlobalLimit = errors.New("global daily otp limit reached")
+)
+
+// OTPSendStats counts codes sent by SMS within the quota window; codes that
+// went out over Telegram or the log cost nothing and are not counted
+type OTPSendStats struct {
+	PhoneCount int
+	UserCount  int
+	TotalCount int
+	// LastSentAt is the latest code sent to the phone or requested by the user
+	// over any channel, as the resend cooldown applies to all of them
+	LastSentAt time.Time
+}
+
+// OTPQuota decides whether one more code may be sent. Repositories take a
+// per-phone and per-user lock, count the codes sent since Since and call
+// Allow in the transaction that stores the new code, so two requests at once
+// cannot both pass.
+type OTPQuota struct {
+	Since time.Time
+	Allow func(OTPSendStats) error
 }
 
 type Admin struct {
@@ -70,11 +222,34 @@ type Channel struct {
 	CreatedAt       time.Time `db:"created_at"`
 }
 
+// PhoneResolution tells what happened to the other account that had the
+// same verified phone
+type PhoneResolution struct {
+	Released *User // lost the number (transfer policy)
+	LinkedTo *User // the user became its alias (alias policy)
+}
+
+// PhoneGroup is a verified phone number used by several unlinked accounts
+type PhoneGroup struct {
+	Phone string
+	Users []User // oldest first
+}
+
 type Stats struct {
 	TotalUsers    int64
 	VerifiedUsers int64
 	TodayUsers    int64
 	TotalChannels int64
+	ByRegion      []RegionStat
+}
+
+// FunnelStep is one registration state in the funnel report
+type FunnelStep struct {
+	State string
+	Users int64 // users who entered the state in the period
+	// Conversion is Users relative to the previous step of the funnel, 0..1
+	Conversion float64
+	MedianTime time.Duration // median time before moving on
 }
 
 // UserRepository interface
@@ -83,20 +258,51 @@ type UserRepository interface {
 	GetByTelegramID(ctx context.Context, telegramID int64) (*User, error)
 	Update(ctx context.Context, user *User) error
 	UpdateState(ctx context.Context, telegramID int64, state string) error
-	UpdateFullName(ctx context.Context, telegramID int64, firstName, lastName string) error
-	UpdateLocation(ctx context.Context, telegramID int64, region, district, school string) error
+	UpdateFullName(ctx context.Context, telegramID int64, name FullName) error
+	UpdateLocation(ctx context.Context, telegramID int64, loc Location) error
 	UpdateGrade(ctx context.Context, telegramID int64, grade int) error
+	UpdateRole(ctx context.Context, telegramID int64, role string) error
+	UpdateSubject(ctx context.Context, telegramID int64, subject string) error
+	UpdateBirthDate(ctx context.Context, telegramID int64, birthDate time.Time) error
+	UpdateActiveParticipant(ctx context.Context, telegramID int64, participantID int64) error
 	UpdatePhone(ctx context.Context, telegramID int64, phone string) error
+	UpdatePendingPhone(ctx context.Context, telegramID int64, phone string) error
+	ConfirmPendingPhone(ctx context.Context, telegramID int64, method string) error
+	ResetRegistration(ctx context.Context, telegramID int64) error
 	GetAllVerified(ctx context.Context) ([]User, error)
+	GetVerifiedByRole(ctx context.Context, role string) ([]User, error)
+	SearchByName(ctx context.Context, latinQuery string, limit int) ([]User, error)
 	GetStats(ctx context.Context) (*Stats, error)
+	GetFunnel(ctx context.Context, from, to time.Time, role string) ([]FunnelStep, error)
+	GetPhoneOwner(ctx context.Context, phone string, exceptUserID int64) (*User, error)
+	GetSharedPhones(ctx context.Context) ([]PhoneGroup, error)
+	ReleasePhone(ctx context.Context, telegramID int64) error
+	LinkAlias(ctx context.Context, aliasID, primaryID int64) error
 }
 
 // OTPRepository interface
 type OTPRepository interface {
-	Create(ctx context.Context, otp *OTPCode) error
-	GetLatestByPhone(ctx context.Context, phone string) (*OTPCode, error)
-	MarkAsUsed(ctx context.Context, id int64) error
-	GetByPhoneAndCode(ctx context.Context, phone, code string) (*OTPCode, error)
+	// Create supersedes the user's earlier pending codes for the same phone
+	// Create stores the code if the quota allows it, see OTPQuota
+	Create(ctx context.Context, otp *OTPCode, quota OTPQuota) error
+	GetActive(ctx context.Context, userID int64, phone string) (*OTPCode, error)
+	MarkVerified(ctx context.Context, id int64) (bool, error)
+	// RecordFailure counts a wrong guess and invalidates the code once it
+	// reaches maxAttempts; it returns the code's failed attempts so far
+	RecordFailure(ctx context.Context, id int64, maxAttempts int) (int, error)
+	// GetRecentFailures counts wrong guesses for the phone made since the
+	// given time and returns when the last one happened
+	GetRecentFailures(ctx context.Context, phone string, since time.Time) (int, time.Time, error)
+	GetSendStats(ctx context.Context, phone string, userID int64, since time.Time) (OTPSendStats, error)
+	// DeleteFinished removes up to limit codes created before the given time
+	// that were used, replaced or have expired
+	DeleteFinished(ctx context.Context, before time.Time, limit int) (int64, error)
+	GetPendingDeliveries(ctx context.Context, since time.Time, limit int) ([]DeliveryCheck, error)
+	UpdateDeliveryStatus(ctx context.Context, id int64, status string) error
+	// UpdateChannel records that the code went out over another channel
+	// after its SMS failed
+	UpdateChannel(ctx context.Context, id int64, channel, messageID string) error
+	GetDeliveryStats(ctx context.Context, since time.Time) (DeliveryStats, error)
 }
 
 // AdminRepository interface
@@ -117,19 +323,38 @@ type ChannelRepository interface {
 // UserService interface
 type UserService interface {
 	GetOrCreateUser(ctx context.Context, telegramID int64, username, langCode string) (*User, error)
-	UpdateUserState(ctx context.Context, telegramID int64, state string) error
-	UpdateFullName(ctx context.Context, telegramID int64, firstName, lastName string) error
-	UpdateLocation(ctx context.Context, telegramID int64, region, district, school string) error
+	Transition(ctx context.Context, user *User, state string) error
+	UpdateFullName(ctx context.Context, telegramID int64, name FullName) error
+	UpdateLocation(ctx context.Context, telegramID int64, loc Location) error
 	UpdateGrade(ctx context.Context, telegramID int64, grade int) error
+	UpdateRole(ctx context.Context, telegramID int64, role string) error
+	UpdateSubject(ctx context.Context, telegramID int64, subject string) error
+	UpdateBirthDate(ctx context.Context, telegramID int64, birthDate time.Time) error
+	UpdateChildName(ctx context.Context, user *User, name FullName) error
+	GetChildren(ctx context.Context, userID int64) ([]Participant, error)
+	GetChildrenOf(ctx context.Context, users []User) (map[int64][]Participant, error)
+	SelectChild(ctx context.Context, user *User, participantID int64) error
+	StartAddChild(ctx context.Context, user *User) error
 	UpdatePhone(ctx context.Context, telegramID int64, phone string) error
+	UpdatePendingPhone(ctx context.Context, telegramID int64, phone string) error
+	ConfirmPendingPhone(ctx context.Context, telegramID int64, method string) error
+	ResetRegistration(ctx context.Context, telegramID int64) error
+	CancelEdit(ctx context.Context, telegramID int64) error
 	GetUser(ctx context.Context, telegramID int64) (*User, error)
-	VerifyUser(ctx context.Context, telegramID int64) error
+	VerifyUser(ctx context.Context, telegramID int64, method string) error
 	GetAllVerified(ctx context.Context) ([]User, error)
+	GetVerifiedByRole(ctx context.Context, role string) ([]User, error)
+	SearchUsers(ctx context.Context, query string) ([]User, error)
 	GetStats(ctx context.Context) (*Stats, error)
+	GetFunnel(ctx context.Context, from, to time.Time, role string) ([]FunnelStep, error)
+	PhoneConflict(ctx context.Context, user *User, phone string) (*User, error)
+	ResolvePhoneConflict(ctx context.Context, user *User, phone string) (PhoneResolution, error)
+	GetSharedPhones(ctx context.Context) ([]PhoneGroup, error)
+	MergePhone(ctx context.Context, phone string) (int, error)
 }
 
 // OTPService interface
 type OTPService interface {
-	GenerateAndSendOTP(ctx context.Context, userID int64, phone string) error
-	VerifyOTP(ctx context.Context, phone, code string) (bool, error)
+	GenerateAndSendOTP(ctx context.Context, user *User, phone string) error
+	VerifyOTP(ctx context.Context, userID int64, phone, code string) (OTPVerification, error)
 }
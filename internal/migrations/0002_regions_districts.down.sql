@@ -0,0 +1,11 @@
+-- migrations/0002_regions_districts.down.sql
+
+DROP INDEX IF EXISTS idx_users_district_id;
+DROP INDEX IF EXISTS idx_users_region_id;
+DROP INDEX IF EXISTS idx_districts_region_id;
+
+ALTER TABLE users DROP COLUMN IF EXISTS district_id;
+ALTER TABLE users DROP COLUMN IF EXISTS region_id;
+
+DROP TABLE IF EXISTS districts;
+DROP TABLE IF EXISTS regions;
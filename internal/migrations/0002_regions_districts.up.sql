@@ -0,0 +1,265 @@
+-- migrations/0002_regions_districts.up.sql
+
+-- Regions reference table
+CREATE TABLE IF NOT EXISTS regions (
+    id SERIAL PRIMARY KEY,
+    name_uz VARCHAR(255) NOT NULL,
+    name_ru VARCHAR(255) NOT NULL,
+    sort_order INTEGER NOT NULL DEFAULT 0
+);
+
+-- Districts reference table
+CREATE TABLE IF NOT EXISTS districts (
+    id SERIAL PRIMARY KEY,
+    region_id INTEGER NOT NULL REFERENCES regions(id) ON DELETE CASCADE,
+    name_uz VARCHAR(255) NOT NULL,
+    name_ru VARCHAR(255) NOT NULL,
+    UNIQUE (region_id, name_uz)
+);
+
+-- Canonical location on users
+ALTER TABLE users ADD COLUMN IF NOT EXISTS region_id INTEGER REFERENCES regions(id);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS district_id INTEGER REFERENCES districts(id);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_districts_region_id ON districts(region_id);
+CREATE INDEX IF NOT EXISTS idx_users_region_id ON users(region_id);
+CREATE INDEX IF NOT EXISTS idx_users_district_id ON users(district_id);
+
+-- Regions
+INSERT INTO regions (id, name_uz, name_ru, sort_order) VALUES
+    (1, 'Qoraqalpog''iston Respublikasi', 'Республика Каракалпакстан', 13),
+    (2, 'Andijon viloyati', 'Андижанская область', 2),
+    (3, 'Buxoro viloyati', 'Бухарская область', 3),
+    (4, 'Jizzax viloyati', 'Джизакская область', 4),
+    (5, 'Qashqadaryo viloyati', 'Кашкадарьинская область', 5),
+    (6, 'Navoiy viloyati', 'Навоийская область', 6),
+    (7, 'Namangan viloyati', 'Наманганская область', 7),
+    (8, 'Samarqand viloyati', 'Самаркандская область', 8),
+    (9, 'Surxondaryo viloyati', 'Сурхандарьинская область', 9),
+    (10, 'Sirdaryo viloyati', 'Сырдарьинская область', 10),
+    (11, 'Toshkent viloyati', 'Ташкентская область', 1),
+    (12, 'Farg''ona viloyati', 'Ферганская область', 11),
+    (13, 'Xorazm viloyati', 'Хорезмская область', 12),
+    (14, 'Toshkent shahri', 'г. Ташкент', 0)
+ON CONFLICT (id) DO NOTHING;
+
+SELECT setval('regions_id_seq', (SELECT MAX(id) FROM regions));
+
+-- Districts
+INSERT INTO districts (region_id, name_uz, name_ru) VALUES
+    (1, 'Amudaryo tumani', 'Амударьинский район'),
+    (1, 'Beruniy tumani', 'Берунийский район'),
+    (1, 'Bo''zatov tumani', 'Бозатауский район'),
+    (1, 'Chimboy tumani', 'Чимбайский район'),
+    (1, 'Ellikqal''a tumani', 'Элликкалинский район'),
+    (1, 'Kegeyli tumani', 'Кегейлийский район'),
+    (1, 'Mo''ynoq tumani', 'Муйнакский район'),
+    (1, 'Nukus tumani', 'Нукусский район'),
+    (1, 'Qanliko''l tumani', 'Канлыкульский район'),
+    (1, 'Qo''ng''irot tumani', 'Кунградский район'),
+    (1, 'Qorao''zak tumani', 'Караузякский район'),
+    (1, 'Shumanay tumani', 'Шуманайский район'),
+    (1, 'Taxiatosh tumani', 'Тахиаташский район'),
+    (1, 'Taxtako''pir tumani', 'Тахтакупырский район'),
+    (1, 'To''rtko''l tumani', 'Турткульский район'),
+    (1, 'Xo''jayli tumani', 'Ходжейлийский район'),
+    (1, 'Nukus shahri', 'г. Нукус'),
+    (2, 'Andijon tumani', 'Андижанский район'),
+    (2, 'Asaka tumani', 'Асакинский район'),
+    (2, 'Baliqchi tumani', 'Балыкчинский район'),
+    (2, 'Bo''z tumani', 'Бузский район'),
+    (2, 'Buloqboshi tumani', 'Булакбашинский район'),
+    (2, 'Izboskan tumani', 'Избасканский район'),
+    (2, 'Jalaquduq tumani', 'Джалакудукский район'),
+    (2, 'Marhamat tumani', 'Мархаматский район'),
+    (2, 'Oltinko''l tumani', 'Алтынкульский район'),
+    (2, 'Paxtaobod tumani', 'Пахтаабадский район'),
+    (2, 'Qo''rg''ontepa tumani', 'Кургантепинский район'),
+    (2, 'Shahrixon tumani', 'Шахриханский район'),
+    (2, 'Ulug''nor tumani', 'Улугнорский район'),
+    (2, 'Xo''jaobod tumani', 'Ходжаабадский район'),
+    (2, 'Andijon shahri', 'г. Андижан'),
+    (2, 'Xonobod shahri', 'г. Ханабад'),
+    (3, 'Buxoro tumani', 'Бухарский район'),
+    (3, 'G''ijduvon tumani', 'Гиждуванский район'),
+    (3, 'Jondor tumani', 'Жондорский район'),
+    (3, 'Kogon tumani', 'Каганский район'),
+    (3, 'Olot tumani', 'Алатский район'),
+    (3, 'Peshku tumani', 'Пешкунский район'),
+    (3, 'Qorako''l tumani', 'Каракульский район'),
+    (3, 'Qorovulbozor tumani', 'Караулбазарский район'),
+    (3, 'Romitan tumani', 'Ромитанский район'),
+    (3, 'Shofirkon tumani', 'Шафирканский район'),
+    (3, 'Vobkent tumani', 'Вабкентский район'),
+    (3, 'Buxoro shahri', 'г. Бухара'),
+    (3, 'Kogon shahri', 'г. Каган'),
+    (4, 'Arnasoy tumani', 'Арнасайский район'),
+    (4, 'Baxmal tumani', 'Бахмальский район'),
+    (4, 'Do''stlik tumani', 'Дустликский район'),
+    (4, 'Forish tumani', 'Фаришский район'),
+    (4, 'G''allaorol tumani', 'Галляаральский район'),
+    (4, 'Mirzacho''l tumani', 'Мирзачульский район'),
+    (4, 'Paxtakor tumani', 'Пахтакорский район'),
+    (4, 'Sharof Rashidov tumani', 'Шараф-Рашидовский район'),
+    (4, 'Yangiobod tumani', 'Янгиабадский район'),
+    (4, 'Zafarobod tumani', 'Зафарабадский район'),
+    (4, 'Zarbdor tumani', 'Зарбдарский район'),
+    (4, 'Zomin tumani', 'Зааминский район'),
+    (4, 'Jizzax shahri', 'г. Джизак'),
+    (5, 'Chiroqchi tumani', 'Чиракчинский район'),
+    (5, 'Dehqonobod tumani', 'Дехканабадский район'),
+    (5, 'G''uzor tumani', 'Гузарский район'),
+    (5, 'Kasbi tumani', 'Касбийский район'),
+    (5, 'Kitob tumani', 'Китабский район'),
+    (5, 'Koson tumani', 'Касанский район'),
+    (5, 'Ko''kdala tumani', 'Кукдалинский район'),
+    (5, 'Mirishkor tumani', 'Миришкорский район'),
+    (5, 'Muborak tumani', 'Мубарекский район'),
+    (5, 'Nishon tumani', 'Нишанский район'),
+    (5, 'Qamashi tumani', 'Камашинский район'),
+    (5, 'Qarshi tumani', 'Каршинский район'),
+    (5, 'Shahrisabz tumani', 'Шахрисабзский район'),
+    (5, 'Yakkabog'' tumani', 'Яккабагский район'),
+    (5, 'Qarshi shahri', 'г. Карши'),
+    (5, 'Shahrisabz shahri', 'г. Шахрисабз'),
+    (6, 'Karmana tumani', 'Карманинский район'),
+    (6, 'Konimex tumani', 'Канимехский район'),
+    (6, 'Navbahor tumani', 'Навбахорский район'),
+    (6, 'Nurota tumani', 'Нуратинский район'),
+    (6, 'Qiziltepa tumani', 'Кызылтепинский район'),
+    (6, 'Tomdi tumani', 'Тамдынский район'),
+    (6, 'Uchquduq tumani', 'Учкудукский район'),
+    (6, 'Xatirchi tumani', 'Хатырчинский район'),
+    (6, 'Navoiy shahri', 'г. Навои'),
+    (6, 'Zarafshon shahri', 'г. Зарафшан'),
+    (7, 'Chortoq tumani', 'Чартакский район'),
+    (7, 'Chust tumani', 'Чустский район'),
+    (7, 'Davlatobod tumani', 'Давлатабадский район'),
+    (7, 'Kosonsoy tumani', 'Касансайский район'),
+    (7, 'Mingbuloq tumani', 'Мингбулакский район'),
+    (7, 'Namangan tumani', 'Наманганский район'),
+    (7, 'Norin tumani', 'Нарынский район'),
+    (7, 'Pop tumani', 'Папский район'),
+    (7, 'To''raqo''rg''on tumani', 'Туракурганский район'),
+    (7, 'Uchqo''rg''on tumani', 'Учкурганский район'),
+    (7, 'Uychi tumani', 'Уйчинский район'),
+    (7, 'Yangiqo''rg''on tumani', 'Янгикурганский район'),
+    (7, 'Namangan shahri', 'г. Наманган'),
+    (8, 'Bulung''ur tumani', 'Булунгурский район'),
+    (8, 'Ishtixon tumani', 'Иштыханский район'),
+    (8, 'Jomboy tumani', 'Джамбайский район'),
+    (8, 'Kattaqo''rg''on tumani', 'Каттакурганский район'),
+    (8, 'Narpay tumani', 'Нарпайский район'),
+    (8, 'Nurobod tumani', 'Нурабадский район'),
+    (8, 'Oqdaryo tumani', 'Акдарьинский район'),
+    (8, 'Pastdarg''om tumani', 'Пастдаргомский район'),
+    (8, 'Paxtachi tumani', 'Пахтачийский район'),
+    (8, 'Payariq tumani', 'Пайарыкский район'),
+    (8, 'Qo''shrabot tumani', 'Кошрабадский район'),
+    (8, 'Samarqand tumani', 'Самаркандский район'),
+    (8, 'Toyloq tumani', 'Тайлакский район'),
+    (8, 'Urgut tumani', 'Ургутский район'),
+    (8, 'Samarqand shahri', 'г. Самарканд'),
+    (8, 'Kattaqo''rg''on shahri', 'г. Каттакурган'),
+    (9, 'Angor tumani', 'Ангорский район'),
+    (9, 'Bandixon tumani', 'Бандиханский район'),
+    (9, 'Boysun tumani', 'Байсунский район'),
+    (9, 'Denov tumani', 'Денауский район'),
+    (9, 'Jarqo''rg''on tumani', 'Джаркурганский район'),
+    (9, 'Muzrabot tumani', 'Музрабадский район'),
+    (9, 'Oltinsoy tumani', 'Алтынсайский район'),
+    (9, 'Qiziriq tumani', 'Кизирикский район'),
+    (9, 'Qumqo''rg''on tumani', 'Кумкурганский район'),
+    (9, 'Sariosiyo tumani', 'Сариасийский район'),
+    (9, 'Sherobod tumani', 'Шерабадский район'),
+    (9, 'Sho''rchi tumani', 'Шурчинский район'),
+    (9, 'Termiz tumani', 'Термезский район'),
+    (9, 'Uzun tumani', 'Узунский район'),
+    (9, 'Termiz shahri', 'г. Термез'),
+    (10, 'Boyovut tumani', 'Баяутский район'),
+    (10, 'Guliston tumani', 'Гулистанский район'),
+    (10, 'Mirzaobod tumani', 'Мирзаабадский район'),
+    (10, 'Oqoltin tumani', 'Акалтынский район'),
+    (10, 'Sardoba tumani', 'Сардобинский район'),
+    (10, 'Sayxunobod tumani', 'Сайхунабадский район'),
+    (10, 'Sirdaryo tumani', 'Сырдарьинский район'),
+    (10, 'Xovos tumani', 'Хавастский район'),
+    (10, 'Guliston shahri', 'г. Гулистан'),
+    (10, 'Shirin shahri', 'г. Ширин'),
+    (10, 'Yangiyer shahri', 'г. Янгиер'),
+    (11, 'Bekobod tumani', 'Бекабадский район'),
+    (11, 'Bo''ka tumani', 'Букинский район'),
+    (11, 'Bo''stonliq tumani', 'Бостанлыкский район'),
+    (11, 'Chinoz tumani', 'Чиназский район'),
+    (11, 'Ohangaron tumani', 'Ахангаранский район'),
+    (11, 'Oqqo''rg''on tumani', 'Аккурганский район'),
+    (11, 'O''rta Chirchiq tumani', 'Среднечирчикский район'),
+    (11, 'Parkent tumani', 'Паркентский район'),
+    (11, 'Piskent tumani', 'Пскентский район'),
+    (11, 'Qibray tumani', 'Кибрайский район'),
+    (11, 'Quyi Chirchiq tumani', 'Нижнечирчикский район'),
+    (11, 'Toshkent tumani', 'Ташкентский район'),
+    (11, 'Yangiyo''l tumani', 'Янгиюльский район'),
+    (11, 'Yuqori Chirchiq tumani', 'Верхнечирчикский район'),
+    (11, 'Zangiota tumani', 'Зангиатинский район'),
+    (11, 'Angren shahri', 'г. Ангрен'),
+    (11, 'Bekobod shahri', 'г. Бекабад'),
+    (11, 'Chirchiq shahri', 'г. Чирчик'),
+    (11, 'Nurafshon shahri', 'г. Нурафшан'),
+    (11, 'Ohangaron shahri', 'г. Ахангаран'),
+    (11, 'Olmaliq shahri', 'г. Алмалык'),
+    (11, 'Yangiyo''l shahri', 'г. Янгиюль'),
+    (12, 'Bag''dod tumani', 'Багдадский район'),
+    (12, 'Beshariq tumani', 'Бешарыкский район'),
+    (12, 'Buvayda tumani', 'Бувайдинский район'),
+    (12, 'Dang''ara tumani', 'Дангаринский район'),
+    (12, 'Farg''ona tumani', 'Ферганский район'),
+    (12, 'Furqat tumani', 'Фуркатский район'),
+    (12, 'Oltiariq tumani', 'Алтыарыкский район'),
+    (12, 'O''zbekiston tumani', 'Узбекистанский район'),
+    (12, 'Qo''shtepa tumani', 'Куштепинский район'),
+    (12, 'Quva tumani', 'Кувинский район'),
+    (12, 'Rishton tumani', 'Риштанский район'),
+    (12, 'So''x tumani', 'Сохский район'),
+    (12, 'Toshloq tumani', 'Ташлакский район'),
+    (12, 'Uchko''prik tumani', 'Учкуприкский район'),
+    (12, 'Yozyovon tumani', 'Язъяванский район'),
+    (12, 'Farg''ona shahri', 'г. Фергана'),
+    (12, 'Marg''ilon shahri', 'г. Маргилан'),
+    (12, 'Qo''qon shahri', 'г. Коканд'),
+    (12, 'Quvasoy shahri', 'г. Кувасай'),
+    (13, 'Bog''ot tumani', 'Багатский район'),
+    (13, 'Gurlan tumani', 'Гурленский район'),
+    (13, 'Hazorasp tumani', 'Хазараспский район'),
+    (13, 'Qo''shko''pir tumani', 'Кошкупырский район'),
+    (13, 'Shovot tumani', 'Шаватский район'),
+    (13, 'Tuproqqal''a tumani', 'Тупроккалинский район'),
+    (13, 'Urganch tumani', 'Ургенчский район'),
+    (13, 'Xiva tumani', 'Хивинский район'),
+    (13, 'Xonqa tumani', 'Ханкинский район'),
+    (13, 'Yangiariq tumani', 'Янгиарыкский район'),
+    (13, 'Yangibozor tumani', 'Янгибазарский район'),
+    (13, 'Urganch shahri', 'г. Ургенч'),
+    (13, 'Xiva shahri', 'г. Хива'),
+    (14, 'Bektemir tumani', 'Бектемирский район'),
+    (14, 'Chilonzor tumani', 'Чиланзарский район'),
+    (14, 'Mirobod tumani', 'Мирабадский район'),
+    (14, 'Mirzo Ulug''bek tumani', 'Мирзо-Улугбекский район'),
+    (14, 'Olmazor tumani', 'Алмазарский район'),
+    (14, 'Sergeli tumani', 'Сергелийский район'),
+    (14, 'Shayxontohur tumani', 'Шайхантахурский район'),
+    (14, 'Uchtepa tumani', 'Учтепинский район'),
+    (14, 'Yakkasaroy tumani', 'Яккасарайский район'),
+    (14, 'Yangihayot tumani', 'Янгихаётский район'),
+    (14, 'Yashnobod tumani', 'Яшнабадский район'),
+    (14, 'Yunusobod tumani', 'Юнусабадский район')
+ON CONFLICT (region_id, name_uz) DO NOTHING;
+
+-- Best-effort mapping of legacy free-text regions
+UPDATE users u
+SET region_id = r.id, region = r.name_uz
+FROM regions r
+WHERE u.region_id IS NULL
+  AND u.region IS NOT NULL
+  AND (lower(u.region) = lower(r.name_uz) OR lower(u.region) = lower(split_part(r.name_uz, ' ', 1)));
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- migrations/0003_schools.down.sql
+
+DROP INDEX IF EXISTS idx_users_school_id;
+DROP INDEX IF EXISTS idx_schools_status;
+DROP INDEX IF EXISTS idx_schools_district_number;
+DROP INDEX IF EXISTS idx_schools_district_status;
+
+ALTER TABLE users DROP COLUMN IF EXISTS school_id;
+
+DROP TABLE IF EXISTS schools;
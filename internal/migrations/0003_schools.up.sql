@@ -0,0 +1,21 @@
+-- migrations/0003_schools.up.sql
+
+-- Schools reference table (scoped to a district)
+-- status: approved | pending | rejected
+CREATE TABLE IF NOT EXISTS schools (
+    id SERIAL PRIMARY KEY,
+    district_id INTEGER NOT NULL REFERENCES districts(id) ON DELETE CASCADE,
+    number INTEGER,
+    name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'approved',
+    submitted_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS school_id INTEGER REFERENCES schools(id) ON DELETE SET NULL;
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_schools_district_status ON schools(district_id, status);
+CREATE INDEX IF NOT EXISTS idx_schools_district_number ON schools(district_id, number);
+CREATE INDEX IF NOT EXISTS idx_schools_status ON schools(status);
+CREATE INDEX IF NOT EXISTS idx_users_school_id ON users(school_id);
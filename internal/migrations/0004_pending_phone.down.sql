@@ -0,0 +1,3 @@
+-- migrations/0004_pending_phone.down.sql
+
+ALTER TABLE users DROP COLUMN IF EXISTS pending_phone;
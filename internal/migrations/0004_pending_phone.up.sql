@@ -0,0 +1,4 @@
+-- migrations/0004_pending_phone.up.sql
+
+-- New phone number waiting for OTP confirmation (profile edit)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_phone VARCHAR(20);
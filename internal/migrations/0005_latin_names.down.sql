@@ -0,0 +1,4 @@
+-- migrations/0005_latin_names.down.sql
+
+ALTER TABLE users DROP COLUMN IF EXISTS last_name_latin;
+ALTER TABLE users DROP COLUMN IF EXISTS first_name_latin;
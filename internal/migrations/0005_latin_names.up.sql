@@ -0,0 +1,43 @@
+-- migrations/0005_latin_names.up.sql
+
+-- Canonical Latin spelling of the name, whichever alphabet the user typed in
+ALTER TABLE users ADD COLUMN IF NOT EXISTS first_name_latin VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_name_latin VARCHAR(255);
+
+-- Backfill existing users. New names are transliterated by the bot (pkg/translit),
+-- this is a letter-by-letter approximation of the same rules for old rows.
+UPDATE users
+SET first_name_latin = regexp_replace(first_name, '(^|[\s-])Е', '\1Ye', 'g'),
+    last_name_latin = regexp_replace(last_name, '(^|[\s-])Е', '\1Ye', 'g')
+WHERE first_name IS NOT NULL;
+
+DO $$
+DECLARE
+    m RECORD;
+BEGIN
+    FOR m IN
+        SELECT * FROM (VALUES
+            ('Ё', 'Yo'), ('Ж', 'J'), ('Ц', 'Ts'), ('Ч', 'Ch'), ('Ш', 'Sh'), ('Щ', 'Sh'),
+            ('Ю', 'Yu'), ('Я', 'Ya'), ('Ў', 'Oʻ'), ('Ғ', 'Gʻ'), ('Қ', 'Q'), ('Ҳ', 'H'),
+            ('ё', 'yo'), ('ж', 'j'), ('ц', 'ts'), ('ч', 'ch'), ('ш', 'sh'), ('щ', 'sh'),
+            ('ю', 'yu'), ('я', 'ya'), ('ў', 'oʻ'), ('ғ', 'gʻ'), ('қ', 'q'), ('ҳ', 'h'),
+            ('ъ', 'ʼ'), ('ь', ''), ('Й', 'Y'), ('й', 'y'), ('Х', 'X'), ('х', 'x'),
+            ('Ы', 'I'), ('ы', 'i'), ('Э', 'E'), ('э', 'e')
+        ) AS t(cyr, lat)
+    LOOP
+        UPDATE users
+        SET first_name_latin = replace(first_name_latin, m.cyr, m.lat),
+            last_name_latin = replace(last_name_latin, m.cyr, m.lat)
+        WHERE first_name_latin IS NOT NULL;
+    END LOOP;
+END $$;
+
+-- One-to-one letters
+UPDATE users
+SET first_name_latin = translate(first_name_latin,
+        'АБВГДЕЗИКЛМНОПРСТУФабвгдезиклмнопрстуф',
+        'ABVGDEZIKLMNOPRSTUFabvgdeziklmnoprstuf'),
+    last_name_latin = translate(last_name_latin,
+        'АБВГДЕЗИКЛМНОПРСТУФабвгдезиклмнопрстуф',
+        'ABVGDEZIKLMNOPRSTUFabvgdeziklmnoprstuf')
+WHERE first_name_latin IS NOT NULL;
@@ -0,0 +1,6 @@
+-- migrations/0006_roles.down.sql
+
+DROP INDEX IF EXISTS idx_users_role;
+
+ALTER TABLE users DROP COLUMN IF EXISTS subject;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
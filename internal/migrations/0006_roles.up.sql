@@ -0,0 +1,14 @@
+-- migrations/0006_roles.up.sql
+
+-- Participant role chosen at the start of registration
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20)
+    CHECK (role IN ('student', 'teacher', 'parent'));
+
+-- Role specific fields: teachers give a subject. Parents' children are kept
+-- in participants (0007).
+ALTER TABLE users ADD COLUMN IF NOT EXISTS subject VARCHAR(255);
+
+-- Everyone registered so far went through the student flow
+UPDATE users SET role = 'student' WHERE role IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
@@ -0,0 +1,4 @@
+-- migrations/0007_participants.down.sql
+
+ALTER TABLE users DROP COLUMN IF EXISTS active_participant_id;
+DROP TABLE IF EXISTS participants;
@@ -0,0 +1,28 @@
+-- migrations/0007_participants.up.sql
+
+-- Children registered by a parent. One Telegram account (users row) can have
+-- several participants, each with their own school and grade.
+CREATE TABLE IF NOT EXISTS participants (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    first_name VARCHAR(255),
+    last_name VARCHAR(255),
+    first_name_latin VARCHAR(255),
+    last_name_latin VARCHAR(255),
+    region_id INTEGER REFERENCES regions(id) ON DELETE SET NULL,
+    region VARCHAR(255),
+    district_id INTEGER REFERENCES districts(id) ON DELETE SET NULL,
+    district VARCHAR(255),
+    school_id INTEGER REFERENCES schools(id) ON DELETE SET NULL,
+    school VARCHAR(255),
+    grade INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_participants_user_id ON participants(user_id);
+CREATE INDEX IF NOT EXISTS idx_participants_school_id ON participants(school_id);
+
+-- The child a parent is currently adding or editing in the bot
+ALTER TABLE users ADD COLUMN IF NOT EXISTS active_participant_id INTEGER
+    REFERENCES participants(id) ON DELETE SET NULL;
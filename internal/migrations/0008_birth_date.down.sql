@@ -0,0 +1,4 @@
+-- migrations/0008_birth_date.down.sql
+
+ALTER TABLE participants DROP COLUMN IF EXISTS birth_date;
+ALTER TABLE users DROP COLUMN IF EXISTS birth_date;
@@ -0,0 +1,5 @@
+-- migrations/0008_birth_date.up.sql
+
+-- Olympiad eligibility depends on age, so students and children give a birth date
+ALTER TABLE users ADD COLUMN IF NOT EXISTS birth_date DATE;
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS birth_date DATE;
@@ -0,0 +1,4 @@
+-- migrations/0009_registration_reminders.down.sql
+
+DROP INDEX IF EXISTS idx_users_unverified_state;
+DROP TABLE IF EXISTS registration_reminders;
@@ -0,0 +1,14 @@
+-- migrations/0009_registration_reminders.up.sql
+
+-- Nudges sent to users who stopped in the middle of registration. A user is
+-- reminded at most REMINDER_MAX_ATTEMPTS times per step they got stuck on.
+CREATE TABLE IF NOT EXISTS registration_reminders (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    state VARCHAR(50) NOT NULL,
+    attempt INTEGER NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_registration_reminders_user_id ON registration_reminders(user_id, sent_at);
+CREATE INDEX IF NOT EXISTS idx_users_unverified_state ON users(state, updated_at) WHERE is_verified = FALSE;
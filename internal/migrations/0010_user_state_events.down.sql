@@ -0,0 +1,3 @@
+-- migrations/0010_user_state_events.down.sql
+
+DROP TABLE IF EXISTS user_state_events;
@@ -0,0 +1,14 @@
+-- migrations/0010_user_state_events.up.sql
+
+-- Every change of users.state, so the admin funnel can show where people drop
+-- off and how long each step takes. History starts with this migration.
+CREATE TABLE IF NOT EXISTS user_state_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_state VARCHAR(50),
+    to_state VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_state_events_user_id ON user_state_events(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_user_state_events_created_at ON user_state_events(created_at);
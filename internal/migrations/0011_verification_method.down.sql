@@ -0,0 +1,3 @@
+-- migrations/0011_verification_method.down.sql
+
+ALTER TABLE users DROP COLUMN IF EXISTS verification_method;
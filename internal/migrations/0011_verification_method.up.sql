@@ -0,0 +1,9 @@
+-- migrations/0011_verification_method.up.sql
+
+-- How the phone number was confirmed: an SMS code, or the user's own contact
+-- shared through Telegram (PHONE_VERIFICATION_POLICY=trust_own_contact)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verification_method VARCHAR(20)
+    CHECK (verification_method IN ('sms_otp', 'telegram_contact'));
+
+-- Until now every number was confirmed by SMS
+UPDATE users SET verification_method = 'sms_otp' WHERE is_verified = TRUE AND verification_method IS NULL;
@@ -0,0 +1,4 @@
+-- migrations/0012_phone_aliases.down.sql
+
+DROP INDEX IF EXISTS idx_users_primary_user_id;
+ALTER TABLE users DROP COLUMN IF EXISTS primary_user_id;
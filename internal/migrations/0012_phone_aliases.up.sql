@@ -0,0 +1,9 @@
+-- migrations/0012_phone_aliases.up.sql
+
+-- A second Telegram account of the same person (same verified phone) is kept as
+-- an alias of the first one: it shares the primary account's children and is
+-- not counted separately in statistics and exports.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS primary_user_id INTEGER
+    REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_primary_user_id ON users(primary_user_id);
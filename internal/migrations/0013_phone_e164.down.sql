@@ -0,0 +1,5 @@
+-- migrations/0013_phone_e164.down.sql
+
+UPDATE users SET phone = ltrim(phone, '+') WHERE phone LIKE '+%';
+UPDATE users SET pending_phone = ltrim(pending_phone, '+') WHERE pending_phone LIKE '+%';
+UPDATE otp_codes SET phone = ltrim(phone, '+') WHERE phone LIKE '+%';
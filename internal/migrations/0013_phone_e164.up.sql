@@ -0,0 +1,7 @@
+-- migrations/0013_phone_e164.up.sql
+
+-- Phone numbers are stored in E.164 form with a leading "+" so numbers from
+-- other countries can be told apart. Existing rows were all 998XXXXXXXXX.
+UPDATE users SET phone = '+' || phone WHERE phone ~ '^[0-9]+$';
+UPDATE users SET pending_phone = '+' || pending_phone WHERE pending_phone ~ '^[0-9]+$';
+UPDATE otp_codes SET phone = '+' || phone WHERE phone ~ '^[0-9]+$';
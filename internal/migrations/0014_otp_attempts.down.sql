@@ -0,0 +1,4 @@
+-- migrations/0014_otp_attempts.down.sql
+
+DROP TABLE IF EXISTS otp_failures;
+ALTER TABLE otp_codes DROP COLUMN IF EXISTS failed_attempts;
@@ -0,0 +1,16 @@
+-- migrations/0014_otp_attempts.up.sql
+
+-- Wrong guesses per code: the code is invalidated after OTP_MAX_ATTEMPTS
+ALTER TABLE otp_codes ADD COLUMN IF NOT EXISTS failed_attempts INTEGER NOT NULL DEFAULT 0;
+
+-- One row per wrong guess, so the phone lockout counts only the guesses made
+-- within OTP_LOCKOUT_MINS and locks once they reach OTP_PHONE_MAX_FAILURES
+CREATE TABLE IF NOT EXISTS otp_failures (
+    id BIGSERIAL PRIMARY KEY,
+    otp_id INTEGER NOT NULL REFERENCES otp_codes(id) ON DELETE CASCADE,
+    phone VARCHAR(20) NOT NULL,
+    failed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_otp_failures_phone ON otp_failures(phone, failed_at);
+CREATE INDEX IF NOT EXISTS idx_otp_failures_otp_id ON otp_failures(otp_id);
@@ -0,0 +1,4 @@
+-- migrations/0015_otp_quota_indexes.down.sql
+
+DROP INDEX IF EXISTS idx_otp_codes_user_id;
+DROP INDEX IF EXISTS idx_otp_codes_created_at;
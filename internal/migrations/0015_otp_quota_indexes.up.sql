@@ -0,0 +1,5 @@
+-- migrations/0015_otp_quota_indexes.up.sql
+
+-- SMS quotas count codes sent in the last 24 hours per user and overall
+CREATE INDEX IF NOT EXISTS idx_otp_codes_created_at ON otp_codes(created_at);
+CREATE INDEX IF NOT EXISTS idx_otp_codes_user_id ON otp_codes(user_id, created_at);
@@ -0,0 +1,8 @@
+-- migrations/0016_otp_code_hash.down.sql
+
+-- Hashed codes cannot be turned back into plaintext; they are invalidated
+ALTER TABLE otp_codes ADD COLUMN IF NOT EXISTS code VARCHAR(6) NOT NULL DEFAULT '';
+UPDATE otp_codes SET is_used = TRUE;
+ALTER TABLE otp_codes DROP COLUMN IF EXISTS code_hash;
+
+CREATE INDEX IF NOT EXISTS idx_otp_codes_code ON otp_codes(code);
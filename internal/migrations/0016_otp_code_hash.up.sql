@@ -0,0 +1,13 @@
+-- migrations/0016_otp_code_hash.up.sql
+
+-- Codes are stored as a hex HMAC-SHA256 keyed with OTP_SECRET. Plaintext codes
+-- cannot be hashed here without the key, so codes still active are invalidated:
+-- users waiting for one simply request a new code.
+ALTER TABLE otp_codes ADD COLUMN IF NOT EXISTS code_hash VARCHAR(64);
+
+UPDATE otp_codes SET is_used = TRUE, code_hash = '' WHERE code_hash IS NULL;
+
+ALTER TABLE otp_codes ALTER COLUMN code_hash SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_otp_codes_code;
+ALTER TABLE otp_codes DROP COLUMN IF EXISTS code;
@@ -0,0 +1,7 @@
+-- migrations/0017_otp_status.down.sql
+
+DROP INDEX IF EXISTS idx_otp_codes_user_phone_status;
+
+ALTER TABLE otp_codes ADD COLUMN IF NOT EXISTS is_used BOOLEAN DEFAULT FALSE;
+UPDATE otp_codes SET is_used = (status <> 'pending');
+ALTER TABLE otp_codes DROP COLUMN IF EXISTS status;
@@ -0,0 +1,13 @@
+-- migrations/0017_otp_status.up.sql
+
+-- is_used could not tell a verified code from one replaced by a resend or
+-- burnt by wrong guesses. Old used codes are recorded as verified, except
+-- those invalidated when codes started being hashed.
+ALTER TABLE otp_codes ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'pending';
+
+UPDATE otp_codes SET status = CASE WHEN code_hash = '' THEN 'superseded' ELSE 'verified' END
+WHERE is_used = TRUE;
+
+ALTER TABLE otp_codes DROP COLUMN IF EXISTS is_used;
+
+CREATE INDEX IF NOT EXISTS idx_otp_codes_user_phone_status ON otp_codes(user_id, phone, status);
@@ -0,0 +1,3 @@
+-- migrations/0018_otp_cleanup_indexes.down.sql
+
+DROP INDEX IF EXISTS idx_otp_codes_expires_at;
@@ -0,0 +1,5 @@
+-- migrations/0018_otp_cleanup_indexes.up.sql
+
+-- The cleanup job deletes finished codes by created_at (indexed in 0015) and
+-- expired ones by expires_at. 0001_drop_tables.down.sql already drops this index.
+CREATE INDEX IF NOT EXISTS idx_otp_codes_expires_at ON otp_codes(expires_at);
@@ -0,0 +1,5 @@
+-- migrations/0019_sms_delivery_status.down.sql
+
+DROP INDEX IF EXISTS idx_otp_codes_delivery_pending;
+ALTER TABLE otp_codes DROP COLUMN IF EXISTS delivery_checked_at;
+ALTER TABLE otp_codes DROP COLUMN IF EXISTS delivery_status;
@@ -0,0 +1,11 @@
+-- migrations/0019_sms_delivery_status.up.sql
+
+-- Delivery report of the OTP message from the SMS gateway. Codes sent before
+-- tracking started are not polled.
+ALTER TABLE otp_codes ADD COLUMN IF NOT EXISTS delivery_status VARCHAR(20) NOT NULL DEFAULT 'pending';
+ALTER TABLE otp_codes ADD COLUMN IF NOT EXISTS delivery_checked_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE otp_codes SET delivery_status = 'unknown';
+
+CREATE INDEX IF NOT EXISTS idx_otp_codes_delivery_pending ON otp_codes(created_at)
+    WHERE delivery_status = 'pending';
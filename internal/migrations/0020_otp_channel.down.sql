@@ -0,0 +1,3 @@
+-- migrations/0020_otp_channel.down.sql
+
+ALTER TABLE otp_codes DROP COLUMN IF EXISTS channel;
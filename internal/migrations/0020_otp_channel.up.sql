@@ -0,0 +1,4 @@
+-- migrations/0020_otp_channel.up.sql
+
+-- Which channel delivered the code: sms, telegram or log (development)
+ALTER TABLE otp_codes ADD COLUMN IF NOT EXISTS channel VARCHAR(20) NOT NULL DEFAULT 'sms';
@@ -0,0 +1,3 @@
+-- migrations/0021_sms_templates.down.sql
+
+DROP TABLE IF EXISTS sms_templates;
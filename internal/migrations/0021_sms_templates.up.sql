@@ -0,0 +1,10 @@
+-- migrations/0021_sms_templates.up.sql
+
+-- Admin overrides of the OTP SMS text per language. The text may use {code},
+-- {minutes} and {brand}; languages without a row use the built-in text.
+CREATE TABLE IF NOT EXISTS sms_templates (
+    language_code VARCHAR(5) PRIMARY KEY,
+    template TEXT NOT NULL,
+    updated_by BIGINT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
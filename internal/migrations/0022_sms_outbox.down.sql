@@ -0,0 +1,3 @@
+-- migrations/0022_sms_outbox.down.sql
+
+DROP TABLE IF EXISTS sms_outbox;
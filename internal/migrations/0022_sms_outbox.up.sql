@@ -0,0 +1,27 @@
+-- migrations/0022_sms_outbox.up.sql
+
+-- OTP SMS waiting to be sent by the outbox worker. message_id is our own
+-- random ID, kept across retries so a gateway can drop a duplicate. The text
+-- is not stored: the worker rebuilds it from the language and the code, which
+-- is kept AES-GCM encrypted with a key derived from OTP_SECRET and cleared
+-- once the message is finished.
+CREATE TABLE IF NOT EXISTS sms_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    message_id VARCHAR(64) NOT NULL UNIQUE,
+    otp_id INTEGER NOT NULL REFERENCES otp_codes(id) ON DELETE CASCADE,
+    telegram_id BIGINT NOT NULL,
+    phone VARCHAR(20) NOT NULL,
+    language_code VARCHAR(10) NOT NULL DEFAULT '',
+    sealed_code BYTEA,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    provider_message_id VARCHAR(100),
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sms_outbox_due ON sms_outbox(next_attempt_at)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_sms_outbox_otp_id ON sms_outbox(otp_id);
@@ -0,0 +1,7 @@
+-- migrations/0023_chat_otp_verification.down.sql
+
+UPDATE users SET verification_method = NULL WHERE verification_method = 'chat_otp';
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_verification_method_check;
+ALTER TABLE users ADD CONSTRAINT users_verification_method_check
+    CHECK (verification_method IN ('sms_otp', 'telegram_contact'));
@@ -0,0 +1,8 @@
+-- migrations/0023_chat_otp_verification.up.sql
+
+-- A code sent to the user's own chat (or the log) instead of by SMS does not
+-- prove the phone. Such users are registered with chat_otp and never count
+-- as the owner of the number.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_verification_method_check;
+ALTER TABLE users ADD CONSTRAINT users_verification_method_check
+    CHECK (verification_method IN ('sms_otp', 'telegram_contact', 'chat_otp'));
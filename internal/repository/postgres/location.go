@@ -0,0 +1,97 @@
+// internal/repository/postgres/location.go
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/storage"
+)
+
+type LocationRepository struct {
+	db *storage.Storage
+}
+
+func NewLocationRepository(db *storage.Storage) *LocationRepository {
+	return &LocationRepository{db: db}
+}
+
+func (r *LocationRepository) GetRegions(ctx context.Context) ([]domain.Region, error) {
+	query := `SELECT id, name_uz, name_ru, sort_order FROM regions ORDER BY sort_order, name_uz`
+
+	rows, err := r.db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get regions: %w", err)
+	}
+	defer rows.Close()
+
+	var regions []domain.Region
+	for rows.Next() {
+		var reg domain.Region
+		if err := rows.Scan(&reg.ID, &reg.NameUz, &reg.NameRu, &reg.SortOrder); err != nil {
+			return nil, fmt.Errorf("scan region: %w", err)
+		}
+		regions = append(regions, reg)
+	}
+
+	return regions, nil
+}
+
+func (r *LocationRepository) GetRegionByID(ctx context.Context, id int64) (*domain.Region, error) {
+	query := `SELECT id, name_uz, name_ru, sort_order FROM regions WHERE id = $1`
+
+	var reg domain.Region
+	err := r.db.Pool.QueryRow(ctx, query, id).Scan(&reg.ID, &reg.NameUz, &reg.NameRu, &reg.SortOrder)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get region by id: %w", err)
+	}
+
+	return &reg, nil
+}
+
+func (r *LocationRepository) GetDistrictsByRegion(ctx context.Context, regionID int64) ([]domain.District, error) {
+	// Tumanlar avval, shaharlar oxirida
+	query := `
+		SELECT id, region_id, name_uz, name_ru
+		FROM districts
+		WHERE region_id = $1
+		ORDER BY name_uz LIKE '% shahri', name_uz`
+
+	rows, err := r.db.Pool.Query(ctx, query, regionID)
+	if err != nil {
+		return nil, fmt.Errorf("get districts: %w", err)
+	}
+	defer rows.Close()
+
+	var districts []domain.District
+	for rows.Next() {
+		var d domain.District
+		if err := rows.Scan(&d.ID, &d.RegionID, &d.NameUz, &d.NameRu); err != nil {
+			return nil, fmt.Errorf("scan district: %w", err)
+		}
+		districts = append(districts, d)
+	}
+
+	return districts, nil
+}
+
+func (r *LocationRepository) GetDistrictByID(ctx context.Context, id int64) (*domain.District, error) {
+	query := `SELECT id, region_id, name_uz, name_ru FROM districts WHERE id = $1`
+
+	var d domain.District
+	err := r.db.Pool.QueryRow(ctx, query, id).Scan(&d.ID, &d.RegionID, &d.NameUz, &d.NameRu)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get district by id: %w", err)
+	}
+
+	return &d, nil
+}
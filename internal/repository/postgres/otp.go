@@ -6,8 +6,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"khisobot/internal/domain"
 	"khisobot/pkg/storage"
 )
@@ -20,48 +22,102 @@ func NewOTPRepository(db *storage.Storage) *OTPRepository {
 	return &OTPRepository{db: db}
 }
 
-func (r *OTPRepository) Create(ctx context.Context, otp *domain.OTPCode) error {
+// Create stores a new code and, in the same statement, supersedes the codes
+// still pending for that user and phone so only the newest one can be used
+func (r *OTPRepository) Create(ctx context.Context, otp *domain.OTPCode, quota domain.OTPQuota) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("create otp: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := createOTP(ctx, tx, otp, quota); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("create otp: %w", err)
+	}
+	return nil
+}
+
+// createOTP checks the quota and inserts the code. The advisory locks are held
+// until the transaction ends, so concurrent sends to the same phone or by the
+// same user wait for each other and see each other's codes. The phone is
+// always locked first, so two transactions cannot deadlock.
+func createOTP(ctx context.Context, tx pgx.Tx, otp *domain.OTPCode, quota domain.OTPQuota) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('otp_phone:' || $1))`, otp.Phone); err != nil {
+		return fmt.Errorf("lock otp phone: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('otp_user:' || $1))`,
+		strconv.FormatInt(otp.UserID, 10)); err != nil {
+		return fmt.Errorf("lock otp user: %w", err)
+	}
+
+	stats, err := getSendStats(ctx, tx, otp.Phone, otp.UserID, quota.Since)
+	if err != nil {
+		return err
+	}
+	if err := quota.Allow(stats); err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO otp_codes (user_id, phone, code, message_id, expires_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		WITH superseded AS (
+			UPDATE otp_codes SET status = $8
+			WHERE user_id = $1 AND phone = $2 AND status = $7
+		)
+		INSERT INTO otp_codes (user_id, phone, code_hash, message_id, status, channel, delivery_status,
+		                       expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $7, $9, $10, $5, $6)
 		RETURNING id`
 
 	now := time.Now()
-	err := r.db.Pool.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		otp.UserID,
 		otp.Phone,
-		otp.Code,
-		otp.MessageID,
+		otp.CodeHash,
+		nullString(otp.MessageID),
 		otp.ExpiresAt,
 		now,
+		domain.OTPStatusPending,
+		domain.OTPStatusSuperseded,
+		otp.Channel,
+		otp.DeliveryStatus,
 	).Scan(&otp.ID)
 
 	if err != nil {
 		return fmt.Errorf("create otp: %w", err)
 	}
 
+	otp.Status = domain.OTPStatusPending
 	otp.CreatedAt = now
 	return nil
 }
 
-func (r *OTPRepository) GetLatestByPhone(ctx context.Context, phone string) (*domain.OTPCode, error) {
+// GetActive returns the pending code sent to the user for this phone, if it has not expired
+func (r *OTPRepository) GetActive(ctx context.Context, userID int64, phone string) (*domain.OTPCode, error) {
 	query := `
-		SELECT id, user_id, phone, code, message_id, is_used, expires_at, created_at
+		SELECT id, user_id, phone, code_hash, message_id, status, failed_attempts, channel, delivery_status,
+		       expires_at, created_at
 		FROM otp_codes
-		WHERE phone = $1 AND is_used = FALSE AND expires_at > NOW()
+		WHERE user_id = $1 AND phone = $2 AND status = $3 AND expires_at > NOW()
 		ORDER BY created_at DESC
 		LIMIT 1`
 
 	var otp domain.OTPCode
 	var msgID sql.NullString
 
-	err := r.db.Pool.QueryRow(ctx, query, phone).Scan(
+	err := r.db.Pool.QueryRow(ctx, query, userID, phone, domain.OTPStatusPending).Scan(
 		&otp.ID,
 		&otp.UserID,
 		&otp.Phone,
-		&otp.Code,
+		&otp.CodeHash,
 		&msgID,
-		&otp.IsUsed,
+		&otp.Status,
+		&otp.FailedAttempts,
+		&otp.Channel,
+		&otp.DeliveryStatus,
 		&otp.ExpiresAt,
 		&otp.CreatedAt,
 	)
@@ -70,51 +126,184 @@ func (r *OTPRepository) GetLatestByPhone(ctx context.Context, phone string) (*do
 		return nil, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("get latest otp: %w", err)
+		return nil, fmt.Errorf("get active otp: %w", err)
 	}
 
 	otp.MessageID = msgID.String
 	return &otp, nil
 }
 
-func (r *OTPRepository) GetByPhoneAndCode(ctx context.Context, phone, code string) (*domain.OTPCode, error) {
+// MarkVerified consumes a pending code; false means another request got to it first
+func (r *OTPRepository) MarkVerified(ctx context.Context, id int64) (bool, error) {
+	query := `UPDATE otp_codes SET status = $2 WHERE id = $1 AND status = $3`
+	tag, err := r.db.Pool.Exec(ctx, query, id, domain.OTPStatusVerified, domain.OTPStatusPending)
+	if err != nil {
+		return false, fmt.Errorf("mark otp as verified: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *OTPRepository) RecordFailure(ctx context.Context, id int64, maxAttempts int) (int, error) {
 	query := `
-		SELECT id, user_id, phone, code, message_id, is_used, expires_at, created_at
-		FROM otp_codes
-		WHERE phone = $1 AND code = $2 AND is_used = FALSE AND expires_at > NOW()
-		ORDER BY created_at DESC
-		LIMIT 1`
+		WITH failed AS (
+			UPDATE otp_codes
+			SET failed_attempts = failed_attempts + 1,
+			    status = CASE WHEN failed_attempts + 1 >= $2 THEN $3 ELSE status END
+			WHERE id = $1
+			RETURNING id, phone, failed_attempts
+		), logged AS (
+			INSERT INTO otp_failures (otp_id, phone, failed_at)
+			SELECT id, phone, NOW() FROM failed
+		)
+		SELECT failed_attempts FROM failed`
 
-	var otp domain.OTPCode
-	var msgID sql.NullString
+	var failed int
+	if err := r.db.Pool.QueryRow(ctx, query, id, maxAttempts, domain.OTPStatusExhausted).Scan(&failed); err != nil {
+		return 0, fmt.Errorf("record otp failure: %w", err)
+	}
+	return failed, nil
+}
 
-	err := r.db.Pool.QueryRow(ctx, query, phone, code).Scan(
-		&otp.ID,
-		&otp.UserID,
-		&otp.Phone,
-		&otp.Code,
-		&msgID,
-		&otp.IsUsed,
-		&otp.ExpiresAt,
-		&otp.CreatedAt,
+func (r *OTPRepository) GetRecentFailures(ctx context.Context, phone string, since time.Time) (int, time.Time, error) {
+	query := `
+		SELECT COUNT(*), MAX(failed_at)
+		FROM otp_failures
+		WHERE phone = $1 AND failed_at >= $2`
+
+	var failures int
+	var lastFailedAt sql.NullTime
+	if err := r.db.Pool.QueryRow(ctx, query, phone, since).Scan(&failures, &lastFailedAt); err != nil {
+		return 0, time.Time{}, fmt.Errorf("get recent otp failures: %w", err)
+	}
+	return failures, lastFailedAt.Time, nil
+}
+
+func (r *OTPRepository) GetSendStats(ctx context.Context, phone string, userID int64, since time.Time) (domain.OTPSendStats, error) {
+	return getSendStats(ctx, r.db.Pool, phone, userID, since)
+}
+
+// rowQuerier is a pool or a transaction
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func getSendStats(ctx context.Context, q rowQuerier, phone string, userID int64, since time.Time) (domain.OTPSendStats, error) {
+	query := `
+		SELECT COUNT(*) FILTER (WHERE channel = $4 AND phone = $1),
+		       COUNT(*) FILTER (WHERE channel = $4 AND user_id = $2),
+		       COUNT(*) FILTER (WHERE channel = $4),
+		       MAX(created_at) FILTER (WHERE phone = $1 OR user_id = $2)
+		FROM otp_codes
+		WHERE created_at >= $3`
+
+	var stats domain.OTPSendStats
+	var lastSentAt sql.NullTime
+	err := q.QueryRow(ctx, query, phone, userID, since, domain.OTPChannelSMS).Scan(
+		&stats.PhoneCount,
+		&stats.UserCount,
+		&stats.TotalCount,
+		&lastSentAt,
 	)
+	if err != nil {
+		return domain.OTPSendStats{}, fmt.Errorf("get otp send stats: %w", err)
+	}
 
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
+	stats.LastSentAt = lastSentAt.Time
+	return stats, nil
+}
+
+func (r *OTPRepository) DeleteFinished(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM otp_codes
+		WHERE id IN (
+			SELECT id FROM otp_codes
+			WHERE created_at < $1 AND (status <> $2 OR expires_at < NOW())
+			ORDER BY id
+			LIMIT $3
+		)`
+
+	tag, err := r.db.Pool.Exec(ctx, query, before, domain.OTPStatusPending, limit)
+	if err != nil {
+		return 0, fmt.Errorf("delete finished otp codes: %w", err)
 	}
+	return tag.RowsAffected(), nil
+}
+
+// GetPendingDeliveries returns OTP messages sent since the given time that
+// have no final delivery report, oldest first
+func (r *OTPRepository) GetPendingDeliveries(ctx context.Context, since time.Time, limit int) ([]domain.DeliveryCheck, error) {
+	query := `
+		SELECT o.id, o.user_id, o.phone, o.message_id, o.status, o.expires_at, o.created_at, u.telegram_id
+		FROM otp_codes o
+		JOIN users u ON u.id = o.user_id
+		WHERE o.channel = $4 AND o.delivery_status = $1 AND o.message_id IS NOT NULL AND o.created_at >= $2
+		ORDER BY o.created_at
+		LIMIT $3`
+
+	rows, err := r.db.Pool.Query(ctx, query, domain.DeliveryStatusPending, since, limit, domain.OTPChannelSMS)
 	if err != nil {
-		return nil, fmt.Errorf("get otp by phone and code: %w", err)
+		return nil, fmt.Errorf("get pending deliveries: %w", err)
 	}
+	defer rows.Close()
 
-	otp.MessageID = msgID.String
-	return &otp, nil
+	var checks []domain.DeliveryCheck
+	for rows.Next() {
+		var c domain.DeliveryCheck
+		err := rows.Scan(
+			&c.OTP.ID,
+			&c.OTP.UserID,
+			&c.OTP.Phone,
+			&c.OTP.MessageID,
+			&c.OTP.Status,
+			&c.OTP.ExpiresAt,
+			&c.OTP.CreatedAt,
+			&c.TelegramID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan pending delivery: %w", err)
+		}
+		c.OTP.Channel = domain.OTPChannelSMS
+		c.OTP.DeliveryStatus = domain.DeliveryStatusPending
+		checks = append(checks, c)
+	}
+
+	return checks, nil
 }
 
-func (r *OTPRepository) MarkAsUsed(ctx context.Context, id int64) error {
-	query := `UPDATE otp_codes SET is_used = TRUE WHERE id = $1`
-	_, err := r.db.Pool.Exec(ctx, query, id)
+func (r *OTPRepository) UpdateDeliveryStatus(ctx context.Context, id int64, status string) error {
+	query := `UPDATE otp_codes SET delivery_status = $2, delivery_checked_at = NOW() WHERE id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id, status)
 	if err != nil {
-		return fmt.Errorf("mark otp as used: %w", err)
+		return fmt.Errorf("update delivery status: %w", err)
 	}
 	return nil
 }
+
+func (r *OTPRepository) UpdateChannel(ctx context.Context, id int64, channel, messageID string) error {
+	query := `
+		UPDATE otp_codes SET channel = $2, message_id = $3, delivery_status = $4, delivery_checked_at = NOW()
+		WHERE id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id, channel, nullString(messageID), domain.DeliveryStatusDelivered)
+	if err != nil {
+		return fmt.Errorf("update otp channel: %w", err)
+	}
+	return nil
+}
+
+func (r *OTPRepository) GetDeliveryStats(ctx context.Context, since time.Time) (domain.DeliveryStats, error) {
+	query := `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE delivery_status = $2),
+		       COUNT(*) FILTER (WHERE delivery_status = $3)
+		FROM otp_codes
+		WHERE created_at >= $1 AND channel = $4 AND message_id IS NOT NULL AND delivery_status <> $5`
+
+	var stats domain.DeliveryStats
+	err := r.db.Pool.QueryRow(ctx, query, since, domain.DeliveryStatusDelivered, domain.DeliveryStatusFailed,
+		domain.OTPChannelSMS, domain.DeliveryStatusUnknown).
+		Scan(&stats.Sent, &stats.Delivered, &stats.Failed)
+	if err != nil {
+		return domain.DeliveryStats{}, fmt.Errorf("get delivery stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,192 @@
+// internal/repository/postgres/participant.go
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/storage"
+)
+
+type ParticipantRepository struct {
+	db *storage.Storage
+}
+
+func NewParticipantRepository(db *storage.Storage) *ParticipantRepository {
+	return &ParticipantRepository{db: db}
+}
+
+func (r *ParticipantRepository) Create(ctx context.Context, p *domain.Participant) error {
+	query := `
+		INSERT INTO participants (user_id, first_name, last_name, first_name_latin, last_name_latin,
+		                          created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.Pool.QueryRow(ctx, query,
+		p.UserID, p.FirstName, p.LastName, p.FirstNameLatin, p.LastNameLatin, now,
+	).Scan(&p.ID)
+	if err != nil {
+		return fmt.Errorf("create participant: %w", err)
+	}
+
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	return nil
+}
+
+func (r *ParticipantRepository) GetByID(ctx context.Context, id int64) (*domain.Participant, error) {
+	query := `SELECT ` + participantColumns + ` FROM participants WHERE id = $1`
+
+	p, err := scanParticipant(r.db.Pool.QueryRow(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get participant by id: %w", err)
+	}
+
+	return p, nil
+}
+
+func (r *ParticipantRepository) GetByUserID(ctx context.Context, userID int64) ([]domain.Participant, error) {
+	query := `SELECT ` + participantColumns + ` FROM participants WHERE user_id = $1 ORDER BY id`
+	return r.list(ctx, "get participants by user", query, userID)
+}
+
+func (r *ParticipantRepository) GetByUserIDs(ctx context.Context, userIDs []int64) ([]domain.Participant, error) {
+	query := `SELECT ` + participantColumns + ` FROM participants WHERE user_id = ANY($1) ORDER BY user_id, id`
+	return r.list(ctx, "get participants by users", query, userIDs)
+}
+
+func (r *ParticipantRepository) UpdateName(ctx context.Context, id int64, name domain.FullName) error {
+	query := `
+		UPDATE participants
+		SET first_name = $2, last_name = $3, first_name_latin = $4, last_name_latin = $5, updated_at = $6
+		WHERE id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id, name.First, name.Last, name.FirstLatin, name.LastLatin, time.Now())
+	if err != nil {
+		return fmt.Errorf("update participant name: %w", err)
+	}
+	return nil
+}
+
+func (r *ParticipantRepository) UpdateLocation(ctx context.Context, id int64, loc domain.Location) error {
+	query := `
+		UPDATE participants
+		SET region_id = $2, region = $3, district_id = $4, district = $5,
+		    school_id = $6, school = $7, updated_at = $8
+		WHERE id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id,
+		nullInt64(loc.RegionID), loc.Region,
+		nullInt64(loc.DistrictID), loc.District,
+		nullInt64(loc.SchoolID), loc.School,
+		time.Now())
+	if err != nil {
+		return fmt.Errorf("update participant location: %w", err)
+	}
+	return nil
+}
+
+func (r *ParticipantRepository) UpdateGrade(ctx context.Context, id int64, grade int) error {
+	query := `UPDATE participants SET grade = $2, updated_at = $3 WHERE id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id, grade, time.Now())
+	if err != nil {
+		return fmt.Errorf("update participant grade: %w", err)
+	}
+	return nil
+}
+
+func (r *ParticipantRepository) UpdateBirthDate(ctx context.Context, id int64, birthDate time.Time) error {
+	query := `UPDATE participants SET birth_date = $2, updated_at = $3 WHERE id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id, nullDate(birthDate), time.Now())
+	if err != nil {
+		return fmt.Errorf("update participant birth date: %w", err)
+	}
+	return nil
+}
+
+// DeleteUnverified drops children entered during a registration that was cancelled
+func (r *ParticipantRepository) DeleteUnverified(ctx context.Context, telegramID int64) error {
+	query := `
+		DELETE FROM participants
+		WHERE user_id = (SELECT id FROM users WHERE telegram_id = $1 AND is_verified = FALSE)`
+	_, err := r.db.Pool.Exec(ctx, query, telegramID)
+	if err != nil {
+		return fmt.Errorf("delete unverified participants: %w", err)
+	}
+	return nil
+}
+
+func (r *ParticipantRepository) list(ctx context.Context, op, query string, args ...any) ([]domain.Participant, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var participants []domain.Participant
+	for rows.Next() {
+		p, err := scanParticipant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan participant: %w", err)
+		}
+		participants = append(participants, *p)
+	}
+
+	return participants, nil
+}
+
+const participantColumns = `
+	id, user_id, first_name, last_name, first_name_latin, last_name_latin,
+	region_id, region, district_id, district, school_id, school, grade, birth_date, created_at, updated_at`
+
+func scanParticipant(row rowScanner) (*domain.Participant, error) {
+	var p domain.Participant
+	var firstName, lastName, firstNameLatin, lastNameLatin, region, district, school sql.NullString
+	var regionID, districtID, schoolID sql.NullInt64
+	var grade sql.NullInt32
+	var birthDate sql.NullTime
+
+	err := row.Scan(
+		&p.ID,
+		&p.UserID,
+		&firstName,
+		&lastName,
+		&firstNameLatin,
+		&lastNameLatin,
+		&regionID,
+		&region,
+		&districtID,
+		&district,
+		&schoolID,
+		&school,
+		&grade,
+		&birthDate,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	p.FirstName = firstName.String
+	p.LastName = lastName.String
+	p.FirstNameLatin = firstNameLatin.String
+	p.LastNameLatin = lastNameLatin.String
+	p.RegionID = regionID.Int64
+	p.Region = region.String
+	p.DistrictID = districtID.Int64
+	p.District = district.String
+	p.SchoolID = schoolID.Int64
+	p.School = school.String
+	p.Grade = int(grade.Int32)
+	p.BirthDate = birthDate.Time
+
+	return &p, nil
+}
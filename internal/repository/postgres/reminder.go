@@ -0,0 +1,86 @@
+// internal/repository/postgres/reminder.go
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/storage"
+)
+
+type ReminderRepository struct {
+	db *storage.Storage
+}
+
+func NewReminderRepository(db *storage.Storage) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+func (r *ReminderRepository) Create(ctx context.Context, reminder *domain.Reminder) error {
+	query := `
+		INSERT INTO registration_reminders (user_id, state, attempt, sent_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.Pool.QueryRow(ctx, query, reminder.UserID, reminder.State, reminder.Attempt, now).Scan(&reminder.ID)
+	if err != nil {
+		return fmt.Errorf("create reminder: %w", err)
+	}
+
+	reminder.SentAt = now
+	return nil
+}
+
+// GetCandidates finds unverified users whose last step change is older than
+// stuckBefore. Only reminders sent after that change count as attempts, so
+// a user who moves on and gets stuck again is reminded afresh.
+func (r *ReminderRepository) GetCandidates(ctx context.Context, stuckBefore time.Time, maxAttempts, limit int) ([]domain.ReminderCandidate, error) {
+	query := `
+		SELECT u.id, u.telegram_id, u.state, u.updated_at, COUNT(r.id), MAX(r.sent_at)
+		FROM users u
+		LEFT JOIN registration_reminders r ON r.user_id = u.id AND r.sent_at >= u.updated_at
+		WHERE u.is_verified = FALSE AND u.state LIKE 'wait\_%' AND u.updated_at < $1
+		GROUP BY u.id
+		HAVING COUNT(r.id) < $2
+		ORDER BY u.updated_at
+		LIMIT $3`
+
+	rows, err := r.db.Pool.Query(ctx, query, stuckBefore, maxAttempts, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get reminder candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []domain.ReminderCandidate
+	for rows.Next() {
+		var c domain.ReminderCandidate
+		var lastSentAt sql.NullTime
+		if err := rows.Scan(&c.UserID, &c.TelegramID, &c.State, &c.StuckSince, &c.Attempts, &lastSentAt); err != nil {
+			return nil, fmt.Errorf("scan reminder candidate: %w", err)
+		}
+		c.LastSentAt = lastSentAt.Time
+		candidates = append(candidates, c)
+	}
+
+	return candidates, nil
+}
+
+func (r *ReminderRepository) GetStats(ctx context.Context) (*domain.ReminderStats, error) {
+	query := `
+		SELECT COUNT(*),
+		       COUNT(DISTINCT r.user_id),
+		       COUNT(DISTINCT r.user_id) FILTER (WHERE u.is_verified)
+		FROM registration_reminders r
+		JOIN users u ON u.id = r.user_id`
+
+	var stats domain.ReminderStats
+	err := r.db.Pool.QueryRow(ctx, query).Scan(&stats.Sent, &stats.RemindedUsers, &stats.RecoveredUsers)
+	if err != nil {
+		return nil, fmt.Errorf("get reminder stats: %w", err)
+	}
+	return &stats, nil
+}
@@ -0,0 +1,203 @@
+// internal/repository/postgres/school.go
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/storage"
+)
+
+type SchoolRepository struct {
+	db *storage.Storage
+}
+
+func NewSchoolRepository(db *storage.Storage) *SchoolRepository {
+	return &SchoolRepository{db: db}
+}
+
+func (r *SchoolRepository) Create(ctx context.Context, school *domain.School) error {
+	query := `
+		INSERT INTO schools (district_id, number, name, status, submitted_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.Pool.QueryRow(ctx, query,
+		school.DistrictID,
+		sql.NullInt32{Int32: int32(school.Number), Valid: school.Number != 0},
+		school.Name,
+		school.Status,
+		nullInt64(school.SubmittedBy),
+		now,
+	).Scan(&school.ID)
+
+	if err != nil {
+		return fmt.Errorf("create school: %w", err)
+	}
+
+	school.CreatedAt = now
+	return nil
+}
+
+func (r *SchoolRepository) GetByID(ctx context.Context, id int64) (*domain.School, error) {
+	query := `SELECT ` + schoolColumns + ` FROM schools WHERE id = $1`
+
+	school, err := scanSchool(r.db.Pool.QueryRow(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get school by id: %w", err)
+	}
+
+	return school, nil
+}
+
+func (r *SchoolRepository) GetByDistrict(ctx context.Context, districtID int64) ([]domain.School, error) {
+	query := `
+		SELECT ` + schoolColumns + `
+		FROM schools
+		WHERE district_id = $1 AND status = $2
+		ORDER BY number NULLS LAST, name`
+
+	return r.list(ctx, "get schools by district", query, districtID, domain.SchoolStatusApproved)
+}
+
+// Search matches approved schools of a district by number or by a name fragment
+func (r *SchoolRepository) Search(ctx context.Context, districtID int64, query string, number int, limit int) ([]domain.School, error) {
+	q := `
+		SELECT ` + schoolColumns + `
+		FROM schools
+		WHERE district_id = $1 AND status = $2
+		  AND (($3 > 0 AND number = $3) OR name ILIKE '%' || $4 || '%')
+		ORDER BY (number = $3) IS TRUE DESC, number NULLS LAST, name
+		LIMIT $5`
+
+	return r.list(ctx, "search schools", q, districtID, domain.SchoolStatusApproved, number, query, limit)
+}
+
+func (r *SchoolRepository) GetPending(ctx context.Context) ([]domain.School, error) {
+	query := `
+		SELECT s.id, s.district_id, s.number, s.name, s.status, s.submitted_by, s.created_at,
+		       r.name_uz, d.name_uz
+		FROM schools s
+		JOIN districts d ON d.id = s.district_id
+		JOIN regions r ON r.id = d.region_id
+		WHERE s.status = $1
+		ORDER BY s.created_at`
+
+	rows, err := r.db.Pool.Query(ctx, query, domain.SchoolStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("get pending schools: %w", err)
+	}
+	defer rows.Close()
+
+	var schools []domain.School
+	for rows.Next() {
+		var s domain.School
+		var number sql.NullInt32
+		var submittedBy sql.NullInt64
+
+		if err := rows.Scan(&s.ID, &s.DistrictID, &number, &s.Name, &s.Status, &submittedBy, &s.CreatedAt,
+			&s.Region, &s.District); err != nil {
+			return nil, fmt.Errorf("scan school: %w", err)
+		}
+		s.Number = int(number.Int32)
+		s.SubmittedBy = submittedBy.Int64
+		schools = append(schools, s)
+	}
+
+	return schools, nil
+}
+
+func (r *SchoolRepository) CountPending(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM schools WHERE status = $1`, domain.SchoolStatusPending).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count pending schools: %w", err)
+	}
+	return count, nil
+}
+
+func (r *SchoolRepository) UpdateStatus(ctx context.Context, id int64, status string) error {
+	query := `UPDATE schools SET status = $2 WHERE id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id, status)
+	if err != nil {
+		return fmt.Errorf("update school status: %w", err)
+	}
+	return nil
+}
+
+func (r *SchoolRepository) GetStats(ctx context.Context) ([]domain.SchoolStat, error) {
+	query := `
+		SELECT s.id, s.name, d.name_uz, r.name_uz, COUNT(*)
+		FROM schools s
+		JOIN districts d ON d.id = s.district_id
+		JOIN regions r ON r.id = d.region_id
+		JOIN (
+			SELECT school_id FROM users
+			WHERE is_verified = TRUE AND primary_user_id IS NULL AND role IS DISTINCT FROM 'parent'
+			UNION ALL
+			SELECT p.school_id FROM participants p
+			JOIN users u ON u.id = p.user_id AND u.is_verified = TRUE
+		) x ON x.school_id = s.id
+		GROUP BY s.id, s.name, d.name_uz, r.name_uz, r.sort_order
+		ORDER BY r.sort_order, d.name_uz, s.number NULLS LAST, s.name`
+
+	rows, err := r.db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get school stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []domain.SchoolStat
+	for rows.Next() {
+		var st domain.SchoolStat
+		if err := rows.Scan(&st.SchoolID, &st.School, &st.District, &st.Region, &st.Users); err != nil {
+			return nil, fmt.Errorf("scan school stat: %w", err)
+		}
+		stats = append(stats, st)
+	}
+
+	return stats, nil
+}
+
+func (r *SchoolRepository) list(ctx context.Context, op, query string, args ...any) ([]domain.School, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var schools []domain.School
+	for rows.Next() {
+		school, err := scanSchool(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan school: %w", err)
+		}
+		schools = append(schools, *school)
+	}
+
+	return schools, nil
+}
+
+const schoolColumns = `id, district_id, number, name, status, submitted_by, created_at`
+
+func scanSchool(row rowScanner) (*domain.School, error) {
+	var s domain.School
+	var number sql.NullInt32
+	var submittedBy sql.NullInt64
+
+	if err := row.Scan(&s.ID, &s.DistrictID, &number, &s.Name, &s.Status, &submittedBy, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	s.Number = int(number.Int32)
+	s.SubmittedBy = submittedBy.Int64
+	return &s, nil
+}
@@ -0,0 +1,181 @@
+// internal/repository/postgres/sms_outbox.go
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/storage"
+)
+
+type SMSOutboxRepository struct {
+	db *storage.Storage
+}
+
+func NewSMSOutboxRepository(db *storage.Storage) *SMSOutboxRepository {
+	return &SMSOutboxRepository{db: db}
+}
+
+func (r *SMSOutboxRepository) Enqueue(ctx context.Context, otp *domain.OTPCode, msg *domain.SMSOutboxMessage,
+	quota domain.OTPQuota) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("enqueue sms: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := createOTP(ctx, tx, otp, quota); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO sms_outbox (message_id, otp_id, telegram_id, phone, language_code, sealed_code, status,
+		                        next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		RETURNING id`
+
+	msg.OTPID = otp.ID
+	msg.Status = domain.SMSOutboxPending
+	msg.CreatedAt = otp.CreatedAt
+	msg.NextAttemptAt = otp.CreatedAt
+	err = tx.QueryRow(ctx, query,
+		msg.MessageID,
+		msg.OTPID,
+		msg.TelegramID,
+		msg.Phone,
+		msg.LanguageCode,
+		msg.SealedCode,
+		msg.Status,
+		msg.CreatedAt,
+	).Scan(&msg.ID)
+	if err != nil {
+		return fmt.Errorf("enqueue sms: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("enqueue sms: %w", err)
+	}
+	return nil
+}
+
+func (r *SMSOutboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]domain.SMSOutboxMessage, error) {
+	// SKIP LOCKED: bir nechta replika bir xabarni ikki marta olmaydi
+	query := `
+		WITH due AS (
+			SELECT id FROM sms_outbox
+			WHERE status = $1 AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE sms_outbox s
+		SET attempts = s.attempts + 1, next_attempt_at = NOW() + $3 * INTERVAL '1 millisecond'
+		FROM due, otp_codes o
+		WHERE s.id = due.id AND o.id = s.otp_id
+		RETURNING s.id, s.message_id, s.otp_id, s.telegram_id, s.phone, s.language_code, s.sealed_code, s.status, s.attempts,
+		          s.next_attempt_at, COALESCE(s.last_error, ''), s.created_at, o.status, o.expires_at`
+
+	rows, err := r.db.Pool.Query(ctx, query, domain.SMSOutboxPending, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim due sms: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []domain.SMSOutboxMessage
+	for rows.Next() {
+		var m domain.SMSOutboxMessage
+		err := rows.Scan(
+			&m.ID,
+			&m.MessageID,
+			&m.OTPID,
+			&m.TelegramID,
+			&m.Phone,
+			&m.LanguageCode,
+			&m.SealedCode,
+			&m.Status,
+			&m.Attempts,
+			&m.NextAttemptAt,
+			&m.LastError,
+			&m.CreatedAt,
+			&m.OTPStatus,
+			&m.OTPExpiresAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan sms outbox message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+
+	return messages, rows.Err()
+}
+
+func (r *SMSOutboxRepository) MarkSent(ctx context.Context, id int64, providerMessageID, deliveryStatus string) error {
+	query := `
+		WITH sent AS (
+			UPDATE sms_outbox
+			SET status = $2, provider_message_id = $3, sealed_code = NULL, finished_at = NOW()
+			WHERE id = $1
+			RETURNING otp_id
+		)
+		UPDATE otp_codes SET message_id = $3, delivery_status = $4
+		WHERE id = (SELECT otp_id FROM sent)`
+
+	_, err := r.db.Pool.Exec(ctx, query, id, domain.SMSOutboxSent, providerMessageID, deliveryStatus)
+	if err != nil {
+		return fmt.Errorf("mark sms as sent: %w", err)
+	}
+	return nil
+}
+
+func (r *SMSOutboxRepository) Retry(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	query := `UPDATE sms_outbox SET next_attempt_at = $2, last_error = $3 WHERE id = $1`
+	if _, err := r.db.Pool.Exec(ctx, query, id, nextAttemptAt, lastError); err != nil {
+		return fmt.Errorf("schedule sms retry: %w", err)
+	}
+	return nil
+}
+
+func (r *SMSOutboxRepository) MarkDead(ctx context.Context, id int64, lastError string) error {
+	query := `
+		WITH dead AS (
+			UPDATE sms_outbox
+			SET status = $2, last_error = $3, sealed_code = NULL, finished_at = NOW()
+			WHERE id = $1
+			RETURNING otp_id
+		)
+		UPDATE otp_codes SET delivery_status = $4, delivery_checked_at = NOW()
+		WHERE id = (SELECT otp_id FROM dead)`
+
+	_, err := r.db.Pool.Exec(ctx, query, id, domain.SMSOutboxDead, lastError, domain.DeliveryStatusFailed)
+	if err != nil {
+		return fmt.Errorf("mark sms as dead: %w", err)
+	}
+	return nil
+}
+
+func (r *SMSOutboxRepository) Cancel(ctx context.Context, id int64) error {
+	query := `UPDATE sms_outbox SET status = $2, sealed_code = NULL, finished_at = NOW() WHERE id = $1`
+	if _, err := r.db.Pool.Exec(ctx, query, id, domain.SMSOutboxCancelled); err != nil {
+		return fmt.Errorf("cancel sms: %w", err)
+	}
+	return nil
+}
+
+func (r *SMSOutboxRepository) GetStats(ctx context.Context, since time.Time) (domain.SMSOutboxStats, error) {
+	query := `
+		SELECT COUNT(*) FILTER (WHERE status = $2),
+		       COUNT(*) FILTER (WHERE status = $3),
+		       COUNT(*) FILTER (WHERE status = $4)
+		FROM sms_outbox
+		WHERE created_at >= $1`
+
+	var stats domain.SMSOutboxStats
+	err := r.db.Pool.QueryRow(ctx, query, since, domain.SMSOutboxPending, domain.SMSOutboxSent, domain.SMSOutboxDead).
+		Scan(&stats.Pending, &stats.Sent, &stats.Dead)
+	if err != nil {
+		return domain.SMSOutboxStats{}, fmt.Errorf("get sms outbox stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,64 @@
+// internal/repository/postgres/sms_template.go
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/storage"
+)
+
+type SMSTemplateRepository struct {
+	db *storage.Storage
+}
+
+func NewSMSTemplateRepository(db *storage.Storage) *SMSTemplateRepository {
+	return &SMSTemplateRepository{db: db}
+}
+
+func (r *SMSTemplateRepository) Get(ctx context.Context, langCode string) (*domain.SMSTemplate, error) {
+	query := `SELECT language_code, template, updated_by, updated_at FROM sms_templates WHERE language_code = $1`
+
+	var t domain.SMSTemplate
+	var updatedBy sql.NullInt64
+	err := r.db.Pool.QueryRow(ctx, query, langCode).Scan(&t.LanguageCode, &t.Template, &updatedBy, &t.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get sms template: %w", err)
+	}
+
+	t.UpdatedBy = updatedBy.Int64
+	return &t, nil
+}
+
+func (r *SMSTemplateRepository) Upsert(ctx context.Context, t *domain.SMSTemplate) error {
+	query := `
+		INSERT INTO sms_templates (language_code, template, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (language_code) DO UPDATE
+		SET template = EXCLUDED.template, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`
+
+	now := time.Now()
+	_, err := r.db.Pool.Exec(ctx, query, t.LanguageCode, t.Template, nullInt64(t.UpdatedBy), now)
+	if err != nil {
+		return fmt.Errorf("upsert sms template: %w", err)
+	}
+
+	t.UpdatedAt = now
+	return nil
+}
+
+func (r *SMSTemplateRepository) Delete(ctx context.Context, langCode string) error {
+	query := `DELETE FROM sms_templates WHERE language_code = $1`
+	_, err := r.db.Pool.Exec(ctx, query, langCode)
+	if err != nil {
+		return fmt.Errorf("delete sms template: %w", err)
+	}
+	return nil
+}
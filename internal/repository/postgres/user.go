@@ -22,16 +22,21 @@ func NewUserRepository(db *storage.Storage) *UserRepository {
 
 func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	query := `
-		INSERT INTO users (telegram_id, username, language_code, state, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $5)
-		RETURNING id`
+		WITH ins AS (
+			INSERT INTO users (telegram_id, username, language_code, state, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $5)
+			RETURNING id
+		)
+		INSERT INTO user_state_events (user_id, to_state, created_at)
+		SELECT id, $4, $5 FROM ins
+		RETURNING user_id`
 
 	now := time.Now()
 	err := r.db.Pool.QueryRow(ctx, query,
 		user.TelegramID,
 		user.Username,
 		user.LanguageCode,
-		domain.StateWaitFullName,
+		domain.StateWaitRole,
 		now,
 	).Scan(&user.ID)
 
@@ -45,34 +50,9 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 }
 
 func (r *UserRepository) GetByTelegramID(ctx context.Context, telegramID int64) (*domain.User, error) {
-	query := `
-		SELECT id, telegram_id, username, language_code, first_name, last_name,
-		       region, district, school, grade, phone, is_verified, state, created_at, updated_at
-		FROM users
-		WHERE telegram_id = $1`
-
-	var user domain.User
-	var firstName, lastName, region, district, school, phone sql.NullString
-	var grade sql.NullInt32
-
-	err := r.db.Pool.QueryRow(ctx, query, telegramID).Scan(
-		&user.ID,
-		&user.TelegramID,
-		&user.Username,
-		&user.LanguageCode,
-		&firstName,
-		&lastName,
-		&region,
-		&district,
-		&school,
-		&grade,
-		&phone,
-		&user.IsVerified,
-		&user.State,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	query := `SELECT ` + userColumns + ` FROM users WHERE telegram_id = $1`
 
+	user, err := scanUser(r.db.Pool.QueryRow(ctx, query, telegramID))
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -80,24 +60,19 @@ func (r *UserRepository) GetByTelegramID(ctx context.Context, telegramID int64)
 		return nil, fmt.Errorf("get user by telegram id: %w", err)
 	}
 
-	user.FirstName = firstName.String
-	user.LastName = lastName.String
-	user.Region = region.String
-	user.District = district.String
-	user.School = school.String
-	user.Phone = phone.String
-	user.Grade = int(grade.Int32)
-
-	return &user, nil
+	return user, nil
 }
 
 func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
-	query := `
+	query := withStateEvent(`
 		UPDATE users
 		SET username = $2, language_code = $3, first_name = $4, last_name = $5,
-		    region = $6, district = $7, school = $8, grade = $9, phone = $10,
-		    is_verified = $11, state = $12, updated_at = $13
-		WHERE telegram_id = $1`
+		    region_id = $6, region = $7, district_id = $8, district = $9,
+		    school_id = $10, school = $11, grade = $12, phone = $13,
+		    is_verified = $14, state = $15, updated_at = $16,
+		    role = $17, subject = $18, active_participant_id = $19, birth_date = $20,
+		    verification_method = $21
+		WHERE telegram_id = $1`, 15, 16)
 
 	_, err := r.db.Pool.Exec(ctx, query,
 		user.TelegramID,
@@ -105,14 +80,22 @@ func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 		user.LanguageCode,
 		user.FirstName,
 		user.LastName,
+		nullInt64(user.RegionID),
 		user.Region,
+		nullInt64(user.DistrictID),
 		user.District,
+		nullInt64(user.SchoolID),
 		user.School,
 		user.Grade,
 		user.Phone,
 		user.IsVerified,
 		user.State,
 		time.Now(),
+		nullString(user.Role),
+		nullString(user.Subject),
+		nullInt64(user.ActiveParticipantID),
+		nullDate(user.BirthDate),
+		nullString(user.VerificationMethod),
 	)
 
 	if err != nil {
@@ -122,7 +105,7 @@ func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 }
 
 func (r *UserRepository) UpdateState(ctx context.Context, telegramID int64, state string) error {
-	query := `UPDATE users SET state = $2, updated_at = $3 WHERE telegram_id = $1`
+	query := withStateEvent(`UPDATE users SET state = $2, updated_at = $3 WHERE telegram_id = $1`, 2, 3)
 	_, err := r.db.Pool.Exec(ctx, query, telegramID, state, time.Now())
 	if err != nil {
 		return fmt.Errorf("update user state: %w", err)
@@ -130,18 +113,29 @@ func (r *UserRepository) UpdateState(ctx context.Context, telegramID int64, stat
 	return nil
 }
 
-func (r *UserRepository) UpdateFullName(ctx context.Context, telegramID int64, firstName, lastName string) error {
-	query := `UPDATE users SET first_name = $2, last_name = $3, updated_at = $4 WHERE telegram_id = $1`
-	_, err := r.db.Pool.Exec(ctx, query, telegramID, firstName, lastName, time.Now())
+func (r *UserRepository) UpdateFullName(ctx context.Context, telegramID int64, name domain.FullName) error {
+	query := `
+		UPDATE users
+		SET first_name = $2, last_name = $3, first_name_latin = $4, last_name_latin = $5, updated_at = $6
+		WHERE telegram_id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, telegramID, name.First, name.Last, name.FirstLatin, name.LastLatin, time.Now())
 	if err != nil {
 		return fmt.Errorf("update full name: %w", err)
 	}
 	return nil
 }
 
-func (r *UserRepository) UpdateLocation(ctx context.Context, telegramID int64, region, district, school string) error {
-	query := `UPDATE users SET region = $2, district = $3, school = $4, updated_at = $5 WHERE telegram_id = $1`
-	_, err := r.db.Pool.Exec(ctx, query, telegramID, region, district, school, time.Now())
+func (r *UserRepository) UpdateLocation(ctx context.Context, telegramID int64, loc domain.Location) error {
+	query := `
+		UPDATE users
+		SET region_id = $2, region = $3, district_id = $4, district = $5,
+		    school_id = $6, school = $7, updated_at = $8
+		WHERE telegram_id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, telegramID,
+		nullInt64(loc.RegionID), loc.Region,
+		nullInt64(loc.DistrictID), loc.District,
+		nullInt64(loc.SchoolID), loc.School,
+		time.Now())
 	if err != nil {
 		return fmt.Errorf("update location: %w", err)
 	}
@@ -157,6 +151,42 @@ func (r *UserRepository) UpdateGrade(ctx context.Context, telegramID int64, grad
 	return nil
 }
 
+func (r *UserRepository) UpdateRole(ctx context.Context, telegramID int64, role string) error {
+	query := `UPDATE users SET role = $2, updated_at = $3 WHERE telegram_id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, telegramID, role, time.Now())
+	if err != nil {
+		return fmt.Errorf("update role: %w", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) UpdateSubject(ctx context.Context, telegramID int64, subject string) error {
+	query := `UPDATE users SET subject = $2, updated_at = $3 WHERE telegram_id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, telegramID, subject, time.Now())
+	if err != nil {
+		return fmt.Errorf("update subject: %w", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) UpdateBirthDate(ctx context.Context, telegramID int64, birthDate time.Time) error {
+	query := `UPDATE users SET birth_date = $2, updated_at = $3 WHERE telegram_id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, telegramID, nullDate(birthDate), time.Now())
+	if err != nil {
+		return fmt.Errorf("update birth date: %w", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) UpdateActiveParticipant(ctx context.Context, telegramID int64, participantID int64) error {
+	query := `UPDATE users SET active_participant_id = $2, updated_at = $3 WHERE telegram_id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, telegramID, nullInt64(participantID), time.Now())
+	if err != nil {
+		return fmt.Errorf("update active participant: %w", err)
+	}
+	return nil
+}
+
 func (r *UserRepository) UpdatePhone(ctx context.Context, telegramID int64, phone string) error {
 	query := `UPDATE users SET phone = $2, updated_at = $3 WHERE telegram_id = $1`
 	_, err := r.db.Pool.Exec(ctx, query, telegramID, phone, time.Now())
@@ -166,13 +196,48 @@ func (r *UserRepository) UpdatePhone(ctx context.Context, telegramID int64, phon
 	return nil
 }
 
-func (r *UserRepository) GetAllVerified(ctx context.Context) ([]domain.User, error) {
+func (r *UserRepository) UpdatePendingPhone(ctx context.Context, telegramID int64, phone string) error {
+	query := `UPDATE users SET pending_phone = $2, updated_at = $3 WHERE telegram_id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, telegramID, sql.NullString{String: phone, Valid: phone != ""}, time.Now())
+	if err != nil {
+		return fmt.Errorf("update pending phone: %w", err)
+	}
+	return nil
+}
+
+// ConfirmPendingPhone swaps the confirmed pending number in as the main one
+func (r *UserRepository) ConfirmPendingPhone(ctx context.Context, telegramID int64, method string) error {
 	query := `
-		SELECT id, telegram_id, username, language_code, first_name, last_name,
-		       region, district, school, grade, phone, is_verified, state, created_at, updated_at
-		FROM users
-		WHERE is_verified = TRUE
-		ORDER BY created_at DESC`
+		UPDATE users
+		SET phone = pending_phone, pending_phone = NULL, verification_method = $2, updated_at = $3
+		WHERE telegram_id = $1 AND pending_phone IS NOT NULL`
+	_, err := r.db.Pool.Exec(ctx, query, telegramID, method, time.Now())
+	if err != nil {
+		return fmt.Errorf("confirm pending phone: %w", err)
+	}
+	return nil
+}
+
+// ResetRegistration wipes partially entered registration data and restarts from the first step
+func (r *UserRepository) ResetRegistration(ctx context.Context, telegramID int64) error {
+	query := withStateEvent(`
+		UPDATE users
+		SET role = NULL, first_name = NULL, last_name = NULL, first_name_latin = NULL, last_name_latin = NULL,
+		    subject = NULL, active_participant_id = NULL,
+		    region_id = NULL, region = NULL, district_id = NULL, district = NULL,
+		    school_id = NULL, school = NULL, grade = NULL, birth_date = NULL,
+		    phone = NULL, pending_phone = NULL, verification_method = NULL,
+		    state = $2, updated_at = $3
+		WHERE telegram_id = $1 AND is_verified = FALSE`, 2, 3)
+	_, err := r.db.Pool.Exec(ctx, query, telegramID, domain.StateWaitRole, time.Now())
+	if err != nil {
+		return fmt.Errorf("reset registration: %w", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) GetAllVerified(ctx context.Context) ([]domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE is_verified = TRUE AND primary_user_id IS NULL ORDER BY created_at DESC`
 
 	rows, err := r.db.Pool.Query(ctx, query)
 	if err != nil {
@@ -182,27 +247,61 @@ func (r *UserRepository) GetAllVerified(ctx context.Context) ([]domain.User, err
 
 	var users []domain.User
 	for rows.Next() {
-		var user domain.User
-		var firstName, lastName, region, district, school, phone sql.NullString
-		var grade sql.NullInt32
-
-		if err := rows.Scan(
-			&user.ID, &user.TelegramID, &user.Username, &user.LanguageCode,
-			&firstName, &lastName, &region, &district, &school, &grade, &phone,
-			&user.IsVerified, &user.State, &user.CreatedAt, &user.UpdatedAt,
-		); err != nil {
+		user, err := scanUser(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan user: %w", err)
 		}
+		users = append(users, *user)
+	}
 
-		user.FirstName = firstName.String
-		user.LastName = lastName.String
-		user.Region = region.String
-		user.District = district.String
-		user.School = school.String
-		user.Phone = phone.String
-		user.Grade = int(grade.Int32)
+	return users, nil
+}
 
-		users = append(users, user)
+func (r *UserRepository) GetVerifiedByRole(ctx context.Context, role string) ([]domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users
+		WHERE is_verified = TRUE AND primary_user_id IS NULL AND role = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.Pool.Query(ctx, query, role)
+	if err != nil {
+		return nil, fmt.Errorf("get verified users by role: %w", err)
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+
+	return users, nil
+}
+
+// SearchByName matches the Latin spelling in either "first last" or "last first" order
+func (r *UserRepository) SearchByName(ctx context.Context, latinQuery string, limit int) ([]domain.User, error) {
+	query := `
+		SELECT ` + userColumns + ` FROM users
+		WHERE is_verified = TRUE
+		  AND (first_name_latin || ' ' || last_name_latin ILIKE '%' || $1 || '%'
+		       OR last_name_latin || ' ' || first_name_latin ILIKE '%' || $1 || '%')
+		ORDER BY last_name_latin, first_name_latin
+		LIMIT $2`
+
+	rows, err := r.db.Pool.Query(ctx, query, latinQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("search users by name: %w", err)
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		users = append(users, *user)
 	}
 
 	return users, nil
@@ -218,7 +317,7 @@ func (r *UserRepository) GetStats(ctx context.Context) (*domain.Stats, error) {
 	}
 
 	// Verified users
-	err = r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE is_verified = TRUE`).Scan(&stats.VerifiedUsers)
+	err = r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE is_verified = TRUE AND primary_user_id IS NULL`).Scan(&stats.VerifiedUsers)
 	if err != nil {
 		return nil, fmt.Errorf("get verified users: %w", err)
 	}
@@ -235,5 +334,280 @@ func (r *UserRepository) GetStats(ctx context.Context) (*domain.Stats, error) {
 		return nil, fmt.Errorf("get total channels: %w", err)
 	}
 
+	// Verified participants by region: students and teachers themselves,
+	// parents through each of their children
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT COALESCE(r.id, 0), COALESCE(r.name_uz, ''), COUNT(*)
+		FROM (
+			SELECT region_id FROM users
+			WHERE is_verified = TRUE AND primary_user_id IS NULL AND role IS DISTINCT FROM 'parent'
+			UNION ALL
+			SELECT p.region_id FROM participants p
+			JOIN users u ON u.id = p.user_id AND u.is_verified = TRUE
+		) x
+		LEFT JOIN regions r ON r.id = x.region_id
+		GROUP BY r.id, r.name_uz, r.sort_order
+		ORDER BY r.sort_order NULLS LAST`)
+	if err != nil {
+		return nil, fmt.Errorf("get region stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rs domain.RegionStat
+		if err := rows.Scan(&rs.RegionID, &rs.Region, &rs.Users); err != nil {
+			return nil, fmt.Errorf("scan region stat: %w", err)
+		}
+		stats.ByRegion = append(stats.ByRegion, rs)
+	}
+
 	return &stats, nil
 }
+
+// GetFunnel counts the users who entered each state between from and to and
+// how long they stayed there. Edits made from the profile after the first
+// registration are left out, so the funnel only describes sign-ups.
+func (r *UserRepository) GetFunnel(ctx context.Context, from, to time.Time, role string) ([]domain.FunnelStep, error) {
+	query := `
+		WITH ev AS (
+			SELECT e.user_id, e.to_state, e.created_at,
+			       LEAD(e.created_at) OVER w AS left_at,
+			       COALESCE(BOOL_OR(e.to_state = $4) OVER (w ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING), FALSE) AS after_signup
+			FROM user_state_events e
+			JOIN users u ON u.id = e.user_id
+			WHERE $3 = '' OR u.role = $3
+			WINDOW w AS (PARTITION BY e.user_id ORDER BY e.created_at, e.id)
+		)
+		SELECT to_state, COUNT(DISTINCT user_id),
+		       COALESCE(EXTRACT(EPOCH FROM PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY left_at - created_at)), 0)
+		FROM ev
+		WHERE NOT after_signup AND created_at >= $1 AND created_at < $2
+		GROUP BY to_state`
+
+	rows, err := r.db.Pool.Query(ctx, query, from, to, role, domain.StateRegistered)
+	if err != nil {
+		return nil, fmt.Errorf("get funnel: %w", err)
+	}
+	defer rows.Close()
+
+	var steps []domain.FunnelStep
+	for rows.Next() {
+		var step domain.FunnelStep
+		var seconds float64
+		if err := rows.Scan(&step.State, &step.Users, &seconds); err != nil {
+			return nil, fmt.Errorf("scan funnel step: %w", err)
+		}
+		step.MedianTime = time.Duration(seconds * float64(time.Second))
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// GetPhoneOwner returns the verified account, other than exceptUserID, that the
+// phone belongs to. Aliases are skipped: the primary account is the owner.
+// Accounts that did not prove the number (chat_otp) never own it.
+func (r *UserRepository) GetPhoneOwner(ctx context.Context, phone string, exceptUserID int64) (*domain.User, error) {
+	query := `
+		SELECT ` + userColumns + ` FROM users
+		WHERE phone = $1 AND id <> $2 AND is_verified = TRUE AND primary_user_id IS NULL
+		  AND verification_method IS DISTINCT FROM $3
+		ORDER BY created_at
+		LIMIT 1`
+
+	user, err := scanUser(r.db.Pool.QueryRow(ctx, query, phone, exceptUserID, domain.VerificationMethodChatOTP))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get phone owner: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetSharedPhones lists proven phones used by more than one account that
+// are not linked to each other yet
+func (r *UserRepository) GetSharedPhones(ctx context.Context) ([]domain.PhoneGroup, error) {
+	query := `
+		SELECT ` + userColumns + ` FROM users
+		WHERE is_verified = TRUE AND primary_user_id IS NULL AND verification_method IS DISTINCT FROM $1
+		  AND phone IN (
+			SELECT phone FROM users
+			WHERE is_verified = TRUE AND primary_user_id IS NULL AND phone IS NOT NULL
+			  AND verification_method IS DISTINCT FROM $1
+			GROUP BY phone
+			HAVING COUNT(*) > 1
+		)
+		ORDER BY phone, created_at`
+
+	rows, err := r.db.Pool.Query(ctx, query, domain.VerificationMethodChatOTP)
+	if err != nil {
+		return nil, fmt.Errorf("get shared phones: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []domain.PhoneGroup
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		if len(groups) == 0 || groups[len(groups)-1].Phone != user.Phone {
+			groups = append(groups, domain.PhoneGroup{Phone: user.Phone})
+		}
+		groups[len(groups)-1].Users = append(groups[len(groups)-1].Users, *user)
+	}
+
+	return groups, nil
+}
+
+// ReleasePhone takes a verified number away from an account that lost it to
+// another one. The account has to confirm a new number to be verified again.
+func (r *UserRepository) ReleasePhone(ctx context.Context, telegramID int64) error {
+	query := withStateEvent(`
+		UPDATE users
+		SET phone = NULL, pending_phone = NULL, is_verified = FALSE, verification_method = NULL,
+		    state = $2, updated_at = $3
+		WHERE telegram_id = $1`, 2, 3)
+
+	if _, err := r.db.Pool.Exec(ctx, query, telegramID, domain.StateWaitPhone, time.Now()); err != nil {
+		return fmt.Errorf("release phone: %w", err)
+	}
+	return nil
+}
+
+// LinkAlias makes aliasID an alias of primaryID. The alias's children and its
+// own aliases move over to the primary account.
+func (r *UserRepository) LinkAlias(ctx context.Context, aliasID, primaryID int64) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("link alias: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	if _, err := tx.Exec(ctx,
+		`UPDATE participants SET user_id = $2, updated_at = $3 WHERE user_id = $1`,
+		aliasID, primaryID, now); err != nil {
+		return fmt.Errorf("move participants: %w", err)
+	}
+
+	// Faol farzand endi asosiy akkauntga tegishli, shuning uchun uni tozalaymiz
+	if _, err := tx.Exec(ctx, `
+		UPDATE users SET primary_user_id = $2, active_participant_id = NULL, updated_at = $3
+		WHERE id = $1 OR primary_user_id = $1`,
+		aliasID, primaryID, now); err != nil {
+		return fmt.Errorf("link alias: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("link alias: %w", err)
+	}
+	return nil
+}
+
+// withStateEvent wraps an UPDATE of one user (WHERE telegram_id = $1) so that
+// a change of state is also written to user_state_events in the same statement.
+// stateArg and timeArg are the placeholders holding the new state and the time.
+func withStateEvent(update string, stateArg, timeArg int) string {
+	return fmt.Sprintf(`
+		WITH old AS (
+			SELECT id, state FROM users WHERE telegram_id = $1
+		), upd AS (
+			%s
+			RETURNING id
+		)
+		INSERT INTO user_state_events (user_id, from_state, to_state, created_at)
+		SELECT old.id, old.state, $%[2]d, $%[3]d
+		FROM old JOIN upd ON upd.id = old.id
+		WHERE old.state IS DISTINCT FROM $%[2]d`, update, stateArg, timeArg)
+}
+
+const userColumns = `
+	id, telegram_id, username, language_code, role, first_name, last_name, first_name_latin, last_name_latin,
+	region_id, region, district_id, district, school_id, school, grade, birth_date, subject, active_participant_id,
+	primary_user_id, phone, pending_phone,
+	is_verified, verification_method, state, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*domain.User, error) {
+	var user domain.User
+	var role, firstName, lastName, firstNameLatin, lastNameLatin, region, district, school sql.NullString
+	var subject, phone, pendingPhone, verificationMethod sql.NullString
+	var regionID, districtID, schoolID, activeParticipantID, primaryUserID sql.NullInt64
+	var grade sql.NullInt32
+	var birthDate sql.NullTime
+
+	err := row.Scan(
+		&user.ID,
+		&user.TelegramID,
+		&user.Username,
+		&user.LanguageCode,
+		&role,
+		&firstName,
+		&lastName,
+		&firstNameLatin,
+		&lastNameLatin,
+		&regionID,
+		&region,
+		&districtID,
+		&district,
+		&schoolID,
+		&school,
+		&grade,
+		&birthDate,
+		&subject,
+		&activeParticipantID,
+		&primaryUserID,
+		&phone,
+		&pendingPhone,
+		&user.IsVerified,
+		&verificationMethod,
+		&user.State,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Role = role.String
+	user.FirstName = firstName.String
+	user.LastName = lastName.String
+	user.FirstNameLatin = firstNameLatin.String
+	user.LastNameLatin = lastNameLatin.String
+	user.RegionID = regionID.Int64
+	user.Region = region.String
+	user.DistrictID = districtID.Int64
+	user.District = district.String
+	user.SchoolID = schoolID.Int64
+	user.School = school.String
+	user.Phone = phone.String
+	user.PendingPhone = pendingPhone.String
+	user.VerificationMethod = verificationMethod.String
+	user.Grade = int(grade.Int32)
+	user.BirthDate = birthDate.Time
+	user.Subject = subject.String
+	user.ActiveParticipantID = activeParticipantID.Int64
+	user.PrimaryUserID = primaryUserID.Int64
+
+	return &user, nil
+}
+
+func nullString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+// nullInt64 maps zero IDs to NULL so foreign keys stay valid
+func nullInt64(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
+
+// nullDate maps a zero time to NULL for optional DATE columns
+func nullDate(v time.Time) sql.NullTime {
+	return sql.NullTime{Time: v, Valid: !v.IsZero()}
+}
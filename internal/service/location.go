@@ -0,0 +1,37 @@
+// internal/service/location.go
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"khisobot/internal/domain"
+)
+
+type LocationService struct {
+	locationRepo domain.LocationRepository
+	logger       *slog.Logger
+}
+
+func NewLocationService(locationRepo domain.LocationRepository, logger *slog.Logger) *LocationService {
+	return &LocationService{
+		locationRepo: locationRepo,
+		logger:       logger,
+	}
+}
+
+func (s *LocationService) GetRegions(ctx context.Context) ([]domain.Region, error) {
+	return s.locationRepo.GetRegions(ctx)
+}
+
+func (s *LocationService) GetRegion(ctx context.Context, id int64) (*domain.Region, error) {
+	return s.locationRepo.GetRegionByID(ctx, id)
+}
+
+func (s *LocationService) GetDistricts(ctx context.Context, regionID int64) ([]domain.District, error) {
+	return s.locationRepo.GetDistrictsByRegion(ctx, regionID)
+}
+
+func (s *LocationService) GetDistrict(ctx context.Context, id int64) (*domain.District, error) {
+	return s.locationRepo.GetDistrictByID(ctx, id)
+}
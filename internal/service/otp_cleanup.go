@@ -0,0 +1,74 @@
+// internal/service/otp_cleanup.go
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"khisobot/config"
+	"khisobot/internal/domain"
+)
+
+// otpCleanupBatchSize keeps each DELETE short so it does not hold locks that
+// block codes being sent or verified meanwhile
+const otpCleanupBatchSize = 1000
+
+// OTPCleanupService periodically deletes finished OTP codes past their retention
+type OTPCleanupService struct {
+	otpRepo domain.OTPRepository
+	cfg     *config.Config
+	logger  *slog.Logger
+}
+
+func NewOTPCleanupService(otpRepo domain.OTPRepository, cfg *config.Config, logger *slog.Logger) *OTPCleanupService {
+	return &OTPCleanupService{
+		otpRepo: otpRepo,
+		cfg:     cfg,
+		logger:  logger,
+	}
+}
+
+// Run cleans up once at start and then every OTP_CLEANUP_INTERVAL_MINS until ctx is cancelled
+func (s *OTPCleanupService) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(s.cfg.OTPCleanupIntervalMins) * time.Minute)
+	defer ticker.Stop()
+
+	s.logger.Info("🧹 OTP cleanup started",
+		slog.Int("retention_days", s.cfg.OTPRetentionDays),
+		slog.Int("interval_mins", s.cfg.OTPCleanupIntervalMins))
+
+	for {
+		s.cleanup(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *OTPCleanupService) cleanup(ctx context.Context) {
+	before := time.Now().AddDate(0, 0, -s.cfg.OTPRetentionDays)
+	started := time.Now()
+
+	var total int64
+	for {
+		deleted, err := s.otpRepo.DeleteFinished(ctx, before, otpCleanupBatchSize)
+		total += deleted
+		if err != nil {
+			s.logger.Error("❌ Failed to clean up OTP codes", slog.Int64("deleted", total), slog.Any("error", err))
+			return
+		}
+		// Oxirgi to'liq bo'lmagan partiya - o'chiradigan narsa qolmadi
+		if deleted < otpCleanupBatchSize || ctx.Err() != nil {
+			break
+		}
+	}
+
+	s.logger.Info("🧹 OTP codes cleaned up",
+		slog.Int64("deleted", total),
+		slog.Time("older_than", before),
+		slog.Duration("took", time.Since(started)))
+}
@@ -0,0 +1,31 @@
+// internal/service/otp_log.go
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"khisobot/internal/domain"
+)
+
+// LogDeliverer writes OTP messages to the log instead of sending them, so the
+// bot can be tried locally without SMS credentials. Never use it in production.
+type LogDeliverer struct {
+	logger *slog.Logger
+}
+
+func NewLogDeliverer(logger *slog.Logger) *LogDeliverer {
+	return &LogDeliverer{logger: logger}
+}
+
+func (d *LogDeliverer) Channel() string {
+	return domain.OTPChannelLog
+}
+
+func (d *LogDeliverer) Deliver(_ context.Context, msg domain.OTPMessage) (string, error) {
+	d.logger.Warn("🧪 OTP message (log channel)",
+		slog.Int64("telegram_id", msg.TelegramID),
+		slog.String("phone", msg.Phone),
+		slog.String("text", msg.Text))
+	return "", nil
+}
@@ -0,0 +1,106 @@
+// internal/service/reminder.go
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"khisobot/config"
+	"khisobot/internal/domain"
+)
+
+// reminderBatchSize caps how many users are nudged per check so a large
+// backlog does not hit Telegram's rate limits all at once
+const reminderBatchSize = 50
+
+// ReminderService periodically nudges users who abandoned registration
+type ReminderService struct {
+	reminderRepo domain.ReminderRepository
+	sender       domain.ReminderSender
+	cfg          *config.Config
+	logger       *slog.Logger
+}
+
+func NewReminderService(
+	reminderRepo domain.ReminderRepository,
+	sender domain.ReminderSender,
+	cfg *config.Config,
+	logger *slog.Logger,
+) *ReminderService {
+	return &ReminderService{
+		reminderRepo: reminderRepo,
+		sender:       sender,
+		cfg:          cfg,
+		logger:       logger,
+	}
+}
+
+// Run checks for stuck users every REMINDER_CHECK_MINS until ctx is cancelled
+func (s *ReminderService) Run(ctx context.Context) {
+	if s.cfg.ReminderMaxAttempts <= 0 {
+		s.logger.Info("🔕 Registration reminders are disabled")
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(s.cfg.ReminderCheckMins) * time.Minute)
+	defer ticker.Stop()
+
+	s.logger.Info("🔔 Registration reminders started",
+		slog.Int("interval_mins", s.cfg.ReminderIntervalMins),
+		slog.Int("max_attempts", s.cfg.ReminderMaxAttempts))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sendDue(ctx)
+		}
+	}
+}
+
+func (s *ReminderService) sendDue(ctx context.Context) {
+	interval := time.Duration(s.cfg.ReminderIntervalMins) * time.Minute
+	now := time.Now()
+
+	candidates, err := s.reminderRepo.GetCandidates(ctx, now.Add(-interval), s.cfg.ReminderMaxAttempts, reminderBatchSize)
+	if err != nil {
+		s.logger.Error("❌ Failed to load reminder candidates", slog.Any("error", err))
+		return
+	}
+
+	for _, c := range candidates {
+		if !reminderDue(c, interval, now) {
+			continue
+		}
+
+		// Bot bloklangan bo'lsa ham urinish hisoblanadi, aks holda har safar qayta urinamiz
+		if err := s.sender.SendReminder(ctx, c.TelegramID, c.State); err != nil {
+			s.logger.Warn("⚠️ Failed to send reminder",
+				slog.Int64("telegram_id", c.TelegramID),
+				slog.Any("error", err))
+		}
+
+		reminder := &domain.Reminder{UserID: c.UserID, State: c.State, Attempt: c.Attempts + 1}
+		if err := s.reminderRepo.Create(ctx, reminder); err != nil {
+			s.logger.Error("❌ Failed to record reminder", slog.Any("error", err))
+			continue
+		}
+
+		s.logger.Info("🔔 Reminder sent",
+			slog.Int64("telegram_id", c.TelegramID),
+			slog.String("state", c.State),
+			slog.Int("attempt", reminder.Attempt))
+	}
+}
+
+// reminderDue applies the backoff: the first reminder goes out one interval
+// after the user got stuck, each next one after twice the previous wait
+func reminderDue(c domain.ReminderCandidate, interval time.Duration, now time.Time) bool {
+	since := c.StuckSince
+	if c.LastSentAt.After(since) {
+		since = c.LastSentAt
+	}
+	return now.Sub(since) >= interval<<c.Attempts
+}
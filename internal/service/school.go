@@ -0,0 +1,118 @@
+// internal/service/school.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"khisobot/internal/domain"
+)
+
+const schoolSearchLimit = 10
+
+var schoolNumberRegex = regexp.MustCompile(`\d+`)
+
+type SchoolService struct {
+	schoolRepo domain.SchoolRepository
+	logger     *slog.Logger
+}
+
+func NewSchoolService(schoolRepo domain.SchoolRepository, logger *slog.Logger) *SchoolService {
+	return &SchoolService{
+		schoolRepo: schoolRepo,
+		logger:     logger,
+	}
+}
+
+func (s *SchoolService) GetSchool(ctx context.Context, id int64) (*domain.School, error) {
+	return s.schoolRepo.GetByID(ctx, id)
+}
+
+func (s *SchoolService) GetByDistrict(ctx context.Context, districtID int64) ([]domain.School, error) {
+	return s.schoolRepo.GetByDistrict(ctx, districtID)
+}
+
+// Search accepts either a school number ("56", "56-maktab") or a name fragment
+func (s *SchoolService) Search(ctx context.Context, districtID int64, query string) ([]domain.School, error) {
+	query = strings.TrimSpace(query)
+	return s.schoolRepo.Search(ctx, districtID, query, parseSchoolNumber(query), schoolSearchLimit)
+}
+
+// SubmitPending puts a school the user could not find into the admin review queue
+func (s *SchoolService) SubmitPending(ctx context.Context, districtID int64, name string, telegramID int64) (*domain.School, error) {
+	school := &domain.School{
+		DistrictID:  districtID,
+		Number:      parseSchoolNumber(name),
+		Name:        strings.TrimSpace(name),
+		Status:      domain.SchoolStatusPending,
+		SubmittedBy: telegramID,
+	}
+
+	if err := s.schoolRepo.Create(ctx, school); err != nil {
+		return nil, fmt.Errorf("create pending school: %w", err)
+	}
+
+	s.logger.Info("🏫 School submitted for review",
+		slog.Int64("school_id", school.ID),
+		slog.Int64("district_id", districtID),
+		slog.Int64("telegram_id", telegramID))
+
+	return school, nil
+}
+
+func (s *SchoolService) GetPending(ctx context.Context) ([]domain.School, error) {
+	return s.schoolRepo.GetPending(ctx)
+}
+
+func (s *SchoolService) CountPending(ctx context.Context) (int64, error) {
+	return s.schoolRepo.CountPending(ctx)
+}
+
+func (s *SchoolService) Approve(ctx context.Context, id int64) (*domain.School, error) {
+	return s.review(ctx, id, domain.SchoolStatusApproved)
+}
+
+func (s *SchoolService) Reject(ctx context.Context, id int64) (*domain.School, error) {
+	return s.review(ctx, id, domain.SchoolStatusRejected)
+}
+
+func (s *SchoolService) review(ctx context.Context, id int64, status string) (*domain.School, error) {
+	school, err := s.schoolRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get school: %w", err)
+	}
+	if school == nil || school.Status != domain.SchoolStatusPending {
+		return nil, fmt.Errorf("school %d is not pending", id)
+	}
+
+	if err := s.schoolRepo.UpdateStatus(ctx, id, status); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("🏫 School reviewed",
+		slog.Int64("school_id", id),
+		slog.String("status", status))
+
+	school.Status = status
+	return school, nil
+}
+
+func (s *SchoolService) GetStats(ctx context.Context) ([]domain.SchoolStat, error) {
+	return s.schoolRepo.GetStats(ctx)
+}
+
+func parseSchoolNumber(text string) int {
+	match := schoolNumberRegex.FindString(text)
+	if match == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(match)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -8,11 +8,14 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"khisobot/config"
+	"khisobot/internal/domain"
 )
 
+// SMSService is the sms.etc.uz provider ("single-sms" JSON protocol)
 type SMSService struct {
 	cfg    *config.Config
 	client *http.Client
@@ -53,15 +56,17 @@ func NewSMSService(cfg *config.Config, logger *slog.Logger) *SMSService {
 	return &SMSService{
 		cfg: cfg,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: time.Duration(cfg.SMSTimeoutSecs) * time.Second,
 		},
 		logger: logger,
 	}
 }
 
-func (s *SMSService) SendSMS(ctx context.Context, phone, message string) (string, error) {
-	messageID := fmt.Sprintf("OTP_%d", time.Now().UnixNano())
+func (s *SMSService) Name() string {
+	return SMSProviderEtcUz
+}
 
+func (s *SMSService) SendSMS(ctx context.Context, messageID, phone, message string) (string, error) {
 	req := SMSRequest{
 		Header: SMSHeader{
 			Login: s.cfg.SMSLogin,
@@ -70,7 +75,7 @@ func (s *SMSService) SendSMS(ctx context.Context, phone, message string) (string
 		},
 		Body: SMSBody{
 			MessageIDIn: messageID,
-			CdPN:        phone,
+			CdPN:        strings.TrimPrefix(phone, "+"), // shlyuz raqamni "+" siz kutadi
 			Text:        message,
 		},
 	}
@@ -94,13 +99,17 @@ func (s *SMSService) SendSMS(ctx context.Context, phone, message string) (string
 
 	resp, err := s.client.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("send sms request: %w", err)
+		return "", providerUnavailable("send sms request: %v", err)
 	}
 	defer resp.Body.Close()
 
+	// Shlyuz noto'g'ri so'rovga 500 qaytaradi, boshqa provayderda ham o'tmaydi
 	if resp.StatusCode == http.StatusInternalServerError {
 		return "", fmt.Errorf("sms service error: invalid request format")
 	}
+	if resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusUnauthorized {
+		return "", providerUnavailable("sms service http %d", resp.StatusCode)
+	}
 
 	var smsResp SMSResponse
 	if err := json.NewDecoder(resp.Body).Decode(&smsResp); err != nil {
@@ -114,9 +123,9 @@ func (s *SMSService) SendSMS(ctx context.Context, phone, message string) (string
 			slog.String("message_id", messageID))
 		return messageID, nil
 	case 401:
-		return "", fmt.Errorf("sms auth failed: %s", smsResp.QueryState)
+		return "", providerUnavailable("sms auth failed: %s", smsResp.QueryState)
 	case 503:
-		return "", fmt.Errorf("sms service error: %s", smsResp.QueryState)
+		return "", providerUnavailable("sms service error: %s", smsResp.QueryState)
 	default:
 		return "", fmt.Errorf("unknown sms error: code=%d, state=%s", smsResp.QueryCode, smsResp.QueryState)
 	}
@@ -155,3 +164,25 @@ func (s *SMSService) GetStatus(ctx context.Context, messageID string) (string, e
 
 	return statusResp.QueryState, nil
 }
+
+func (s *SMSService) TracksDelivery() bool {
+	return true
+}
+
+// failedDeliveryStates are the gateway's final states for a message that did not arrive
+var failedDeliveryStates = []string{"UNDELIV", "FAIL", "REJECT", "EXPIRED", "DELETED", "ERROR"}
+
+// DeliveryStatus maps the gateway's query_state to a domain delivery status.
+// Anything that is not final yet (accepted, enroute, ...) stays pending.
+func DeliveryStatus(queryState string) string {
+	state := strings.ToUpper(queryState)
+	for _, failed := range failedDeliveryStates {
+		if strings.Contains(state, failed) {
+			return domain.DeliveryStatusFailed
+		}
+	}
+	if strings.Contains(state, "DELIVR") || strings.Contains(state, "DELIVERED") {
+		return domain.DeliveryStatusDelivered
+	}
+	return domain.DeliveryStatusPending
+}
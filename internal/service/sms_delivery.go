@@ -0,0 +1,110 @@
+// internal/service/sms_delivery.go
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"khisobot/config"
+	"khisobot/internal/domain"
+)
+
+// deliveryBatchSize caps how many status requests go to the gateway per check
+const deliveryBatchSize = 100
+
+// DeliveryTracker polls the SMS gateway for delivery reports of recent OTP
+// messages and tells users whose code did not arrive
+type DeliveryTracker struct {
+	otpRepo  domain.OTPRepository
+	gateway  SMSGateway
+	notifier domain.DeliveryNotifier
+	cfg      *config.Config
+	logger   *slog.Logger
+}
+
+func NewDeliveryTracker(
+	otpRepo domain.OTPRepository,
+	gateway SMSGateway,
+	notifier domain.DeliveryNotifier,
+	cfg *config.Config,
+	logger *slog.Logger,
+) *DeliveryTracker {
+	return &DeliveryTracker{
+		otpRepo:  otpRepo,
+		gateway:  gateway,
+		notifier: notifier,
+		cfg:      cfg,
+		logger:   logger,
+	}
+}
+
+// Run checks pending deliveries every SMS_STATUS_CHECK_SECS until ctx is cancelled
+func (t *DeliveryTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(t.cfg.SMSStatusCheckSecs) * time.Second)
+	defer ticker.Stop()
+
+	t.logger.Info("📨 SMS delivery tracking started",
+		slog.Int("check_secs", t.cfg.SMSStatusCheckSecs),
+		slog.Int("window_mins", t.cfg.SMSStatusWindowMins))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.check(ctx)
+		}
+	}
+}
+
+func (t *DeliveryTracker) check(ctx context.Context) {
+	// Oynadan eski xabarlar uchun hisobot kelmaydi deb hisoblaymiz va so'ramaymiz
+	since := time.Now().Add(-time.Duration(t.cfg.SMSStatusWindowMins) * time.Minute)
+
+	checks, err := t.otpRepo.GetPendingDeliveries(ctx, since, deliveryBatchSize)
+	if err != nil {
+		t.logger.Error("❌ Failed to load pending SMS deliveries", slog.Any("error", err))
+		return
+	}
+
+	for _, c := range checks {
+		state, err := t.gateway.GetStatus(ctx, c.OTP.MessageID)
+		if err != nil {
+			t.logger.Warn("⚠️ Failed to get SMS status",
+				slog.String("message_id", c.OTP.MessageID),
+				slog.Any("error", err))
+			continue
+		}
+
+		status := DeliveryStatus(state)
+		if status == domain.DeliveryStatusPending {
+			continue
+		}
+		if err := t.otpRepo.UpdateDeliveryStatus(ctx, c.OTP.ID, status); err != nil {
+			t.logger.Error("❌ Failed to save SMS status", slog.Any("error", err))
+			continue
+		}
+
+		if status == domain.DeliveryStatusFailed {
+			t.logger.Warn("📵 OTP SMS not delivered",
+				slog.String("phone", c.OTP.Phone),
+				slog.String("message_id", c.OTP.MessageID),
+				slog.String("state", state))
+			t.notifyFailed(ctx, c)
+		}
+	}
+}
+
+// notifyFailed only bothers users still waiting for this very code
+func (t *DeliveryTracker) notifyFailed(ctx context.Context, c domain.DeliveryCheck) {
+	if c.OTP.Status != domain.OTPStatusPending || time.Now().After(c.OTP.ExpiresAt) {
+		return
+	}
+
+	if err := t.notifier.NotifyDeliveryFailed(ctx, c.TelegramID, c.OTP.Phone); err != nil {
+		t.logger.Warn("⚠️ Failed to notify about undelivered SMS",
+			slog.Int64("telegram_id", c.TelegramID),
+			slog.Any("error", err))
+	}
+}
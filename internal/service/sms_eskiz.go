@@ -0,0 +1,165 @@
+// internal/service/sms_eskiz.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"khisobot/config"
+)
+
+// EskizProvider speaks the Eskiz-style REST API: a bearer token from
+// /auth/login, form posts to /message/sms/send and a status lookup by ID
+type EskizProvider struct {
+	cfg    *config.Config
+	client *http.Client
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	token string
+}
+
+func NewEskizProvider(cfg *config.Config, logger *slog.Logger) *EskizProvider {
+	return &EskizProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Duration(cfg.SMSTimeoutSecs) * time.Second},
+		logger: logger,
+	}
+}
+
+func (p *EskizProvider) Name() string {
+	return SMSProviderEskiz
+}
+
+// SendSMS ignores messageID: Eskiz assigns its own and has no client ID to dedupe on
+func (p *EskizProvider) SendSMS(ctx context.Context, _, phone, message string) (string, error) {
+	form := url.Values{
+		"mobile_phone": {strings.TrimPrefix(phone, "+")},
+		"message":      {message},
+		"from":         {p.cfg.EskizFrom},
+	}
+
+	var resp struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := p.do(ctx, http.MethodPost, "/message/sms/send", form, &resp); err != nil {
+		return "", fmt.Errorf("eskiz send: %w", err)
+	}
+	if resp.ID == "" {
+		return "", fmt.Errorf("eskiz send: no message id, status=%s", resp.Status)
+	}
+	return resp.ID, nil
+}
+
+func (p *EskizProvider) GetStatus(ctx context.Context, messageID string) (string, error) {
+	var resp struct {
+		Data struct {
+			Status string `json:"status"`
+		} `json:"data"`
+	}
+	if err := p.do(ctx, http.MethodGet, "/message/sms/status_by_id/"+url.PathEscape(messageID), nil, &resp); err != nil {
+		return "", fmt.Errorf("eskiz status: %w", err)
+	}
+	return resp.Data.Status, nil
+}
+
+func (p *EskizProvider) TracksDelivery() bool {
+	return true
+}
+
+// do calls the API with the cached token and logs in again once if it expired
+func (p *EskizProvider) do(ctx context.Context, method, path string, form url.Values, out any) error {
+	stale := ""
+	for attempt := 0; ; attempt++ {
+		token, err := p.getToken(ctx, stale)
+		if err != nil {
+			return err
+		}
+
+		status, err := p.request(ctx, method, path, form, token, out)
+		if status == http.StatusUnauthorized && attempt == 0 {
+			stale = token
+			continue
+		}
+		return err
+	}
+}
+
+// getToken returns the cached token unless it is missing or equal to stale,
+// the one the API just rejected. The login runs outside the lock so a slow
+// auth call does not block requests that still hold a good token.
+func (p *EskizProvider) getToken(ctx context.Context, stale string) (string, error) {
+	p.mu.Lock()
+	token := p.token
+	p.mu.Unlock()
+
+	if token != "" && token != stale {
+		return token, nil
+	}
+
+	form := url.Values{"email": {p.cfg.EskizEmail}, "password": {p.cfg.EskizPassword}}
+	var resp struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if _, err := p.request(ctx, http.MethodPost, "/auth/login", form, "", &resp); err != nil {
+		return "", fmt.Errorf("eskiz login: %w", err)
+	}
+	if resp.Data.Token == "" {
+		return "", providerUnavailable("eskiz login: empty token")
+	}
+
+	p.mu.Lock()
+	p.token = resp.Data.Token
+	p.mu.Unlock()
+
+	p.logger.Info("🔑 Eskiz token refreshed")
+	return resp.Data.Token, nil
+}
+
+func (p *EskizProvider) request(ctx context.Context, method, path string, form url.Values, token string, out any) (int, error) {
+	var body *strings.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	} else {
+		body = strings.NewReader("")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.cfg.EskizBaseURL+path, body)
+	if err != nil {
+		return 0, fmt.Errorf("create http request: %w", err)
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, providerUnavailable("%v", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode >= http.StatusInternalServerError:
+		return resp.StatusCode, providerUnavailable("http %d", resp.StatusCode)
+	case resp.StatusCode >= http.StatusBadRequest:
+		return resp.StatusCode, fmt.Errorf("http %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("decode response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,274 @@
+// internal/service/sms_outbox.go
+package service
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"khisobot/config"
+	"khisobot/internal/domain"
+)
+
+// smsOutboxBatchSize caps how many messages one poll claims and sends at once
+const smsOutboxBatchSize = 20
+
+// newSMSMessageID returns a random ID that stays unique across replicas,
+// unlike a timestamp. Letters and digits only, as some gateways require.
+func newSMSMessageID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	return "otp" + hex.EncodeToString(b), nil
+}
+
+// SMSTextBuilder rebuilds the text of a queued message from its sealed code;
+// OTPService is the real one
+type SMSTextBuilder interface {
+	OutboxText(ctx context.Context, m domain.SMSOutboxMessage) (string, error)
+}
+
+// SMSOutbox drains the sms_outbox table: it sends queued OTP messages,
+// retries failures with exponential backoff and jitter, and gives a message
+// up after SMS_OUTBOX_MAX_ATTEMPTS. A code given up because every provider was
+// unavailable is then tried over the channels listed after sms in OTP_CHANNELS;
+// a rejected one is not, and the user is told it did not arrive.
+type SMSOutbox struct {
+	outboxRepo domain.SMSOutboxRepository
+	otpRepo    domain.OTPRepository
+	texts      SMSTextBuilder
+	gateway    SMSGateway
+	fallback   []domain.OTPDeliverer
+	notifier   domain.DeliveryNotifier
+	cfg        *config.Config
+	logger     *slog.Logger
+
+	// unrecorded holds gateway IDs of messages that went out but could not be
+	// marked as sent, by outbox message ID, so a re-claimed message is only
+	// recorded and not sent twice
+	mu         sync.Mutex
+	unrecorded map[string]string
+}
+
+func NewSMSOutbox(
+	outboxRepo domain.SMSOutboxRepository,
+	otpRepo domain.OTPRepository,
+	texts SMSTextBuilder,
+	gateway SMSGateway,
+	fallback []domain.OTPDeliverer,
+	notifier domain.DeliveryNotifier,
+	cfg *config.Config,
+	logger *slog.Logger,
+) *SMSOutbox {
+	return &SMSOutbox{
+		outboxRepo: outboxRepo,
+		otpRepo:    otpRepo,
+		texts:      texts,
+		gateway:    gateway,
+		fallback:   fallback,
+		notifier:   notifier,
+		cfg:        cfg,
+		logger:     logger,
+		unrecorded: make(map[string]string),
+	}
+}
+
+// Run polls the outbox every SMS_OUTBOX_POLL_MS until ctx is cancelled
+func (o *SMSOutbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(o.cfg.SMSOutboxPollMillis) * time.Millisecond)
+	defer ticker.Stop()
+
+	o.logger.Info("📤 SMS outbox started",
+		slog.Int("max_attempts", o.cfg.SMSOutboxMaxAttempts),
+		slog.Int("backoff_secs", o.cfg.SMSOutboxBackoffSecs))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.drain(ctx)
+		}
+	}
+}
+
+// lease is how long a claimed message is hidden from other workers: long
+// enough for every provider to time out once
+func (o *SMSOutbox) lease() time.Duration {
+	providers := max(len(o.cfg.SMSProviders), 1)
+	return time.Duration(o.cfg.SMSTimeoutSecs*providers)*time.Second + 30*time.Second
+}
+
+func (o *SMSOutbox) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		messages, err := o.outboxRepo.ClaimDue(ctx, smsOutboxBatchSize, o.lease())
+		if err != nil {
+			o.logger.Error("❌ Failed to claim queued SMS", slog.Any("error", err))
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, m := range messages {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				o.send(ctx, m)
+			}()
+		}
+		wg.Wait()
+
+		// To'liq bo'lmagan partiya - navbatda boshqa tayyor xabar yo'q
+		if len(messages) < smsOutboxBatchSize {
+			return
+		}
+	}
+}
+
+func (o *SMSOutbox) send(ctx context.Context, m domain.SMSOutboxMessage) {
+	// SMS allaqachon ketgan, faqat bazaga yozilmay qolgan
+	if messageID, ok := o.takeUnrecorded(m.MessageID); ok {
+		o.markSent(ctx, m, messageID)
+		return
+	}
+
+	// Kod ishlatilgan, almashtirilgan yoki eskirgan bo'lsa SMS endi kerak emas
+	if m.OTPStatus != domain.OTPStatusPending || time.Now().After(m.OTPExpiresAt) {
+		if err := o.outboxRepo.Cancel(ctx, m.ID); err != nil {
+			o.logger.Error("❌ Failed to cancel queued SMS", slog.Any("error", err))
+		}
+		return
+	}
+
+	text, err := o.texts.OutboxText(ctx, m)
+	if err != nil {
+		// Kodni ochib bo'lmaydi (masalan, OTP_SECRET almashgan) - qayta urinish foydasiz
+		o.logger.Error("❌ Failed to build queued SMS", slog.String("phone", m.Phone), slog.Any("error", err))
+		if err := o.outboxRepo.MarkDead(ctx, m.ID, err.Error()); err != nil {
+			o.logger.Error("❌ Failed to mark SMS as dead", slog.Any("error", err))
+		}
+		o.notifyFailed(ctx, m)
+		return
+	}
+
+	msg := domain.OTPMessage{ID: m.MessageID, TelegramID: m.TelegramID, Phone: m.Phone, Text: text}
+	messageID, err := o.gateway.Deliver(ctx, msg)
+	if err == nil {
+		o.logger.Info("📱 OTP SMS sent",
+			slog.String("phone", m.Phone),
+			slog.String("message_id", messageID),
+			slog.Int("attempt", m.Attempts))
+		o.markSent(ctx, m, messageID)
+		return
+	}
+
+	// Rad etilgan raqam yoki matn qayta urinishda ham o'tmaydi
+	if errors.Is(err, ErrProviderUnavailable) && m.Attempts < o.cfg.SMSOutboxMaxAttempts {
+		next := time.Now().Add(o.backoff(m.Attempts))
+		if err := o.outboxRepo.Retry(ctx, m.ID, next, err.Error()); err != nil {
+			o.logger.Error("❌ Failed to schedule SMS retry", slog.Any("error", err))
+		}
+		o.logger.Warn("⚠️ OTP SMS failed, will retry",
+			slog.String("phone", m.Phone),
+			slog.Int("attempt", m.Attempts),
+			slog.Time("next_attempt_at", next),
+			slog.Any("error", err))
+		return
+	}
+
+	o.logger.Error("📵 OTP SMS given up",
+		slog.String("phone", m.Phone),
+		slog.String("message_id", m.MessageID),
+		slog.Int("attempts", m.Attempts),
+		slog.Any("error", err))
+	if err := o.outboxRepo.MarkDead(ctx, m.ID, err.Error()); err != nil {
+		o.logger.Error("❌ Failed to mark SMS as dead", slog.Any("error", err))
+	}
+
+	// Rad etilgan raqamga kod boshqa kanal orqali yuborilmaydi: aks holda
+	// begona raqamni kiritib, kodni o'z chatida olish mumkin bo'lardi
+	if !errors.Is(err, ErrProviderUnavailable) {
+		o.notifyFailed(ctx, m)
+		return
+	}
+	o.fallBack(ctx, m, msg)
+}
+
+// markSent records a sent message. If that fails the gateway ID is kept, and
+// when the lease runs out and the message is claimed again it is only recorded.
+func (o *SMSOutbox) markSent(ctx context.Context, m domain.SMSOutboxMessage, messageID string) {
+	// Hisobot bermaydigan provayder xabarlari kuzatilmaydi
+	status := domain.DeliveryStatusPending
+	if !o.gateway.TracksDelivery(messageID) {
+		status = domain.DeliveryStatusUnknown
+	}
+	if err := o.outboxRepo.MarkSent(ctx, m.ID, messageID, status); err != nil {
+		o.logger.Error("❌ Failed to mark SMS as sent, will record it again",
+			slog.String("message_id", m.MessageID),
+			slog.Any("error", err))
+		o.mu.Lock()
+		o.unrecorded[m.MessageID] = messageID
+		o.mu.Unlock()
+	}
+}
+
+func (o *SMSOutbox) takeUnrecorded(outboxMessageID string) (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	messageID, ok := o.unrecorded[outboxMessageID]
+	delete(o.unrecorded, outboxMessageID)
+	return messageID, ok
+}
+
+// backoff doubles the delay with every attempt up to the maximum and picks a
+// random point in its upper half, so messages failed together do not retry together
+func (o *SMSOutbox) backoff(attempt int) time.Duration {
+	base := time.Duration(o.cfg.SMSOutboxBackoffSecs) * time.Second
+	limit := time.Duration(o.cfg.SMSOutboxMaxBackoffSecs) * time.Second
+
+	delay := base
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	delay = min(delay, limit)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// fallBack sends a given-up code over the remaining channels, or tells the
+// user to ask for a new one
+func (o *SMSOutbox) fallBack(ctx context.Context, m domain.SMSOutboxMessage, msg domain.OTPMessage) {
+	for _, d := range o.fallback {
+		messageID, err := d.Deliver(ctx, msg)
+		if err != nil {
+			o.logger.Warn("⚠️ OTP channel failed, trying the next one",
+				slog.String("channel", d.Channel()),
+				slog.String("phone", m.Phone),
+				slog.Any("error", err))
+			continue
+		}
+
+		if err := o.otpRepo.UpdateChannel(ctx, m.OTPID, d.Channel(), messageID); err != nil {
+			o.logger.Error("❌ Failed to save OTP channel", slog.Any("error", err))
+		}
+		o.logger.Info("📱 OTP sent after SMS failed",
+			slog.String("phone", m.Phone),
+			slog.String("channel", d.Channel()))
+		return
+	}
+
+	o.notifyFailed(ctx, m)
+}
+
+func (o *SMSOutbox) notifyFailed(ctx context.Context, m domain.SMSOutboxMessage) {
+	if err := o.notifier.NotifyDeliveryFailed(ctx, m.TelegramID, m.Phone); err != nil {
+		o.logger.Warn("⚠️ Failed to notify about undelivered SMS",
+			slog.Int64("telegram_id", m.TelegramID),
+			slog.Any("error", err))
+	}
+}
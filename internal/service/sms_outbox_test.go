@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"khisobot/config"
+	"khisobot/internal/domain"
+)
+
+// fakeOutboxRepo records the outcome of one message
+type fakeOutboxRepo struct {
+	domain.SMSOutboxRepository
+	outcome        string
+	deliveryStatus string
+	retryAt        time.Time
+	markSentErr    error // returned once
+}
+
+func (r *fakeOutboxRepo) MarkSent(_ context.Context, _ int64, _, deliveryStatus string) error {
+	if err := r.markSentErr; err != nil {
+		r.markSentErr = nil
+		return err
+	}
+	r.outcome, r.deliveryStatus = domain.SMSOutboxSent, deliveryStatus
+	return nil
+}
+
+func (r *fakeOutboxRepo) Retry(_ context.Context, _ int64, next time.Time, _ string) error {
+	r.outcome, r.retryAt = "retry", next
+	return nil
+}
+
+func (r *fakeOutboxRepo) MarkDead(_ context.Context, _ int64, _ string) error {
+	r.outcome = domain.SMSOutboxDead
+	return nil
+}
+
+func (r *fakeOutboxRepo) Cancel(_ context.Context, _ int64) error {
+	r.outcome = domain.SMSOutboxCancelled
+	return nil
+}
+
+type fakeOTPRepo struct {
+	domain.OTPRepository
+	channel string
+}
+
+func (r *fakeOTPRepo) UpdateChannel(_ context.Context, _ int64, channel, _ string) error {
+	r.channel = channel
+	return nil
+}
+
+type fakeTexts struct{ err error }
+
+func (f fakeTexts) OutboxText(_ context.Context, _ domain.SMSOutboxMessage) (string, error) {
+	return "kod 123456", f.err
+}
+
+type fakeGateway struct {
+	err    error
+	tracks bool
+	sent   int
+}
+
+func (g *fakeGateway) Channel() string { return domain.OTPChannelSMS }
+
+func (g *fakeGateway) Deliver(_ context.Context, msg domain.OTPMessage) (string, error) {
+	if g.err != nil {
+		return "", g.err
+	}
+	g.sent++
+	return "etc_uz:" + msg.ID, nil
+}
+
+func (g *fakeGateway) GetStatus(_ context.Context, _ string) (string, error) { return "", nil }
+
+func (g *fakeGateway) TracksDelivery(_ string) bool { return g.tracks }
+
+type fakeDeliverer struct {
+	err  error
+	sent int
+}
+
+func (d *fakeDeliverer) Channel() string { return domain.OTPChannelTelegram }
+
+func (d *fakeDeliverer) Deliver(_ context.Context, _ domain.OTPMessage) (string, error) {
+	if d.err != nil {
+		return "", d.err
+	}
+	d.sent++
+	return "", nil
+}
+
+type fakeNotifier struct{ notified int }
+
+func (n *fakeNotifier) NotifyDeliveryFailed(_ context.Context, _ int64, _ string) error {
+	n.notified++
+	return nil
+}
+
+func testOutboxConfig() *config.Config {
+	return &config.Config{
+		SMSOutboxMaxAttempts:    3,
+		SMSOutboxBackoffSecs:    2,
+		SMSOutboxMaxBackoffSecs: 60,
+	}
+}
+
+func TestSMSOutboxBackoff(t *testing.T) {
+	o := &SMSOutbox{cfg: testOutboxConfig()}
+
+	tests := []struct {
+		attempt int
+		delay   time.Duration // the delay before jitter
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{5, 32 * time.Second},
+		{6, 60 * time.Second},
+		{20, 60 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.delay.String(), func(t *testing.T) {
+			for range 100 {
+				got := o.backoff(tt.attempt)
+				if got < tt.delay/2 || got > tt.delay {
+					t.Fatalf("backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.delay/2, tt.delay)
+				}
+			}
+		})
+	}
+}
+
+func TestSMSOutboxSend(t *testing.T) {
+	tests := []struct {
+		name         string
+		attempts     int
+		otpStatus    string
+		expired      bool
+		textErr      error
+		gatewayErr   error
+		tracks       bool
+		fallbackErr  error
+		wantOutcome  string
+		wantDelivery string
+		wantFallback bool
+		wantNotified bool
+	}{
+		{
+			name:         "sent and tracked",
+			attempts:     1,
+			tracks:       true,
+			wantOutcome:  domain.SMSOutboxSent,
+			wantDelivery: domain.DeliveryStatusPending,
+		},
+		{
+			name:         "sent by a gateway without reports",
+			attempts:     1,
+			wantOutcome:  domain.SMSOutboxSent,
+			wantDelivery: domain.DeliveryStatusUnknown,
+		},
+		{
+			name:        "unavailable gateway is retried",
+			attempts:    2,
+			gatewayErr:  errUnavailable,
+			wantOutcome: "retry",
+		},
+		{
+			name:         "last attempt dead-letters and falls back",
+			attempts:     3,
+			gatewayErr:   errUnavailable,
+			wantOutcome:  domain.SMSOutboxDead,
+			wantFallback: true,
+		},
+		{
+			name:         "rejected message is not retried or sent elsewhere",
+			attempts:     1,
+			gatewayErr:   errRejected,
+			wantOutcome:  domain.SMSOutboxDead,
+			wantNotified: true,
+		},
+		{
+			name:         "user is told when every channel fails",
+			attempts:     3,
+			gatewayErr:   errUnavailable,
+			fallbackErr:  errors.New("chat not found"),
+			wantOutcome:  domain.SMSOutboxDead,
+			wantNotified: true,
+		},
+		{
+			name:         "unreadable code is dead-lettered",
+			attempts:     1,
+			textErr:      errors.New("cipher: message authentication failed"),
+			wantOutcome:  domain.SMSOutboxDead,
+			wantNotified: true,
+		},
+		{
+			name:        "replaced code is cancelled",
+			attempts:    1,
+			otpStatus:   domain.OTPStatusSuperseded,
+			wantOutcome: domain.SMSOutboxCancelled,
+		},
+		{
+			name:        "expired code is cancelled",
+			attempts:    1,
+			expired:     true,
+			wantOutcome: domain.SMSOutboxCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outboxRepo := &fakeOutboxRepo{}
+			otpRepo := &fakeOTPRepo{}
+			gateway := &fakeGateway{err: tt.gatewayErr, tracks: tt.tracks}
+			fallback := &fakeDeliverer{err: tt.fallbackErr}
+			notifier := &fakeNotifier{}
+			o := NewSMSOutbox(outboxRepo, otpRepo, fakeTexts{err: tt.textErr}, gateway,
+				[]domain.OTPDeliverer{fallback}, notifier, testOutboxConfig(), slog.New(slog.DiscardHandler))
+
+			m := domain.SMSOutboxMessage{
+				ID:           1,
+				MessageID:    "otp1",
+				Phone:        "+998901234567",
+				Attempts:     tt.attempts,
+				OTPStatus:    domain.OTPStatusPending,
+				OTPExpiresAt: time.Now().Add(time.Minute),
+			}
+			if tt.otpStatus != "" {
+				m.OTPStatus = tt.otpStatus
+			}
+			if tt.expired {
+				m.OTPExpiresAt = time.Now().Add(-time.Second)
+			}
+
+			o.send(context.Background(), m)
+
+			if outboxRepo.outcome != tt.wantOutcome {
+				t.Errorf("outcome = %q, want %q", outboxRepo.outcome, tt.wantOutcome)
+			}
+			if outboxRepo.deliveryStatus != tt.wantDelivery {
+				t.Errorf("delivery status = %q, want %q", outboxRepo.deliveryStatus, tt.wantDelivery)
+			}
+			if got := otpRepo.channel == domain.OTPChannelTelegram; got != tt.wantFallback {
+				t.Errorf("fell back = %v, want %v", got, tt.wantFallback)
+			}
+			if got := notifier.notified > 0; got != tt.wantNotified {
+				t.Errorf("notified = %v, want %v", got, tt.wantNotified)
+			}
+			if tt.wantOutcome == "retry" && !outboxRepo.retryAt.After(time.Now()) {
+				t.Errorf("retry at %v, want a time in the future", outboxRepo.retryAt)
+			}
+		})
+	}
+}
+
+func TestSMSOutboxSendUnrecorded(t *testing.T) {
+	outboxRepo := &fakeOutboxRepo{markSentErr: errors.New("connection reset")}
+	gateway := &fakeGateway{tracks: true}
+	o := NewSMSOutbox(outboxRepo, &fakeOTPRepo{}, fakeTexts{}, gateway, nil, &fakeNotifier{},
+		testOutboxConfig(), slog.New(slog.DiscardHandler))
+
+	m := domain.SMSOutboxMessage{
+		ID:           1,
+		MessageID:    "otp1",
+		Attempts:     1,
+		OTPStatus:    domain.OTPStatusPending,
+		OTPExpiresAt: time.Now().Add(time.Minute),
+	}
+	o.send(context.Background(), m)
+	if outboxRepo.outcome != "" {
+		t.Fatalf("outcome after a failed MarkSent = %q, want none", outboxRepo.outcome)
+	}
+
+	// Lease tugagach xabar qayta olinadi
+	m.Attempts = 2
+	o.send(context.Background(), m)
+	if gateway.sent != 1 {
+		t.Errorf("gateway sent %d messages, want 1", gateway.sent)
+	}
+	if outboxRepo.outcome != domain.SMSOutboxSent || outboxRepo.deliveryStatus != domain.DeliveryStatusPending {
+		t.Errorf("outcome = %q/%q, want sent/pending", outboxRepo.outcome, outboxRepo.deliveryStatus)
+	}
+}
+
+func TestSealCode(t *testing.T) {
+	s := &OTPService{cfg: &config.Config{OTPSecret: "0123456789abcdef0123456789abcdef"}}
+	other := &OTPService{cfg: &config.Config{OTPSecret: "fedcba9876543210fedcba9876543210"}}
+
+	sealed, err := s.sealCode("otp1", "123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		service   *OTPService
+		messageID string
+		sealed    []byte
+		want      string
+		wantErr   bool
+	}{
+		{"round trip", s, "otp1", sealed, "123456", false},
+		{"another message", s, "otp2", sealed, "", true},
+		{"another secret", other, "otp1", sealed, "", true},
+		{"truncated", s, "otp1", sealed[:4], "", true},
+		{"cleared", s, "otp1", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.service.openCode(tt.messageID, tt.sealed)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("openCode() = %q, %v, want %q, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
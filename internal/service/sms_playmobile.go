@@ -0,0 +1,101 @@
+// internal/service/sms_playmobile.go
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"khisobot/config"
+)
+
+// PlaymobileProvider speaks the Playmobile-style broker API: basic auth and a
+// JSON batch of messages. Delivery reports are pushed by the broker rather
+// than polled, so its messages are not tracked.
+type PlaymobileProvider struct {
+	cfg    *config.Config
+	client *http.Client
+	logger *slog.Logger
+}
+
+type playmobileRequest struct {
+	Messages []playmobileMessage `json:"messages"`
+}
+
+type playmobileMessage struct {
+	Recipient string        `json:"recipient"`
+	MessageID string        `json:"message-id"`
+	SMS       playmobileSMS `json:"sms"`
+}
+
+type playmobileSMS struct {
+	Originator string `json:"originator"`
+	Content    struct {
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+func NewPlaymobileProvider(cfg *config.Config, logger *slog.Logger) *PlaymobileProvider {
+	return &PlaymobileProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Duration(cfg.SMSTimeoutSecs) * time.Second},
+		logger: logger,
+	}
+}
+
+func (p *PlaymobileProvider) Name() string {
+	return SMSProviderPlaymobile
+}
+
+func (p *PlaymobileProvider) SendSMS(ctx context.Context, messageID, phone, message string) (string, error) {
+	msg := playmobileMessage{
+		Recipient: strings.TrimPrefix(phone, "+"),
+		MessageID: messageID,
+		SMS:       playmobileSMS{Originator: p.cfg.PlaymobileOriginator},
+	}
+	msg.SMS.Content.Text = message
+
+	jsonData, err := json.Marshal(playmobileRequest{Messages: []playmobileMessage{msg}})
+	if err != nil {
+		return "", fmt.Errorf("marshal playmobile request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.PlaymobileBaseURL+"/broker-api/send",
+		bytes.NewReader(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("create http request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(p.cfg.PlaymobileLogin, p.cfg.PlaymobilePassword)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", providerUnavailable("playmobile send: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode >= http.StatusInternalServerError:
+		return "", providerUnavailable("playmobile send: http %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("playmobile send: http %d: %s", resp.StatusCode, body)
+	}
+
+	return messageID, nil
+}
+
+// GetStatus is never called, see TracksDelivery
+func (p *PlaymobileProvider) GetStatus(_ context.Context, _ string) (string, error) {
+	return "", nil
+}
+
+func (p *PlaymobileProvider) TracksDelivery() bool {
+	return false
+}
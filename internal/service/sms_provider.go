@@ -0,0 +1,191 @@
+// internal/service/sms_provider.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"khisobot/internal/domain"
+)
+
+// SMS provider names, see SMS_PROVIDERS
+const (
+	SMSProviderEtcUz      = "etc_uz"
+	SMSProviderEskiz      = "eskiz"
+	SMSProviderPlaymobile = "playmobile"
+)
+
+// ErrProviderUnavailable marks failures another provider may not have: bad
+// credentials (401), an overloaded gateway (503), timeouts and network errors.
+// Other errors, e.g. a rejected number, are returned without failover.
+var ErrProviderUnavailable = errors.New("sms provider unavailable")
+
+func providerUnavailable(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrProviderUnavailable, fmt.Sprintf(format, args...))
+}
+
+// SMSProvider is one SMS gateway
+type SMSProvider interface {
+	Name() string
+	// SendSMS returns the gateway's message ID. Gateways that accept a client
+	// ID get messageID, so a retried send with the same ID is not duplicated.
+	SendSMS(ctx context.Context, messageID, phone, message string) (string, error)
+	// GetStatus returns the gateway's delivery state, "" if it has none yet
+	GetStatus(ctx context.Context, messageID string) (string, error)
+	// TracksDelivery is false for gateways without a status lookup; their
+	// messages are never polled and are left out of the delivery stats
+	TracksDelivery() bool
+}
+
+// ProviderStats are the in-memory counters of one provider since start
+type ProviderStats struct {
+	Name      string
+	Healthy   bool
+	Sent      int64
+	Failed    int64
+	LastError string
+	// DownUntil is when an unhealthy provider is tried again first
+	DownUntil time.Time
+}
+
+// SMSRouter sends through the first healthy provider and fails over to the
+// next one when a provider is unavailable. A failed provider is skipped for
+// the cooldown, unless every provider is down.
+type SMSRouter struct {
+	providers []SMSProvider
+	cooldown  time.Duration
+	logger    *slog.Logger
+
+	mu    sync.Mutex
+	stats map[string]*ProviderStats
+}
+
+func NewSMSRouter(providers []SMSProvider, cooldown time.Duration, logger *slog.Logger) *SMSRouter {
+	stats := make(map[string]*ProviderStats, len(providers))
+	for _, p := range providers {
+		stats[p.Name()] = &ProviderStats{Name: p.Name(), Healthy: true}
+	}
+	return &SMSRouter{
+		providers: providers,
+		cooldown:  cooldown,
+		logger:    logger,
+		stats:     stats,
+	}
+}
+
+// Channel implements domain.OTPDeliverer
+func (r *SMSRouter) Channel() string {
+	return domain.OTPChannelSMS
+}
+
+// Deliver implements domain.OTPDeliverer. The returned message ID is prefixed
+// with the provider name so delivery reports are asked from the right gateway.
+func (r *SMSRouter) Deliver(ctx context.Context, msg domain.OTPMessage) (string, error) {
+	var errs []error
+	for _, p := range r.ordered() {
+		messageID, err := p.SendSMS(ctx, msg.ID, msg.Phone, msg.Text)
+		r.record(p.Name(), err)
+		if err == nil {
+			return p.Name() + ":" + messageID, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+		if !errors.Is(err, ErrProviderUnavailable) || ctx.Err() != nil {
+			break
+		}
+		r.logger.Warn("⚠️ SMS provider unavailable, failing over",
+			slog.String("provider", p.Name()),
+			slog.Any("error", err))
+	}
+	return "", errors.Join(errs...)
+}
+
+// GetStatus asks the provider that sent the message
+func (r *SMSRouter) GetStatus(ctx context.Context, messageID string) (string, error) {
+	p := r.provider(messageID)
+	if p == nil {
+		return "", fmt.Errorf("sms provider of %q is not configured", messageID)
+	}
+	_, id := splitMessageID(messageID)
+	return p.GetStatus(ctx, id)
+}
+
+func (r *SMSRouter) provider(messageID string) SMSProvider {
+	name, _ := splitMessageID(messageID)
+	for _, p := range r.providers {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// splitMessageID separates the provider name from the gateway's ID. IDs
+// stored before routing existed have no prefix and belong to sms.etc.uz.
+func splitMessageID(messageID string) (string, string) {
+	name, id, ok := strings.Cut(messageID, ":")
+	if !ok {
+		return SMSProviderEtcUz, messageID
+	}
+	return name, id
+}
+
+// TracksDelivery reports whether the provider that sent the message has delivery reports
+func (r *SMSRouter) TracksDelivery(messageID string) bool {
+	p := r.provider(messageID)
+	return p != nil && p.TracksDelivery()
+}
+
+// Stats returns the counters in provider order
+func (r *SMSRouter) Stats() []ProviderStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]ProviderStats, 0, len(r.providers))
+	for _, p := range r.providers {
+		st := *r.stats[p.Name()]
+		st.Healthy = time.Now().After(st.DownUntil)
+		result = append(result, st)
+	}
+	return result
+}
+
+// ordered puts healthy providers first, keeping the configured order otherwise
+func (r *SMSRouter) ordered() []SMSProvider {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var healthy, down []SMSProvider
+	for _, p := range r.providers {
+		if now.Before(r.stats[p.Name()].DownUntil) {
+			down = append(down, p)
+		} else {
+			healthy = append(healthy, p)
+		}
+	}
+	return append(healthy, down...)
+}
+
+func (r *SMSRouter) record(name string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := r.stats[name]
+	if err == nil {
+		st.Sent++
+		st.DownUntil = time.Time{}
+		return
+	}
+
+	st.Failed++
+	st.LastError = err.Error()
+	if errors.Is(err, ErrProviderUnavailable) {
+		st.DownUntil = time.Now().Add(r.cooldown)
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"khisobot/internal/domain"
+)
+
+// fakeProvider fails with err until it is cleared, and records what it sent
+type fakeProvider struct {
+	name    string
+	err     error
+	tracks  bool
+	sent    []string
+	queried []string
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) SendSMS(_ context.Context, messageID, _, _ string) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	p.sent = append(p.sent, messageID)
+	return messageID, nil
+}
+
+func (p *fakeProvider) GetStatus(_ context.Context, messageID string) (string, error) {
+	p.queried = append(p.queried, messageID)
+	return "DELIVERED", nil
+}
+
+func (p *fakeProvider) TracksDelivery() bool { return p.tracks }
+
+var (
+	errUnavailable = providerUnavailable("http 503")
+	errRejected    = errors.New("invalid number")
+)
+
+func TestSMSRouterDeliver(t *testing.T) {
+	tests := []struct {
+		name     string
+		errs     []error // per provider, in configured order
+		wantID   string
+		wantErr  error
+		wantSent []int // messages sent per provider
+		wantDown []bool
+	}{
+		{
+			name:     "first provider sends",
+			errs:     []error{nil, nil},
+			wantID:   "a:otp1",
+			wantSent: []int{1, 0},
+			wantDown: []bool{false, false},
+		},
+		{
+			name:     "fails over when unavailable",
+			errs:     []error{errUnavailable, nil},
+			wantID:   "b:otp1",
+			wantSent: []int{0, 1},
+			wantDown: []bool{true, false},
+		},
+		{
+			name:     "no failover on a rejected message",
+			errs:     []error{errRejected, nil},
+			wantErr:  errRejected,
+			wantSent: []int{0, 0},
+			wantDown: []bool{false, false},
+		},
+		{
+			name:     "all providers unavailable",
+			errs:     []error{errUnavailable, errUnavailable},
+			wantErr:  ErrProviderUnavailable,
+			wantSent: []int{0, 0},
+			wantDown: []bool{true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &fakeProvider{name: "a", err: tt.errs[0]}
+			b := &fakeProvider{name: "b", err: tt.errs[1]}
+			r := NewSMSRouter([]SMSProvider{a, b}, time.Minute, slog.New(slog.DiscardHandler))
+
+			id, err := r.Deliver(context.Background(), domain.OTPMessage{ID: "otp1", Phone: "+998901234567"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Deliver() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil || id != tt.wantID {
+				t.Fatalf("Deliver() = %q, %v, want %q", id, err, tt.wantID)
+			}
+
+			for i, p := range []*fakeProvider{a, b} {
+				if len(p.sent) != tt.wantSent[i] {
+					t.Errorf("provider %s sent %d, want %d", p.name, len(p.sent), tt.wantSent[i])
+				}
+			}
+			for i, st := range r.Stats() {
+				if st.Healthy == tt.wantDown[i] {
+					t.Errorf("provider %s healthy = %v, want %v", st.Name, st.Healthy, !tt.wantDown[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSMSRouterCooldown(t *testing.T) {
+	a := &fakeProvider{name: "a", err: errUnavailable}
+	b := &fakeProvider{name: "b"}
+	r := NewSMSRouter([]SMSProvider{a, b}, time.Minute, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+
+	if _, err := r.Deliver(ctx, domain.OTPMessage{ID: "otp1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a sog'aygan bo'lsa ham cooldown tugaguncha b birinchi
+	a.err = nil
+	id, err := r.Deliver(ctx, domain.OTPMessage{ID: "otp2"})
+	if err != nil || id != "b:otp2" {
+		t.Fatalf("Deliver() during cooldown = %q, %v, want b:otp2", id, err)
+	}
+
+	r.stats["a"].DownUntil = time.Now().Add(-time.Second)
+	id, err = r.Deliver(ctx, domain.OTPMessage{ID: "otp3"})
+	if err != nil || id != "a:otp3" {
+		t.Fatalf("Deliver() after cooldown = %q, %v, want a:otp3", id, err)
+	}
+
+	stats := r.Stats()
+	if stats[0].Sent != 1 || stats[0].Failed != 1 || stats[1].Sent != 2 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestSMSRouterStatus(t *testing.T) {
+	etc := &fakeProvider{name: SMSProviderEtcUz, tracks: true}
+	pm := &fakeProvider{name: SMSProviderPlaymobile}
+	r := NewSMSRouter([]SMSProvider{etc, pm}, time.Minute, slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		messageID string
+		tracks    bool
+		provider  *fakeProvider
+		wantID    string // what the provider is asked for
+		wantErr   bool
+	}{
+		{"etc_uz:123", true, etc, "123", false},
+		{"456", true, etc, "456", false},
+		{"playmobile:otp1", false, pm, "otp1", false},
+		{"eskiz:789", false, nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.messageID, func(t *testing.T) {
+			if got := r.TracksDelivery(tt.messageID); got != tt.tracks {
+				t.Errorf("TracksDelivery(%q) = %v, want %v", tt.messageID, got, tt.tracks)
+			}
+
+			_, err := r.GetStatus(context.Background(), tt.messageID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetStatus(%q) error = %v, wantErr %v", tt.messageID, err, tt.wantErr)
+			}
+			if tt.provider != nil && tt.provider.queried[len(tt.provider.queried)-1] != tt.wantID {
+				t.Errorf("GetStatus(%q) asked %s for %q, want %q",
+					tt.messageID, tt.provider.name, tt.provider.queried[len(tt.provider.queried)-1], tt.wantID)
+			}
+		})
+	}
+}
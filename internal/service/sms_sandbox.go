@@ -0,0 +1,103 @@
+// internal/service/sms_sandbox.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"khisobot/internal/domain"
+)
+
+// sandboxCapacity is how many fake messages are kept; older ones are dropped
+const sandboxCapacity = 200
+
+// SMSGateway is what the bot needs from an SMS provider: sending OTP messages
+// and reading their delivery reports
+type SMSGateway interface {
+	domain.OTPDeliverer
+	GetStatus(ctx context.Context, messageID string) (string, error)
+	// TracksDelivery reports whether GetStatus can tell if the message arrived
+	TracksDelivery(messageID string) bool
+}
+
+// SandboxMessage is an SMS the sandbox pretended to send
+type SandboxMessage struct {
+	MessageID  string
+	TelegramID int64
+	Phone      string
+	Text       string
+	SentAt     time.Time
+}
+
+// SandboxSMS replaces the SMS gateway outside production: nothing leaves the
+// process, messages are kept in memory for the /sandbox admin command and
+// every message is reported as delivered
+type SandboxSMS struct {
+	logger *slog.Logger
+
+	mu       sync.RWMutex
+	messages []SandboxMessage
+	seq      int64
+}
+
+func NewSandboxSMS(logger *slog.Logger) *SandboxSMS {
+	return &SandboxSMS{logger: logger}
+}
+
+func (s *SandboxSMS) Channel() string {
+	return domain.OTPChannelSMS
+}
+
+func (s *SandboxSMS) Deliver(_ context.Context, msg domain.OTPMessage) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	m := SandboxMessage{
+		MessageID:  fmt.Sprintf("SANDBOX_%d", s.seq),
+		TelegramID: msg.TelegramID,
+		Phone:      msg.Phone,
+		Text:       msg.Text,
+		SentAt:     time.Now(),
+	}
+	s.messages = append(s.messages, m)
+	if len(s.messages) > sandboxCapacity {
+		s.messages = s.messages[len(s.messages)-sandboxCapacity:]
+	}
+
+	s.logger.Info("🧪 Sandbox SMS",
+		slog.String("phone", m.Phone),
+		slog.String("message_id", m.MessageID))
+	return m.MessageID, nil
+}
+
+func (s *SandboxSMS) GetStatus(_ context.Context, _ string) (string, error) {
+	return "DELIVERED", nil
+}
+
+func (s *SandboxSMS) TracksDelivery(_ string) bool {
+	return true
+}
+
+func (m SandboxMessage) matches(phone string) bool {
+	return phone == "" || strings.Contains(m.Phone, phone)
+}
+
+// Messages returns up to limit latest messages, newest first. A non-empty
+// phone keeps only numbers containing it, so "4567" finds +998901234567.
+func (s *SandboxSMS) Messages(phone string, limit int) []SandboxMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []SandboxMessage
+	for i := len(s.messages) - 1; i >= 0 && len(result) < limit; i-- {
+		if s.messages[i].matches(phone) {
+			result = append(result, s.messages[i])
+		}
+	}
+	return result
+}
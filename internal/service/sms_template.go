@@ -0,0 +1,102 @@
+// internal/service/sms_template.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"khisobot/internal/domain"
+	"khisobot/pkg/i18n"
+	"khisobot/pkg/sms"
+)
+
+// SMSTemplate returns the OTP SMS template used for the language and whether
+// an admin has overridden the built-in one
+func (s *OTPService) SMSTemplate(ctx context.Context, langCode string) (string, bool, error) {
+	t, err := s.templateRepo.Get(ctx, langCode)
+	if err != nil {
+		return "", false, err
+	}
+	if t == nil {
+		return i18n.Get(langCode).OTPSMS, false, nil
+	}
+	return t.Template, true, nil
+}
+
+// SetSMSTemplate stores an admin's template after checking that it carries the
+// code and that the rendered text still goes out as a single SMS
+func (s *OTPService) SetSMSTemplate(ctx context.Context, langCode, template string, adminID int64) (sms.Segment, error) {
+	if !slices.Contains(i18n.Languages, langCode) {
+		return sms.Segment{}, domain.ErrSMSTemplateLanguage
+	}
+
+	template = strings.TrimSpace(template)
+	_, segment := s.PreviewSMS(template)
+	if !strings.Contains(template, domain.SMSPlaceholderCode) {
+		return segment, domain.ErrSMSTemplateNoCode
+	}
+	if !segment.Fits() {
+		return segment, domain.ErrSMSTemplateTooLong
+	}
+
+	err := s.templateRepo.Upsert(ctx, &domain.SMSTemplate{
+		LanguageCode: langCode,
+		Template:     template,
+		UpdatedBy:    adminID,
+	})
+	return segment, err
+}
+
+// ResetSMSTemplate drops the admin's template so the built-in one is used again
+func (s *OTPService) ResetSMSTemplate(ctx context.Context, langCode string) error {
+	if !slices.Contains(i18n.Languages, langCode) {
+		return domain.ErrSMSTemplateLanguage
+	}
+	return s.templateRepo.Delete(ctx, langCode)
+}
+
+// PreviewSMS renders the template with a sample code of the configured length
+func (s *OTPService) PreviewSMS(template string) (string, sms.Segment) {
+	sample := strings.Repeat("0", s.cfg.OTPLength)
+	text := s.renderSMS(template, sample)
+	return text, sms.Measure(text)
+}
+
+// otpText builds the OTP message in the user's language. An admin template
+// that no longer fits one SMS (e.g. after OTP_LENGTH grew) falls back to the
+// built-in text.
+func (s *OTPService) otpText(ctx context.Context, langCode, code string) string {
+	template, custom, err := s.SMSTemplate(ctx, langCode)
+	if err != nil {
+		s.logger.Warn("⚠️ Failed to load SMS template", slog.String("lang", langCode), slog.Any("error", err))
+		template, custom = i18n.Get(langCode).OTPSMS, false
+	}
+
+	text := s.renderSMS(template, code)
+	if custom && !sms.Measure(text).Fits() {
+		s.logger.Warn("⚠️ SMS template is too long for one SMS, using the default", slog.String("lang", langCode))
+		text = s.renderSMS(i18n.Get(langCode).OTPSMS, code)
+	}
+	return text
+}
+
+// OutboxText rebuilds the text of a queued OTP SMS from its sealed code
+func (s *OTPService) OutboxText(ctx context.Context, m domain.SMSOutboxMessage) (string, error) {
+	code, err := s.openCode(m.MessageID, m.SealedCode)
+	if err != nil {
+		return "", fmt.Errorf("open queued otp code: %w", err)
+	}
+	return s.otpText(ctx, m.LanguageCode, code), nil
+}
+
+func (s *OTPService) renderSMS(template, code string) string {
+	return strings.NewReplacer(
+		domain.SMSPlaceholderCode, code,
+		domain.SMSPlaceholderMinutes, strconv.Itoa(s.cfg.OTPExpiresMins),
+		domain.SMSPlaceholderBrand, s.cfg.SMSBrand,
+	).Replace(template)
+}
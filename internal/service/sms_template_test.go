@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"khisobot/config"
+	"khisobot/internal/domain"
+	"khisobot/pkg/i18n"
+	"khisobot/pkg/sms"
+)
+
+type fakeTemplateRepo struct {
+	domain.SMSTemplateRepository
+}
+
+func (fakeTemplateRepo) Get(_ context.Context, _ string) (*domain.SMSTemplate, error) {
+	return nil, nil
+}
+
+// The built-in texts never pass through SetSMSTemplate, so they must fit one
+// SMS on their own, even with the longest code OTP_LENGTH allows
+func TestBuiltInSMSTemplatesFitOneSegment(t *testing.T) {
+	s := &OTPService{
+		cfg:          &config.Config{OTPLength: 12, OTPExpiresMins: 60, SMSBrand: "Amity"},
+		templateRepo: fakeTemplateRepo{},
+		logger:       slog.New(slog.DiscardHandler),
+	}
+	code := strings.Repeat("9", s.cfg.OTPLength)
+
+	for _, lang := range i18n.Languages {
+		t.Run(lang, func(t *testing.T) {
+			text := s.otpText(context.Background(), lang, code)
+			if !strings.Contains(text, code) {
+				t.Fatalf("text %q has no code", text)
+			}
+			if seg := sms.Measure(text); !seg.Fits() {
+				t.Errorf("%q needs more than one SMS: %s, %d/%d", text, seg.Encoding, seg.Length, seg.Limit)
+			}
+		})
+	}
+}
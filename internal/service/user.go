@@ -3,25 +3,48 @@ package service
 
 import (
 	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/big"
+	"slices"
+	"strings"
 	"time"
 
 	"khisobot/config"
 	"khisobot/internal/domain"
+	"khisobot/pkg/translit"
 )
 
+const userSearchLimit = 20
+
 type UserService struct {
-	userRepo domain.UserRepository
-	logger   *slog.Logger
+	userRepo        domain.UserRepository
+	participantRepo domain.ParticipantRepository
+	fsm             *domain.RegistrationFSM
+	cfg             *config.Config
+	logger          *slog.Logger
 }
 
-func NewUserService(userRepo domain.UserRepository, logger *slog.Logger) *UserService {
+func NewUserService(
+	userRepo domain.UserRepository,
+	participantRepo domain.ParticipantRepository,
+	fsm *domain.RegistrationFSM,
+	cfg *config.Config,
+	logger *slog.Logger,
+) *UserService {
 	return &UserService{
-		userRepo: userRepo,
-		logger:   logger,
+		userRepo:        userRepo,
+		participantRepo: participantRepo,
+		fsm:             fsm,
+		cfg:             cfg,
+		logger:          logger,
 	}
 }
 
@@ -35,7 +58,7 @@ func (s *UserService) GetOrCreateUser(ctx context.Context, telegramID int64, use
 		s.logger.Info("👤 Existing user found",
 			slog.Int64("telegram_id", telegramID),
 			slog.String("state", user.State))
-		return user, nil
+		return s.repairState(ctx, s.withActiveChild(ctx, user)), nil
 	}
 
 	if langCode == "" || (langCode != "uz" && langCode != "ru" && langCode != "en") {
@@ -46,7 +69,7 @@ func (s *UserService) GetOrCreateUser(ctx context.Context, telegramID int64, use
 		TelegramID:   telegramID,
 		Username:     username,
 		LanguageCode: langCode,
-		State:        domain.StateWaitFullName,
+		State:        domain.StateWaitRole,
 	}
 
 	if err := s.userRepo.Create(ctx, newUser); err != nil {
@@ -61,30 +84,359 @@ func (s *UserService) GetOrCreateUser(ctx context.Context, telegramID int64, use
 }
 
 func (s *UserService) GetUser(ctx context.Context, telegramID int64) (*domain.User, error) {
-	return s.userRepo.GetByTelegramID(ctx, telegramID)
+	user, err := s.userRepo.GetByTelegramID(ctx, telegramID)
+	if err != nil || user == nil {
+		return user, err
+	}
+	return s.repairState(ctx, s.withActiveChild(ctx, user)), nil
 }
 
-func (s *UserService) UpdateUserState(ctx context.Context, telegramID int64, state string) error {
-	return s.userRepo.UpdateState(ctx, telegramID, state)
+// withActiveChild shows the child a parent is adding or editing through the
+// user's location and grade fields, so the registration steps work the same
+// for every role
+func (s *UserService) withActiveChild(ctx context.Context, user *domain.User) *domain.User {
+	if user.Role != domain.RoleParent || user.ActiveParticipantID == 0 {
+		return user
+	}
+
+	p, err := s.participantRepo.GetByID(ctx, user.ActiveParticipantID)
+	if err != nil || p == nil {
+		s.logger.Error("❌ Failed to load active participant",
+			slog.Int64("telegram_id", user.TelegramID),
+			slog.Any("error", err))
+		return user
+	}
+
+	user.ChildName = p.FullName()
+	user.RegionID = p.RegionID
+	user.Region = p.Region
+	user.DistrictID = p.DistrictID
+	user.District = p.District
+	user.SchoolID = p.SchoolID
+	user.School = p.School
+	user.Grade = p.Grade
+	user.BirthDate = p.BirthDate
+	return user
 }
 
-func (s *UserService) UpdateFullName(ctx context.Context, telegramID int64, firstName, lastName string) error {
-	return s.userRepo.UpdateFullName(ctx, telegramID, firstName, lastName)
+// activeChild returns the participant that location and grade updates go to,
+// or 0 when they belong to the user themselves
+func (s *UserService) activeChild(ctx context.Context, telegramID int64) (int64, error) {
+	user, err := s.userRepo.GetByTelegramID(ctx, telegramID)
+	if err != nil {
+		return 0, fmt.Errorf("get user: %w", err)
+	}
+	if user == nil || user.Role != domain.RoleParent {
+		return 0, nil
+	}
+	return user.ActiveParticipantID, nil
 }
 
-func (s *UserService) UpdateLocation(ctx context.Context, telegramID int64, region, district, school string) error {
-	return s.userRepo.UpdateLocation(ctx, telegramID, region, district, school)
+// Transition moves the user to a new state if the registration FSM allows it
+func (s *UserService) Transition(ctx context.Context, user *domain.User, state string) error {
+	if err := s.fsm.Transition(user, state); err != nil {
+		s.logger.Warn("⚠️ Rejected state transition",
+			slog.Int64("telegram_id", user.TelegramID),
+			slog.String("from", user.State),
+			slog.String("to", state))
+		return err
+	}
+
+	if err := s.userRepo.UpdateState(ctx, user.TelegramID, state); err != nil {
+		return err
+	}
+
+	user.State = state
+	return nil
+}
+
+// repairState fixes a persisted state the FSM does not recognise, e.g. one
+// left behind by an older bot version, so the user is never stuck
+func (s *UserService) repairState(ctx context.Context, user *domain.User) *domain.User {
+	state, changed := s.fsm.Repair(user)
+	if !changed {
+		return user
+	}
+
+	s.logger.Warn("🔧 Repairing invalid user state",
+		slog.Int64("telegram_id", user.TelegramID),
+		slog.String("from", user.State),
+		slog.String("to", state))
+
+	if err := s.userRepo.UpdateState(ctx, user.TelegramID, state); err != nil {
+		s.logger.Error("❌ Failed to repair user state", slog.Any("error", err))
+		return user
+	}
+
+	user.State = state
+	return user
+}
+
+func (s *UserService) UpdateFullName(ctx context.Context, telegramID int64, name domain.FullName) error {
+	return s.userRepo.UpdateFullName(ctx, telegramID, name)
+}
+
+func (s *UserService) UpdateLocation(ctx context.Context, telegramID int64, loc domain.Location) error {
+	childID, err := s.activeChild(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+	if childID != 0 {
+		return s.participantRepo.UpdateLocation(ctx, childID, loc)
+	}
+	return s.userRepo.UpdateLocation(ctx, telegramID, loc)
 }
 
 func (s *UserService) UpdateGrade(ctx context.Context, telegramID int64, grade int) error {
+	childID, err := s.activeChild(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+	if childID != 0 {
+		return s.participantRepo.UpdateGrade(ctx, childID, grade)
+	}
 	return s.userRepo.UpdateGrade(ctx, telegramID, grade)
 }
 
+func (s *UserService) UpdateBirthDate(ctx context.Context, telegramID int64, birthDate time.Time) error {
+	childID, err := s.activeChild(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+	if childID != 0 {
+		return s.participantRepo.UpdateBirthDate(ctx, childID, birthDate)
+	}
+	return s.userRepo.UpdateBirthDate(ctx, telegramID, birthDate)
+}
+
+func (s *UserService) UpdateRole(ctx context.Context, telegramID int64, role string) error {
+	if !domain.IsValidRole(role) {
+		return fmt.Errorf("unknown role %q", role)
+	}
+	return s.userRepo.UpdateRole(ctx, telegramID, role)
+}
+
+func (s *UserService) UpdateSubject(ctx context.Context, telegramID int64, subject string) error {
+	return s.userRepo.UpdateSubject(ctx, telegramID, subject)
+}
+
+// UpdateChildName renames the active child, or adds a new child and makes it
+// active when the parent is not working on one yet
+func (s *UserService) UpdateChildName(ctx context.Context, user *domain.User, name domain.FullName) error {
+	if user.ActiveParticipantID != 0 {
+		return s.participantRepo.UpdateName(ctx, user.ActiveParticipantID, name)
+	}
+
+	p := &domain.Participant{
+		UserID:         user.OwnerID(),
+		FirstName:      name.First,
+		LastName:       name.Last,
+		FirstNameLatin: name.FirstLatin,
+		LastNameLatin:  name.LastLatin,
+	}
+	if err := s.participantRepo.Create(ctx, p); err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdateActiveParticipant(ctx, user.TelegramID, p.ID); err != nil {
+		return err
+	}
+
+	s.logger.Info("👶 Child added",
+		slog.Int64("telegram_id", user.TelegramID),
+		slog.Int64("participant_id", p.ID))
+
+	user.ActiveParticipantID = p.ID
+	return nil
+}
+
+func (s *UserService) GetChildren(ctx context.Context, userID int64) ([]domain.Participant, error) {
+	return s.participantRepo.GetByUserID(ctx, userID)
+}
+
+// GetChildrenOf returns the children of the given users grouped by user ID
+func (s *UserService) GetChildrenOf(ctx context.Context, users []domain.User) (map[int64][]domain.Participant, error) {
+	var ids []int64
+	for _, u := range users {
+		if u.Role == domain.RoleParent {
+			ids = append(ids, u.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	participants, err := s.participantRepo.GetByUserIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int64][]domain.Participant, len(ids))
+	for _, p := range participants {
+		children[p.UserID] = append(children[p.UserID], p)
+	}
+	return children, nil
+}
+
+// SelectChild makes one of the parent's children the target of the next edits
+func (s *UserService) SelectChild(ctx context.Context, user *domain.User, participantID int64) error {
+	p, err := s.participantRepo.GetByID(ctx, participantID)
+	if err != nil {
+		return err
+	}
+	if p == nil || p.UserID != user.OwnerID() {
+		return fmt.Errorf("participant %d does not belong to user %d", participantID, user.ID)
+	}
+
+	if err := s.userRepo.UpdateActiveParticipant(ctx, user.TelegramID, participantID); err != nil {
+		return err
+	}
+	user.ActiveParticipantID = participantID
+	return nil
+}
+
+// StartAddChild clears the active child and asks a registered parent for a new child's name
+func (s *UserService) StartAddChild(ctx context.Context, user *domain.User) error {
+	if err := s.userRepo.UpdateActiveParticipant(ctx, user.TelegramID, 0); err != nil {
+		return err
+	}
+
+	user.ActiveParticipantID = 0
+	user.ChildName = ""
+	user.RegionID, user.Region = 0, ""
+	user.DistrictID, user.District = 0, ""
+	user.SchoolID, user.School = 0, ""
+	user.Grade = 0
+	user.BirthDate = time.Time{}
+
+	return s.Transition(ctx, user, domain.StateWaitChildName)
+}
+
 func (s *UserService) UpdatePhone(ctx context.Context, telegramID int64, phone string) error {
 	return s.userRepo.UpdatePhone(ctx, telegramID, phone)
 }
 
-func (s *UserService) VerifyUser(ctx context.Context, telegramID int64) error {
+func (s *UserService) UpdatePendingPhone(ctx context.Context, telegramID int64, phone string) error {
+	return s.userRepo.UpdatePendingPhone(ctx, telegramID, phone)
+}
+
+func (s *UserService) ConfirmPendingPhone(ctx context.Context, telegramID int64, method string) error {
+	if err := s.userRepo.ConfirmPendingPhone(ctx, telegramID, method); err != nil {
+		return err
+	}
+
+	s.logger.Info("📱 Phone number changed",
+		slog.Int64("telegram_id", telegramID),
+		slog.String("method", method))
+	return nil
+}
+
+// DuplicatePhonePolicy is what happens when a number already belongs to another account
+func (s *UserService) DuplicatePhonePolicy() string {
+	return s.cfg.DuplicatePhonePolicy
+}
+
+// PhoneConflict returns the other verified account the phone belongs to, or
+// nil if it is free or belongs to the user's own primary account
+func (s *UserService) PhoneConflict(ctx context.Context, user *domain.User, phone string) (*domain.User, error) {
+	owner, err := s.userRepo.GetPhoneOwner(ctx, phone, user.ID)
+	if err != nil || owner == nil {
+		return nil, err
+	}
+	if owner.ID == user.PrimaryUserID {
+		return nil, nil
+	}
+	return owner, nil
+}
+
+// ResolvePhoneConflict applies the duplicate phone policy once the user has
+// proven the number is theirs
+func (s *UserService) ResolvePhoneConflict(ctx context.Context, user *domain.User, phone string) (domain.PhoneResolution, error) {
+	owner, err := s.PhoneConflict(ctx, user, phone)
+	if err != nil || owner == nil {
+		return domain.PhoneResolution{}, err
+	}
+
+	switch s.cfg.DuplicatePhonePolicy {
+	case config.DuplicatePhoneTransfer:
+		if err := s.userRepo.ReleasePhone(ctx, owner.TelegramID); err != nil {
+			return domain.PhoneResolution{}, err
+		}
+		s.logger.Info("📱 Phone transferred to another account",
+			slog.Int64("from_telegram_id", owner.TelegramID),
+			slog.Int64("to_telegram_id", user.TelegramID))
+		return domain.PhoneResolution{Released: owner}, nil
+
+	case config.DuplicatePhoneAlias:
+		if err := s.userRepo.LinkAlias(ctx, user.ID, owner.ID); err != nil {
+			return domain.PhoneResolution{}, err
+		}
+		s.logger.Info("🔗 Account linked as alias",
+			slog.Int64("telegram_id", user.TelegramID),
+			slog.Int64("primary_telegram_id", owner.TelegramID))
+		return domain.PhoneResolution{LinkedTo: owner}, nil
+
+	default:
+		// Raqam OTP yuborishdan oldin tekshiriladi, bu yerga faqat poyga holatida kelinadi
+		s.logger.Warn("⚠️ Duplicate phone verified despite reject policy",
+			slog.Int64("telegram_id", user.TelegramID),
+			slog.Int64("owner_telegram_id", owner.TelegramID))
+		return domain.PhoneResolution{}, nil
+	}
+}
+
+func (s *UserService) GetSharedPhones(ctx context.Context) ([]domain.PhoneGroup, error) {
+	return s.userRepo.GetSharedPhones(ctx)
+}
+
+// MergePhone links every account sharing the phone to the oldest one and
+// returns how many accounts became aliases
+func (s *UserService) MergePhone(ctx context.Context, phone string) (int, error) {
+	groups, err := s.userRepo.GetSharedPhones(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, g := range groups {
+		if g.Phone != phone {
+			continue
+		}
+
+		primary := g.Users[0]
+		for _, u := range g.Users[1:] {
+			if err := s.userRepo.LinkAlias(ctx, u.ID, primary.ID); err != nil {
+				return 0, err
+			}
+		}
+
+		s.logger.Info("🔗 Accounts merged",
+			slog.Int64("primary_telegram_id", primary.TelegramID),
+			slog.Int("aliases", len(g.Users)-1))
+		return len(g.Users) - 1, nil
+	}
+	return 0, nil
+}
+
+func (s *UserService) ResetRegistration(ctx context.Context, telegramID int64) error {
+	if err := s.participantRepo.DeleteUnverified(ctx, telegramID); err != nil {
+		return err
+	}
+	if err := s.userRepo.ResetRegistration(ctx, telegramID); err != nil {
+		return err
+	}
+
+	s.logger.Info("🔄 Registration reset", slog.Int64("telegram_id", telegramID))
+	return nil
+}
+
+// CancelEdit drops an unconfirmed phone change and returns a verified user to the registered state
+func (s *UserService) CancelEdit(ctx context.Context, telegramID int64) error {
+	if err := s.userRepo.UpdatePendingPhone(ctx, telegramID, ""); err != nil {
+		return err
+	}
+	return s.userRepo.UpdateState(ctx, telegramID, domain.StateRegistered)
+}
+
+func (s *UserService) VerifyUser(ctx context.Context, telegramID int64, method string) error {
 	user, err := s.userRepo.GetByTelegramID(ctx, telegramID)
 	if err != nil {
 		return fmt.Errorf("get user: %w", err)
@@ -94,86 +446,399 @@ func (s *UserService) VerifyUser(ctx context.Context, telegramID int64) error {
 	}
 
 	user.IsVerified = true
+	user.VerificationMethod = method
 	user.State = domain.StateRegistered
 
+	s.logger.Info("✅ User verified",
+		slog.Int64("telegram_id", telegramID),
+		slog.String("method", method))
+
 	return s.userRepo.Update(ctx, user)
 }
 
+// GetFunnel returns the registration funnel for users who entered each step
+// between from and to, in step order, with conversion from the previous step
+func (s *UserService) GetFunnel(ctx context.Context, from, to time.Time, role string) ([]domain.FunnelStep, error) {
+	counted, err := s.userRepo.GetFunnel(ctx, from, to, role)
+	if err != nil {
+		return nil, err
+	}
+
+	byState := make(map[string]domain.FunnelStep, len(counted))
+	for _, step := range counted {
+		byState[step.State] = step
+	}
+
+	states := s.fsm.FunnelStates(role)
+	steps := make([]domain.FunnelStep, 0, len(states))
+	for i, state := range states {
+		step := byState[state]
+		step.State = state
+		if i > 0 && steps[i-1].Users > 0 {
+			step.Conversion = float64(step.Users) / float64(steps[i-1].Users)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
 func (s *UserService) GetAllVerified(ctx context.Context) ([]domain.User, error) {
 	return s.userRepo.GetAllVerified(ctx)
 }
 
+// GetVerifiedByRole returns verified users of one role, or everyone for an empty role
+func (s *UserService) GetVerifiedByRole(ctx context.Context, role string) ([]domain.User, error) {
+	if role == "" {
+		return s.userRepo.GetAllVerified(ctx)
+	}
+	return s.userRepo.GetVerifiedByRole(ctx, role)
+}
+
+// SearchUsers finds verified users by name in either alphabet
+func (s *UserService) SearchUsers(ctx context.Context, query string) ([]domain.User, error) {
+	query = translit.ToLatin(strings.Join(strings.Fields(query), " "))
+	if query == "" {
+		return nil, nil
+	}
+	return s.userRepo.SearchByName(ctx, query, userSearchLimit)
+}
+
 func (s *UserService) GetStats(ctx context.Context) (*domain.Stats, error) {
 	return s.userRepo.GetStats(ctx)
 }
 
 // OTPService implementation
 type OTPService struct {
-	otpRepo    domain.OTPRepository
-	smsService *SMSService
+	otpRepo      domain.OTPRepository
+	outboxRepo   domain.SMSOutboxRepository
+	templateRepo domain.SMSTemplateRepository
+	// deliverers are tried in order until one delivers the code, see OTP_CHANNELS
+	deliverers []domain.OTPDeliverer
 	cfg        *config.Config
 	logger     *slog.Logger
 }
 
-func NewOTPService(otpRepo domain.OTPRepository, smsService *SMSService, cfg *config.Config, logger *slog.Logger) *OTPService {
+func NewOTPService(
+	otpRepo domain.OTPRepository,
+	outboxRepo domain.SMSOutboxRepository,
+	templateRepo domain.SMSTemplateRepository,
+	deliverers []domain.OTPDeliverer,
+	cfg *config.Config,
+	logger *slog.Logger,
+) *OTPService {
 	return &OTPService{
-		otpRepo:    otpRepo,
-		smsService: smsService,
-		cfg:        cfg,
-		logger:     logger,
+		otpRepo:      otpRepo,
+		outboxRepo:   outboxRepo,
+		templateRepo: templateRepo,
+		deliverers:   deliverers,
+		cfg:          cfg,
+		logger:       logger,
 	}
 }
 
-func (s *OTPService) GenerateAndSendOTP(ctx context.Context, userID int64, phone string) error {
-	code, err := generateOTPCode(s.cfg.OTPLength)
+// TrustOwnContact reports whether a contact the user shared about themselves
+// is enough to verify the number without an SMS code
+func (s *OTPService) TrustOwnContact() bool {
+	return s.cfg.PhoneVerificationPolicy == config.PhonePolicyTrustOwnContact
+}
+
+func (s *OTPService) GenerateAndSendOTP(ctx context.Context, user *domain.User, phone string) error {
+	until, err := s.lockedUntil(ctx, phone)
 	if err != nil {
-		return fmt.Errorf("generate otp code: %w", err)
+		return err
+	}
+	if !until.IsZero() {
+		return &domain.OTPLockedError{Until: until}
 	}
 
-	message := fmt.Sprintf("Sizning tasdiqlash kodingiz: %s\nKod %d daqiqa ichida amal qiladi.",
-		code, s.cfg.OTPExpiresMins)
+	if err := s.checkQuota(ctx, user.ID, phone); err != nil {
+		return err
+	}
 
-	messageID, err := s.smsService.SendSMS(ctx, phone, message)
+	code, err := generateOTPCode(s.cfg.OTPLength)
 	if err != nil {
-		return fmt.Errorf("send sms: %w", err)
+		return fmt.Errorf("generate otp code: %w", err)
+	}
+	// Sinov raqamlari uchun sandboxda doim bir xil kod
+	if s.cfg.SMSSandbox && slices.Contains(s.cfg.SMSSandboxPhones, phone) {
+		code = s.cfg.SMSSandboxCode
+	}
+
+	message := domain.OTPMessage{
+		TelegramID: user.TelegramID,
+		Phone:      phone,
+		Text:       s.otpText(ctx, user.LanguageCode, code),
+	}
+	queued := &domain.SMSOutboxMessage{
+		TelegramID:   user.TelegramID,
+		Phone:        phone,
+		LanguageCode: user.LanguageCode,
 	}
 
 	otp := &domain.OTPCode{
-		UserID:    userID,
+		UserID:    user.ID,
 		Phone:     phone,
-		Code:      code,
-		MessageID: messageID,
+		CodeHash:  s.hashCode(phone, code),
 		ExpiresAt: time.Now().Add(time.Duration(s.cfg.OTPExpiresMins) * time.Minute),
 	}
 
-	if err := s.otpRepo.Create(ctx, otp); err != nil {
-		return fmt.Errorf("save otp: %w", err)
+	if err := s.deliver(ctx, otp, message, queued, code); err != nil {
+		return err
 	}
 
-	s.logger.Info("📱 OTP sent",
+	s.logger.Info("📱 OTP issued",
 		slog.String("phone", phone),
-		slog.String("message_id", messageID))
+		slog.String("channel", otp.Channel),
+		slog.String("message_id", otp.MessageID))
+
+	return nil
+}
+
+// deliver tries the channels in order, falls back to the next one when a
+// channel fails and saves the code. SMS is not sent here: the code is saved
+// with queued in the SMS outbox, which sends it and, if it gives up, moves on
+// to the channels after sms itself.
+func (s *OTPService) deliver(ctx context.Context, otp *domain.OTPCode, msg domain.OTPMessage,
+	queued *domain.SMSOutboxMessage, code string) error {
+	var errs []error
+	for _, d := range s.deliverers {
+		if d.Channel() == domain.OTPChannelSMS {
+			return s.queueSMS(ctx, otp, queued, code)
+		}
+
+		messageID, err := d.Deliver(ctx, msg)
+		if err != nil {
+			s.logger.Warn("⚠️ OTP channel failed, trying the next one",
+				slog.String("channel", d.Channel()),
+				slog.String("phone", msg.Phone),
+				slog.Any("error", err))
+			errs = append(errs, fmt.Errorf("%s: %w", d.Channel(), err))
+			continue
+		}
+
+		otp.Channel = d.Channel()
+		otp.MessageID = messageID
+		otp.DeliveryStatus = domain.DeliveryStatusDelivered
+		if err := s.otpRepo.Create(ctx, otp, s.sendQuota(otp.UserID, otp.Phone)); err != nil {
+			return fmt.Errorf("save otp: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("deliver otp: %w", errors.Join(errs...))
+}
+
+// queueSMS stores the code only sealed; the outbox builds the text when it sends
+func (s *OTPService) queueSMS(ctx context.Context, otp *domain.OTPCode, queued *domain.SMSOutboxMessage, code string) error {
+	id, err := newSMSMessageID()
+	if err != nil {
+		return fmt.Errorf("generate sms message id: %w", err)
+	}
+	sealed, err := s.sealCode(id, code)
+	if err != nil {
+		return fmt.Errorf("seal otp code: %w", err)
+	}
 
+	// Gateway ID'si SMS ketgach yoziladi, hisobot kuzatuvi shundan boshlanadi
+	otp.Channel = domain.OTPChannelSMS
+	otp.DeliveryStatus = domain.DeliveryStatusPending
+	queued.MessageID = id
+	queued.SealedCode = sealed
+	if err := s.outboxRepo.Enqueue(ctx, otp, queued, s.sendQuota(otp.UserID, otp.Phone)); err != nil {
+		return fmt.Errorf("queue otp sms: %w", err)
+	}
 	return nil
 }
 
-func (s *OTPService) VerifyOTP(ctx context.Context, phone, code string) (bool, error) {
-	otp, err := s.otpRepo.GetByPhoneAndCode(ctx, phone, code)
+// OutboxStats counts OTP messages queued since the given time by outbox status
+func (s *OTPService) OutboxStats(ctx context.Context, since time.Time) (domain.SMSOutboxStats, error) {
+	return s.outboxRepo.GetStats(ctx, since)
+}
+
+// DeliveryStats reports how many OTP messages sent since the given time reached the phone
+func (s *OTPService) DeliveryStats(ctx context.Context, since time.Time) (domain.DeliveryStats, error) {
+	return s.otpRepo.GetDeliveryStats(ctx, since)
+}
+
+// ResendCooldown is how long a user waits before asking for another code
+func (s *OTPService) ResendCooldown() time.Duration {
+	return time.Duration(s.cfg.OTPResendCooldownSecs) * time.Second
+}
+
+// checkQuota fails early, before a code is generated or sent over a channel
+// that delivers at once. It is not enough on its own: two requests can both
+// pass it, so the repository checks sendQuota again as it stores the code.
+func (s *OTPService) checkQuota(ctx context.Context, userID int64, phone string) error {
+	quota := s.sendQuota(userID, phone)
+	stats, err := s.otpRepo.GetSendStats(ctx, phone, userID, quota.Since)
+	if err != nil {
+		return err
+	}
+	return quota.Allow(stats)
+}
+
+// sendQuota enforces the resend cooldown and the SMS caps over the last 24 hours
+func (s *OTPService) sendQuota(userID int64, phone string) domain.OTPQuota {
+	return domain.OTPQuota{
+		Since: time.Now().Add(-24 * time.Hour),
+		Allow: func(stats domain.OTPSendStats) error {
+			if until := stats.LastSentAt.Add(s.ResendCooldown()); time.Now().Before(until) {
+				return &domain.OTPCooldownError{Until: until}
+			}
+
+			if s.cfg.OTPDailyGlobal > 0 && stats.TotalCount >= s.cfg.OTPDailyGlobal {
+				s.logger.Error("🚨 Global daily SMS limit reached", slog.Int("limit", s.cfg.OTPDailyGlobal))
+				return domain.ErrOTPGlobalLimit
+			}
+			if stats.PhoneCount >= s.cfg.OTPDailyPerPhone || stats.UserCount >= s.cfg.OTPDailyPerUser {
+				s.logger.Warn("📵 Daily SMS limit reached",
+					slog.String("phone", phone),
+					slog.Int64("user_id", userID),
+					slog.Int("phone_count", stats.PhoneCount),
+					slog.Int("user_count", stats.UserCount))
+				return domain.ErrOTPDailyLimit
+			}
+			return nil
+		},
+	}
+}
+
+// VerifyOTP checks the code against the latest one this user was sent for the
+// phone. Wrong guesses are counted per code and per phone, see OTPVerification.
+func (s *OTPService) VerifyOTP(ctx context.Context, userID int64, phone, code string) (domain.OTPVerification, error) {
+	until, err := s.lockedUntil(ctx, phone)
 	if err != nil {
-		return false, fmt.Errorf("get otp: %w", err)
+		return domain.OTPVerification{}, err
+	}
+	if !until.IsZero() {
+		return domain.OTPVerification{LockedUntil: until}, nil
 	}
 
+	otp, err := s.otpRepo.GetActive(ctx, userID, phone)
+	if err != nil {
+		return domain.OTPVerification{}, fmt.Errorf("get otp: %w", err)
+	}
 	if otp == nil {
-		s.logger.Warn("❌ Invalid OTP code", slog.String("phone", phone))
-		return false, nil
+		s.logger.Warn("❌ No active OTP code", slog.String("phone", phone), slog.Int64("user_id", userID))
+		return domain.OTPVerification{}, nil
+	}
+
+	if !hmac.Equal([]byte(otp.CodeHash), []byte(s.hashCode(phone, code))) {
+		return s.recordFailure(ctx, otp)
+	}
+
+	verified, err := s.otpRepo.MarkVerified(ctx, otp.ID)
+	if err != nil {
+		return domain.OTPVerification{}, err
+	}
+	if !verified {
+		// Bir vaqtda yangi kod yuborilgan yoki kod allaqachon ishlatilgan
+		return domain.OTPVerification{}, nil
+	}
+
+	method := domain.OTPVerificationMethod(otp.Channel)
+	s.logger.Info("✅ OTP verified", slog.String("phone", phone), slog.String("channel", otp.Channel))
+	return domain.OTPVerification{Valid: true, Method: method}, nil
+}
+
+func (s *OTPService) recordFailure(ctx context.Context, otp *domain.OTPCode) (domain.OTPVerification, error) {
+	failed, err := s.otpRepo.RecordFailure(ctx, otp.ID, s.cfg.OTPMaxAttempts)
+	if err != nil {
+		return domain.OTPVerification{}, err
+	}
+
+	res := domain.OTPVerification{AttemptsLeft: max(s.cfg.OTPMaxAttempts-failed, 0)}
+	s.logger.Warn("❌ Invalid OTP code",
+		slog.String("phone", otp.Phone),
+		slog.Int("failed_attempts", failed))
+
+	res.LockedUntil, err = s.lockedUntil(ctx, otp.Phone)
+	if err != nil {
+		return domain.OTPVerification{}, err
+	}
+
+	// Adminlar loglardan qulflangan raqamlarni kuzatadi
+	switch {
+	case !res.LockedUntil.IsZero():
+		s.logger.Warn("🔒 Phone locked after too many wrong OTP codes",
+			slog.String("phone", otp.Phone),
+			slog.Int64("user_id", otp.UserID),
+			slog.Time("locked_until", res.LockedUntil))
+	case res.AttemptsLeft == 0:
+		s.logger.Warn("🚫 OTP code invalidated after too many wrong guesses",
+			slog.String("phone", otp.Phone),
+			slog.Int64("user_id", otp.UserID))
+	}
+
+	return res, nil
+}
+
+// lockedUntil returns when the phone's lockout ends, or zero if it is not locked.
+// Failures older than the lockout period no longer count.
+func (s *OTPService) lockedUntil(ctx context.Context, phone string) (time.Time, error) {
+	lockout := time.Duration(s.cfg.OTPLockoutMins) * time.Minute
+
+	failures, lastFailedAt, err := s.otpRepo.GetRecentFailures(ctx, phone, time.Now().Add(-lockout))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if failures < s.cfg.OTPPhoneMaxFailures {
+		return time.Time{}, nil
+	}
+	return lastFailedAt.Add(lockout), nil
+}
+
+// hashCode keys the code with OTP_SECRET and binds it to the phone, so a
+// leaked table neither reveals codes nor lets one be replayed for another number
+func (s *OTPService) hashCode(phone, code string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.OTPSecret))
+	mac.Write([]byte(phone + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sealKey derives the outbox encryption key from OTP_SECRET, so it differs
+// from the key the stored hashes use
+func (s *OTPService) sealKey() []byte {
+	mac := hmac.New(sha256.New, []byte(s.cfg.OTPSecret))
+	mac.Write([]byte("sms-outbox"))
+	return mac.Sum(nil)
+}
+
+// sealCode encrypts the code with AES-GCM bound to the outbox message ID; the
+// random nonce is prepended to the ciphertext
+func (s *OTPService) sealCode(messageID, code string) ([]byte, error) {
+	aead, err := newAEAD(s.sealKey())
+	if err != nil {
+		return nil, err
 	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, []byte(code), []byte(messageID)), nil
+}
 
-	if err := s.otpRepo.MarkAsUsed(ctx, otp.ID); err != nil {
-		return false, fmt.Errorf("mark otp as used: %w", err)
+func (s *OTPService) openCode(messageID string, sealed []byte) (string, error) {
+	aead, err := newAEAD(s.sealKey())
+	if err != nil {
+		return "", err
 	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("sealed code is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	code, err := aead.Open(nil, nonce, ciphertext, []byte(messageID))
+	if err != nil {
+		return "", err
+	}
+	return string(code), nil
+}
 
-	s.logger.Info("✅ OTP verified", slog.String("phone", phone))
-	return true, nil
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
 }
 
 func generateOTPCode(length int) (string, error) {
@@ -1,12 +1,22 @@
 // pkg/i18n/messages.go
 package i18n
 
+import "reflect"
+
 type Messages struct {
 	Welcome           string
 	AskFullName       string
 	InvalidFullName   string
 	AskLocation       string
 	InvalidLocation   string
+	AskDistrict       string
+	AskSchool         string
+	SchoolResults     string
+	SchoolNotFound    string
+	BtnSchoolMissing  string
+	AskSchoolName     string
+	SchoolSubmitted   string
+	SchoolApproved    string
 	AskGrade          string
 	InvalidGrade      string
 	AskPhone          string
@@ -15,6 +25,19 @@ type Messages struct {
 	InvalidOTP        string
 	OTPSent           string
 	RegistrationDone  string
+	ProfileTitle      string
+	ProfileUpdated    string
+	LabelFirstName    string
+	LabelLastName     string
+	LabelRegion       string
+	LabelDistrict     string
+	LabelSchool       string
+	LabelGrade        string
+	LabelPhone        string
+	BtnEditName       string
+	BtnEditLocation   string
+	BtnEditGrade      string
+	BtnEditPhone      string
 	MainMenu          string
 	AlreadyRegistered string
 	Error             string
@@ -26,23 +49,91 @@ type Messages struct {
 	MustSubscribe     string
 	BtnCheckSub       string
 	SubscribeSuccess  string
+	BtnBack           string
+	PreviousValue     string
+	Cancelled         string
+	EditCancelled     string
+	AskRole           string
+	InvalidRole       string
+	RoleStudent       string
+	RoleTeacher       string
+	RoleParent        string
+	AskChildName      string
+	AskChildGrade     string
+	AskSubject        string
+	InvalidSubject    string
+	LabelRole         string
+	LabelSubject      string
+	LabelChildName    string
+	BtnEditSubject    string
+	LabelChildren     string
+	NoChildren        string
+	ChildLine         string
+	ChildTitle        string
+	BtnAddChild       string
+	AskBirthDate      string
+	AskChildBirthDate string
+	AskBirthMonth     string
+	AskBirthDay       string
+	InvalidBirthDate  string
+	AgeGradeMismatch  string
+	LabelBirthDate    string
+	BtnEditBirthDate  string
+	ReminderNudge     string
+	ReminderNudgeOTP  string
+	PhoneTaken        string
+	PhoneTransferred  string
+	PhoneLinked       string
+	OTPCodeExpired    string
+	OTPLocked         string
+	OTPResendIn       string
+	OTPResendReady    string
+	OTPDailyLimit     string
+	OTPUnavailable    string
+	SMSNotDelivered   string
+	OTPSMS            string // default OTP SMS text, see domain.SMSPlaceholderCode
+	SMSTemplateUsage  string // /smstemplate help for admins
 }
 
+// Languages are the supported language codes, the default one first
+var Languages = []string{"uz", "ru", "en"}
+
 var messages = map[string]Messages{
 	"uz": {
 		Welcome:           "👋 Xush kelibsiz!\n\nRo'yxatdan o'tish uchun ma'lumotlaringizni kiriting.",
 		AskFullName:       "👤 Ism va familiyangizni kiriting:\n\n<i>Misol: Anvar Karimov</i>",
 		InvalidFullName:   "❌ Ism va familiyangizni to'liq kiriting.\n\n<i>Misol: Anvar Karimov</i>",
-		AskLocation:       "📍 Viloyat, tuman va maktabingizni kiriting:\n\n<i>Misol: Toshkent, Yunusobod, 56-maktab</i>",
-		InvalidLocation:   "❌ Noto'g'ri format.\n\nQuyidagi formatda kiriting:\n<i>Viloyat, Tuman, Maktab</i>\n\nMisol: Toshkent, Yunusobod, 56-maktab",
+		AskLocation:       "📍 Viloyatingizni tanlang:",
+		InvalidLocation:   "❌ Iltimos, ro'yxatdagi tugmalardan birini tanlang.",
+		AskDistrict:       "🏘 <b>%s</b>\n\nTumaningizni tanlang:",
+		AskSchool:         "🏫 Maktabingizni tanlang yoki raqami/nomini yozib qidiring:\n\n<i>Misol: 56</i>",
+		SchoolResults:     "🔎 Qidiruv natijalari:",
+		SchoolNotFound:    "🔎 Hech narsa topilmadi. Boshqacha yozib ko'ring yoki \"Maktabim ro'yxatda yo'q\" tugmasini bosing.",
+		BtnSchoolMissing:  "🏫 Maktabim ro'yxatda yo'q",
+		AskSchoolName:     "✍️ Maktabingiz nomini to'liq kiriting:\n\n<i>Misol: 56-umumiy o'rta ta'lim maktabi</i>",
+		SchoolSubmitted:   "✅ Maktabingiz ko'rib chiqish uchun adminga yuborildi.",
+		SchoolApproved:    "✅ Siz qo'shgan maktab tasdiqlandi: <b>%s</b>",
 		AskGrade:          "🎓 Nechanchi sinfda o'qiysiz?\n\n<i>1 dan 11 gacha raqam kiriting</i>",
 		InvalidGrade:      "❌ Noto'g'ri sinf raqami.\n\n<i>1 dan 11 gacha raqam kiriting</i>",
-		AskPhone:          "📱 Telefon raqamingizni kiriting yoki pastdagi tugmani bosing:\n\n<i>Misol: 998901234567</i>",
+		AskPhone:          "📱 Telefon raqamingizni kiriting yoki pastdagi tugmani bosing:\n\n<i>Misol: +998901234567</i>",
 		AskOTP:            "🔐 Telefon raqamingizga yuborilgan tasdiqlash kodini kiriting:",
-		InvalidPhone:      "❌ Telefon raqam noto'g'ri formatda.\n\n<i>Misol: 998901234567</i>",
-		InvalidOTP:        "❌ Tasdiqlash kodi noto'g'ri. Qaytadan urinib ko'ring.",
+		InvalidPhone:      "❌ Telefon raqam noto'g'ri formatda yoki bu mamlakat raqamlari qabul qilinmaydi.\n\n<i>Misol: +998901234567</i>",
+		InvalidOTP:        "❌ Tasdiqlash kodi noto'g'ri. Yana %d ta urinish qoldi.",
 		OTPSent:           "✅ Tasdiqlash kodi yuborildi: <b>%s</b>",
-		RegistrationDone:  "🎉 Tabriklaymiz! Ro'yxatdan muvaffaqiyatli o'tdingiz.\n\n👤 Ism: <b>%s</b>\n👤 Familiya: <b>%s</b>\n🏙 Viloyat: <b>%s</b>\n🏘 Tuman: <b>%s</b>\n🏫 Maktab: <b>%s</b>\n🎓 Sinf: <b>%d</b>\n📱 Telefon: <b>%s</b>",
+		RegistrationDone:  "🎉 Tabriklaymiz! Ro'yxatdan muvaffaqiyatli o'tdingiz.",
+		ProfileTitle:      "👤 <b>Sizning ma'lumotlaringiz</b>",
+		ProfileUpdated:    "✅ Ma'lumotlaringiz yangilandi.",
+		LabelFirstName:    "👤 Ism",
+		LabelLastName:     "👤 Familiya",
+		LabelRegion:       "🏙 Viloyat",
+		LabelDistrict:     "🏘 Tuman",
+		LabelSchool:       "🏫 Maktab",
+		LabelGrade:        "🎓 Sinf",
+		LabelPhone:        "📱 Telefon",
+		BtnEditName:       "✏️ Ism-familiya",
+		BtnEditLocation:   "✏️ Maktab",
+		BtnEditGrade:      "✏️ Sinf",
+		BtnEditPhone:      "✏️ Telefon",
 		MainMenu:          "Assalomu alaykum! Botimizga xush kelibsiz.\n\nAgar sizda <a href=\"https://khiso.uz\">khiso.uz</a> onlayn olimpiadalar platformasida akkaunt mavjud bo'lsa, \"Akkauntga kirish\" tugmasini bosing.\nAgar siz <a href=\"https://khiso.uz\">khiso.uz</a> onlayn olimpiadalar platformasidan ro'yxatdan o'tmagan bo'lsangiz, \"Akkaunt yaratish\" tugmasi orqali ro'yxatdan o'ting.\n\n<b>Diqqat!</b> Account yaratilgandan so'ng Olimpiadalar bo'limiga o'tib ro'yxatdan o'tishingiz mumkin.",
 		AlreadyRegistered: "✅ Siz allaqachon ro'yxatdan o'tgansiz.",
 		Error:             "❌ Xatolik yuz berdi. Iltimos qaytadan urinib ko'ring.",
@@ -54,21 +145,86 @@ var messages = map[string]Messages{
 		MustSubscribe:     "📢 Botdan foydalanish uchun quyidagi kanallarga obuna bo'ling:",
 		BtnCheckSub:       "✅ Obunani tekshirish",
 		SubscribeSuccess:  "✅ Rahmat! Endi botdan foydalanishingiz mumkin.",
+		BtnBack:           "⬅️ Orqaga",
+		PreviousValue:     "✏️ Avval kiritilgan qiymat: <b>%s</b>",
+		Cancelled:         "🔄 Kiritilgan ma'lumotlar o'chirildi. Ro'yxatdan o'tishni qaytadan boshlaymiz.",
+		EditCancelled:     "↩️ Tahrirlash bekor qilindi.",
+		AskRole:           "👋 Kim sifatida ro'yxatdan o'tasiz?",
+		InvalidRole:       "❌ Iltimos, quyidagi tugmalardan birini tanlang.",
+		RoleStudent:       "🎓 O'quvchi",
+		RoleTeacher:       "👨‍🏫 O'qituvchi",
+		RoleParent:        "👪 Ota-ona",
+		AskChildName:      "👶 Farzandingizning ism va familiyasini kiriting:\n\n<i>Misol: Aziza Karimova</i>",
+		AskChildGrade:     "🎓 Farzandingiz nechanchi sinfda o'qiydi?\n\n<i>1 dan 11 gacha raqam kiriting</i>",
+		AskSubject:        "📚 Qaysi fandan dars berasiz?\n\n<i>Misol: Matematika</i>",
+		InvalidSubject:    "❌ Fan nomini harflar bilan kiriting.\n\n<i>Misol: Matematika</i>",
+		LabelRole:         "👤 Rol",
+		LabelSubject:      "📚 Fan",
+		LabelChildName:    "👶 Farzand",
+		BtnEditSubject:    "✏️ Fan",
+		LabelChildren:     "👶 <b>Farzandlar:</b>",
+		NoChildren:        "<i>Hali farzand qo'shilmagan</i>",
+		ChildLine:         "%d. <b>%s</b> — %s, %d-sinf, %s",
+		ChildTitle:        "👶 <b>%s</b>\n\nNimani o'zgartiramiz?",
+		BtnAddChild:       "➕ Farzand qo'shish",
+		AskBirthDate:      "📅 Tug'ilgan sanangizni kiriting.\n\n<i>Masalan: 15.03.2012 yoki quyidan yilni tanlang</i>",
+		AskChildBirthDate: "📅 Farzandingizning tug'ilgan sanasini kiriting.\n\n<i>Masalan: 15.03.2012 yoki quyidan yilni tanlang</i>",
+		AskBirthMonth:     "📅 %d-yil. Oyni tanlang:",
+		AskBirthDay:       "📅 %02d.%d. Kunni tanlang:",
+		InvalidBirthDate:  "❌ Sana noto'g'ri.\n\n<i>KK.OO.YYYY ko'rinishida kiriting, masalan 15.03.2012</i>",
+		AgeGradeMismatch:  "⚠️ Tug'ilgan sana %d-sinfga to'g'ri kelmaydi. Sanani yoki sinfni tekshiring.",
+		LabelBirthDate:    "📅 Tug'ilgan sana",
+		BtnEditBirthDate:  "✏️ Tug'ilgan sana",
+		ReminderNudge:     "👋 Ro'yxatdan o'tish tugallanmay qoldi. To'xtagan joyingizdan davom etamiz:",
+		ReminderNudgeOTP:  "👋 Ro'yxatdan o'tish uchun faqat SMS kodni kiritish qoldi.\n\n<i>Kod kelmagan bo'lsa, /resend buyrug'ini yuboring</i>",
+		PhoneTaken:        "❌ Bu raqam boshqa Telegram akkauntga biriktirilgan.\n\n<i>Boshqa raqam yuboring</i>",
+		PhoneTransferred:  "⚠️ %s raqami boshqa Telegram akkauntda tasdiqlandi va unga o'tkazildi.\n\nDavom etish uchun yangi raqam yuboring.",
+		PhoneLinked:       "🔗 Bu raqam bilan avval ro'yxatdan o'tilgan. Akkauntingiz avvalgisiga bog'landi, ma'lumotlar umumiy bo'ladi.",
+		OTPCodeExpired:    "⌛ Bu kod endi amal qilmaydi. Yangi kod oling.",
+		OTPLocked:         "🔒 Juda ko'p noto'g'ri kod kiritildi. %d daqiqadan so'ng qayta urinib ko'ring.",
+		OTPResendIn:       "⏳ Yangi kodni %d soniyadan so'ng so'rashingiz mumkin.",
+		OTPResendReady:    "🔄 Endi yangi kod so'rashingiz mumkin.",
+		OTPDailyLimit:     "📵 Bugungi SMS kodlar limiti tugadi. Ertaga qayta urinib ko'ring.",
+		OTPUnavailable:    "⚠️ SMS yuborish vaqtincha to'xtatilgan. Birozdan so'ng qayta urinib ko'ring.",
+		SMSNotDelivered:   "📵 %s raqamiga SMS yetib bormadi. Raqam to'g'riligini tekshiring va yangi kod so'rang yoki raqamni o'zgartiring.",
+		OTPSMS:            "Tasdiqlash kodingiz: {code}. Kod {minutes} daqiqa amal qiladi. {brand}",
+		SMSTemplateUsage:  "✉️ Foydalanish:\n/smstemplate — shablonlarni ko'rish\n/smstemplate ru Kod: {code}. {minutes} daqiqa. {brand} — o'zgartirish\n/smstemplate ru reset — standart matnga qaytarish",
 	},
 	"ru": {
 		Welcome:           "👋 Добро пожаловать!\n\nВведите свои данные для регистрации.",
 		AskFullName:       "👤 Введите имя и фамилию:\n\n<i>Пример: Анвар Каримов</i>",
 		InvalidFullName:   "❌ Введите полное имя и фамилию.\n\n<i>Пример: Анвар Каримов</i>",
-		AskLocation:       "📍 Введите область, район и школу:\n\n<i>Пример: Ташкент, Юнусабад, школа 56</i>",
-		InvalidLocation:   "❌ Неверный формат.\n\nВведите в формате:\n<i>Область, Район, Школа</i>\n\nПример: Ташкент, Юнусабад, школа 56",
+		AskLocation:       "📍 Выберите область:",
+		InvalidLocation:   "❌ Пожалуйста, выберите один из вариантов в списке.",
+		AskDistrict:       "🏘 <b>%s</b>\n\nВыберите район:",
+		AskSchool:         "🏫 Выберите школу или введите её номер/название для поиска:\n\n<i>Пример: 56</i>",
+		SchoolResults:     "🔎 Результаты поиска:",
+		SchoolNotFound:    "🔎 Ничего не найдено. Попробуйте написать иначе или нажмите \"Моей школы нет в списке\".",
+		BtnSchoolMissing:  "🏫 Моей школы нет в списке",
+		AskSchoolName:     "✍️ Введите полное название вашей школы:\n\n<i>Пример: Общеобразовательная школа №56</i>",
+		SchoolSubmitted:   "✅ Ваша школа отправлена администратору на проверку.",
+		SchoolApproved:    "✅ Добавленная вами школа подтверждена: <b>%s</b>",
 		AskGrade:          "🎓 В каком классе вы учитесь?\n\n<i>Введите число от 1 до 11</i>",
 		InvalidGrade:      "❌ Неверный номер класса.\n\n<i>Введите число от 1 до 11</i>",
-		AskPhone:          "📱 Введите номер телефона или нажмите кнопку ниже:\n\n<i>Пример: 998901234567</i>",
+		AskPhone:          "📱 Введите номер телефона или нажмите кнопку ниже:\n\n<i>Пример: +998901234567</i>",
 		AskOTP:            "🔐 Введите код подтверждения, отправленный на ваш телефон:",
-		InvalidPhone:      "❌ Неверный формат номера.\n\n<i>Пример: 998901234567</i>",
-		InvalidOTP:        "❌ Неверный код подтверждения. Попробуйте еще раз.",
+		InvalidPhone:      "❌ Неверный формат номера или номера этой страны не принимаются.\n\n<i>Пример: +998901234567</i>",
+		InvalidOTP:        "❌ Неверный код подтверждения. Осталось попыток: %d.",
 		OTPSent:           "✅ Код подтверждения отправлен: <b>%s</b>",
-		RegistrationDone:  "🎉 Поздравляем! Вы успешно зарегистрировались.\n\n👤 Имя: <b>%s</b>\n👤 Фамилия: <b>%s</b>\n🏙 Область: <b>%s</b>\n🏘 Район: <b>%s</b>\n🏫 Школа: <b>%s</b>\n🎓 Класс: <b>%d</b>\n📱 Телефон: <b>%s</b>",
+		RegistrationDone:  "🎉 Поздравляем! Вы успешно зарегистрировались.",
+		ProfileTitle:      "👤 <b>Ваши данные</b>",
+		ProfileUpdated:    "✅ Ваши данные обновлены.",
+		LabelFirstName:    "👤 Имя",
+		LabelLastName:     "👤 Фамилия",
+		LabelRegion:       "🏙 Область",
+		LabelDistrict:     "🏘 Район",
+		LabelSchool:       "🏫 Школа",
+		LabelGrade:        "🎓 Класс",
+		LabelPhone:        "📱 Телефон",
+		BtnEditName:       "✏️ Имя и фамилия",
+		BtnEditLocation:   "✏️ Школа",
+		BtnEditGrade:      "✏️ Класс",
+		BtnEditPhone:      "✏️ Телефон",
 		MainMenu:          "Ассалому алайкум! Добро пожаловать в наш бот.\n\nЕсли у вас есть аккаунт на платформе онлайн олимпиад <a href=\"https://khiso.uz\">khiso.uz</a>, нажмите кнопку \"Войти в аккаунт\".\nЕсли вы не зарегистрированы на платформе <a href=\"https://khiso.uz\">khiso.uz</a>, зарегистрируйтесь через кнопку \"Создать аккаунт\".\n\n<b>Внимание!</b> После создания аккаунта вы можете перейти в раздел Олимпиады и зарегистрироваться.",
 		AlreadyRegistered: "✅ Вы уже зарегистрированы.",
 		Error:             "❌ Произошла ошибка. Попробуйте еще раз.",
@@ -80,21 +236,86 @@ var messages = map[string]Messages{
 		MustSubscribe:     "📢 Для использования бота подпишитесь на следующие каналы:",
 		BtnCheckSub:       "✅ Проверить подписку",
 		SubscribeSuccess:  "✅ Спасибо! Теперь вы можете использовать бота.",
+		BtnBack:           "⬅️ Назад",
+		PreviousValue:     "✏️ Ранее введённое значение: <b>%s</b>",
+		Cancelled:         "🔄 Введённые данные удалены. Начинаем регистрацию заново.",
+		EditCancelled:     "↩️ Редактирование отменено.",
+		AskRole:           "👋 В качестве кого вы регистрируетесь?",
+		InvalidRole:       "❌ Пожалуйста, выберите один из вариантов ниже.",
+		RoleStudent:       "🎓 Ученик",
+		RoleTeacher:       "👨‍🏫 Учитель",
+		RoleParent:        "👪 Родитель",
+		AskChildName:      "👶 Введите имя и фамилию ребёнка:\n\n<i>Пример: Азиза Каримова</i>",
+		AskChildGrade:     "🎓 В каком классе учится ваш ребёнок?\n\n<i>Введите число от 1 до 11</i>",
+		AskSubject:        "📚 Какой предмет вы преподаёте?\n\n<i>Пример: Математика</i>",
+		InvalidSubject:    "❌ Введите название предмета буквами.\n\n<i>Пример: Математика</i>",
+		LabelRole:         "👤 Роль",
+		LabelSubject:      "📚 Предмет",
+		LabelChildName:    "👶 Ребёнок",
+		BtnEditSubject:    "✏️ Предмет",
+		LabelChildren:     "👶 <b>Дети:</b>",
+		NoChildren:        "<i>Дети ещё не добавлены</i>",
+		ChildLine:         "%d. <b>%s</b> — %s, %d класс, %s",
+		ChildTitle:        "👶 <b>%s</b>\n\nЧто изменить?",
+		BtnAddChild:       "➕ Добавить ребёнка",
+		AskBirthDate:      "📅 Введите дату рождения.\n\n<i>Например: 15.03.2012 или выберите год ниже</i>",
+		AskChildBirthDate: "📅 Введите дату рождения ребёнка.\n\n<i>Например: 15.03.2012 или выберите год ниже</i>",
+		AskBirthMonth:     "📅 %d год. Выберите месяц:",
+		AskBirthDay:       "📅 %02d.%d. Выберите день:",
+		InvalidBirthDate:  "❌ Неверная дата.\n\n<i>Введите в формате ДД.ММ.ГГГГ, например 15.03.2012</i>",
+		AgeGradeMismatch:  "⚠️ Дата рождения не подходит для %d класса. Проверьте дату или класс.",
+		LabelBirthDate:    "📅 Дата рождения",
+		BtnEditBirthDate:  "✏️ Дата рождения",
+		ReminderNudge:     "👋 Регистрация не завершена. Продолжим с того места, где вы остановились:",
+		ReminderNudgeOTP:  "👋 Для завершения регистрации осталось ввести код из SMS.\n\n<i>Если код не пришёл, отправьте /resend</i>",
+		PhoneTaken:        "❌ Этот номер уже привязан к другому аккаунту Telegram.\n\n<i>Отправьте другой номер</i>",
+		PhoneTransferred:  "⚠️ Номер %s подтверждён в другом аккаунте Telegram и перенесён туда.\n\nЧтобы продолжить, отправьте новый номер.",
+		PhoneLinked:       "🔗 С этим номером уже регистрировались. Ваш аккаунт привязан к прежнему, данные будут общими.",
+		OTPCodeExpired:    "⌛ Этот код больше не действует. Запросите новый код.",
+		OTPLocked:         "🔒 Слишком много неверных кодов. Попробуйте снова через %d мин.",
+		OTPResendIn:       "⏳ Новый код можно запросить через %d сек.",
+		OTPResendReady:    "🔄 Теперь можно запросить новый код.",
+		OTPDailyLimit:     "📵 Лимит SMS-кодов на сегодня исчерпан. Попробуйте завтра.",
+		OTPUnavailable:    "⚠️ Отправка SMS временно приостановлена. Попробуйте позже.",
+		SMSNotDelivered:   "📵 SMS на номер %s не доставлено. Проверьте номер и запросите новый код или измените номер.",
+		OTPSMS:            "Код подтверждения: {code}. Действует {minutes} мин. {brand}",
+		SMSTemplateUsage:  "✉️ Использование:\n/smstemplate — показать шаблоны\n/smstemplate ru Код: {code}. {minutes} мин. {brand} — изменить\n/smstemplate ru reset — вернуть стандартный текст",
 	},
 	"en": {
 		Welcome:           "👋 Welcome!\n\nPlease enter your information to register.",
 		AskFullName:       "👤 Enter your first and last name:\n\n<i>Example: John Smith</i>",
 		InvalidFullName:   "❌ Please enter your full name.\n\n<i>Example: John Smith</i>",
-		AskLocation:       "📍 Enter your region, district and school:\n\n<i>Example: Tashkent, Yunusabad, School 56</i>",
-		InvalidLocation:   "❌ Invalid format.\n\nPlease enter in format:\n<i>Region, District, School</i>\n\nExample: Tashkent, Yunusabad, School 56",
+		AskLocation:       "📍 Choose your region:",
+		InvalidLocation:   "❌ Please choose one of the options from the list.",
+		AskDistrict:       "🏘 <b>%s</b>\n\nChoose your district:",
+		AskSchool:         "🏫 Choose your school or type its number/name to search:\n\n<i>Example: 56</i>",
+		SchoolResults:     "🔎 Search results:",
+		SchoolNotFound:    "🔎 Nothing found. Try a different spelling or tap \"My school isn't listed\".",
+		BtnSchoolMissing:  "🏫 My school isn't listed",
+		AskSchoolName:     "✍️ Enter the full name of your school:\n\n<i>Example: Secondary School No. 56</i>",
+		SchoolSubmitted:   "✅ Your school has been sent to the admin for review.",
+		SchoolApproved:    "✅ The school you added has been approved: <b>%s</b>",
 		AskGrade:          "🎓 What grade are you in?\n\n<i>Enter a number from 1 to 11</i>",
 		InvalidGrade:      "❌ Invalid grade number.\n\n<i>Enter a number from 1 to 11</i>",
-		AskPhone:          "📱 Enter your phone number or tap the button below:\n\n<i>Example: 998901234567</i>",
+		AskPhone:          "📱 Enter your phone number or tap the button below:\n\n<i>Example: +998901234567</i>",
 		AskOTP:            "🔐 Enter the verification code sent to your phone:",
-		InvalidPhone:      "❌ Invalid phone number format.\n\n<i>Example: 998901234567</i>",
-		InvalidOTP:        "❌ Invalid verification code. Please try again.",
+		InvalidPhone:      "❌ Invalid phone number format, or numbers from this country are not accepted.\n\n<i>Example: +998901234567</i>",
+		InvalidOTP:        "❌ Invalid verification code. Attempts left: %d.",
 		OTPSent:           "✅ Verification code sent: <b>%s</b>",
-		RegistrationDone:  "🎉 Congratulations! You have successfully registered.\n\n👤 First Name: <b>%s</b>\n👤 Last Name: <b>%s</b>\n🏙 Region: <b>%s</b>\n🏘 District: <b>%s</b>\n🏫 School: <b>%s</b>\n🎓 Grade: <b>%d</b>\n📱 Phone: <b>%s</b>",
+		RegistrationDone:  "🎉 Congratulations! You have successfully registered.",
+		ProfileTitle:      "👤 <b>Your details</b>",
+		ProfileUpdated:    "✅ Your details have been updated.",
+		LabelFirstName:    "👤 First Name",
+		LabelLastName:     "👤 Last Name",
+		LabelRegion:       "🏙 Region",
+		LabelDistrict:     "🏘 District",
+		LabelSchool:       "🏫 School",
+		LabelGrade:        "🎓 Grade",
+		LabelPhone:        "📱 Phone",
+		BtnEditName:       "✏️ Name",
+		BtnEditLocation:   "✏️ School",
+		BtnEditGrade:      "✏️ Grade",
+		BtnEditPhone:      "✏️ Phone",
 		MainMenu:          "Assalomu alaykum! Welcome to our bot.\n\nIf you have an account on the <a href=\"https://khiso.uz\">khiso.uz</a> online olympiad platform, click \"Login to account\".\nIf you are not registered on <a href=\"https://khiso.uz\">khiso.uz</a>, register via \"Create account\" button.\n\n<b>Attention!</b> After creating an account, you can go to the Olympiads section and register.",
 		AlreadyRegistered: "✅ You are already registered.",
 		Error:             "❌ An error occurred. Please try again.",
@@ -106,6 +327,50 @@ var messages = map[string]Messages{
 		MustSubscribe:     "📢 To use the bot, please subscribe to the following channels:",
 		BtnCheckSub:       "✅ Check subscription",
 		SubscribeSuccess:  "✅ Thank you! You can now use the bot.",
+		BtnBack:           "⬅️ Back",
+		PreviousValue:     "✏️ Previously entered: <b>%s</b>",
+		Cancelled:         "🔄 Your entered data has been cleared. Let's start the registration again.",
+		EditCancelled:     "↩️ Editing cancelled.",
+		AskRole:           "👋 Who are you registering as?",
+		InvalidRole:       "❌ Please choose one of the options below.",
+		RoleStudent:       "🎓 Student",
+		RoleTeacher:       "👨‍🏫 Teacher",
+		RoleParent:        "👪 Parent",
+		AskChildName:      "👶 Enter your child's first and last name:\n\n<i>Example: Aziza Karimova</i>",
+		AskChildGrade:     "🎓 What grade is your child in?\n\n<i>Enter a number from 1 to 11</i>",
+		AskSubject:        "📚 Which subject do you teach?\n\n<i>Example: Mathematics</i>",
+		InvalidSubject:    "❌ Enter the subject name using letters.\n\n<i>Example: Mathematics</i>",
+		LabelRole:         "👤 Role",
+		LabelSubject:      "📚 Subject",
+		LabelChildName:    "👶 Child",
+		BtnEditSubject:    "✏️ Subject",
+		LabelChildren:     "👶 <b>Children:</b>",
+		NoChildren:        "<i>No children added yet</i>",
+		ChildLine:         "%d. <b>%s</b> — %s, grade %d, %s",
+		ChildTitle:        "👶 <b>%s</b>\n\nWhat would you like to change?",
+		BtnAddChild:       "➕ Add a child",
+		AskBirthDate:      "📅 Enter your date of birth.\n\n<i>For example: 15.03.2012, or pick the year below</i>",
+		AskChildBirthDate: "📅 Enter your child's date of birth.\n\n<i>For example: 15.03.2012, or pick the year below</i>",
+		AskBirthMonth:     "📅 %d. Pick the month:",
+		AskBirthDay:       "📅 %02d.%d. Pick the day:",
+		InvalidBirthDate:  "❌ Invalid date.\n\n<i>Use the DD.MM.YYYY format, e.g. 15.03.2012</i>",
+		AgeGradeMismatch:  "⚠️ This date of birth does not fit grade %d. Please check the date or the grade.",
+		LabelBirthDate:    "📅 Date of birth",
+		BtnEditBirthDate:  "✏️ Date of birth",
+		ReminderNudge:     "👋 Your registration isn't finished yet. Let's continue where you left off:",
+		ReminderNudgeOTP:  "👋 Only the SMS code is left to finish your registration.\n\n<i>If the code didn't arrive, send /resend</i>",
+		PhoneTaken:        "❌ This number is already linked to another Telegram account.\n\n<i>Please send a different number</i>",
+		PhoneTransferred:  "⚠️ The number %s was verified on another Telegram account and moved there.\n\nSend a new number to continue.",
+		PhoneLinked:       "🔗 This number was registered before. Your account is now linked to the earlier one and shares its data.",
+		OTPCodeExpired:    "⌛ This code is no longer valid. Please request a new one.",
+		OTPLocked:         "🔒 Too many wrong codes. Please try again in %d minutes.",
+		OTPResendIn:       "⏳ You can request a new code in %ds.",
+		OTPResendReady:    "🔄 You can request a new code now.",
+		OTPDailyLimit:     "📵 You have used up today's SMS codes. Please try again tomorrow.",
+		OTPUnavailable:    "⚠️ Sending SMS is temporarily paused. Please try again later.",
+		SMSNotDelivered:   "📵 The SMS to %s was not delivered. Check the number and request a new code, or change the number.",
+		OTPSMS:            "Your verification code: {code}. It is valid for {minutes} min. {brand}",
+		SMSTemplateUsage:  "✉️ Usage:\n/smstemplate — show the templates\n/smstemplate en Code: {code}. {minutes} min. {brand} — change\n/smstemplate en reset — restore the default text",
 	},
 }
 
@@ -115,3 +380,16 @@ func Get(lang string) Messages {
 	}
 	return messages["uz"]
 }
+
+// Text looks a message up by its field name, as the registration steps name
+// their texts. Unknown keys give "".
+func (m Messages) Text(key string) string {
+	if key == "" {
+		return ""
+	}
+	f := reflect.ValueOf(m).FieldByName(key)
+	if !f.IsValid() || f.Kind() != reflect.String {
+		return ""
+	}
+	return f.String()
+}
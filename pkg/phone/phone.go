@@ -0,0 +1,152 @@
+// pkg/phone/phone.go
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxDigits is the E.164 limit for country code plus national number
+const maxDigits = 15
+
+var (
+	ErrInvalidNumber      = errors.New("invalid phone number")
+	ErrCountryNotAllowed  = errors.New("country calling code is not allowed")
+	ErrInvalidCountryCode = errors.New("invalid country calling code")
+)
+
+// nationalLengths is the national number length range for calling codes we
+// know. Codes missing here are still accepted if allowed, with loose limits.
+var nationalLengths = map[string][2]int{
+	"998": {9, 9},   // O'zbekiston
+	"7":   {10, 10}, // Qozog'iston, Rossiya
+	"992": {9, 9},   // Tojikiston
+	"996": {9, 9},   // Qirg'iziston
+	"993": {8, 8},   // Turkmaniston
+	"994": {9, 9},   // Ozarbayjon
+	"995": {9, 9},   // Gruziya
+	"374": {8, 8},   // Armaniston
+	"375": {9, 9},   // Belarus
+	"380": {9, 9},   // Ukraina
+	"90":  {10, 10}, // Turkiya
+	"971": {8, 9},   // BAA
+	"82":  {8, 10},  // Janubiy Koreya
+	"1":   {10, 10}, // AQSH, Kanada
+	"44":  {9, 10},  // Buyuk Britaniya
+	"49":  {6, 11},  // Germaniya
+}
+
+// looseLengths apply to allowed calling codes without an entry above
+var looseLengths = [2]int{4, 14}
+
+var separators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "", "\u00a0", "")
+
+// Parser normalizes phone numbers to E.164 ("+998901234567") and only
+// accepts numbers from an allowlist of country calling codes. The first
+// allowed code is the home country: numbers typed without a country code
+// are assumed to belong to it.
+type Parser struct {
+	countryCodes []string
+}
+
+// NewParser builds a parser for the given calling codes, e.g. "998", "7", "992"
+func NewParser(countryCodes []string) (*Parser, error) {
+	if len(countryCodes) == 0 {
+		return nil, fmt.Errorf("%w: allowlist is empty", ErrInvalidCountryCode)
+	}
+
+	codes := make([]string, 0, len(countryCodes))
+	for _, code := range countryCodes {
+		code = strings.TrimPrefix(strings.TrimSpace(code), "+")
+		if len(code) == 0 || len(code) > 3 || !isDigits(code) || code[0] == '0' {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCountryCode, code)
+		}
+		codes = append(codes, code)
+	}
+
+	return &Parser{countryCodes: codes}, nil
+}
+
+// Parse accepts "+998 90 123-45-67", "00998901234567", "998901234567" or a
+// home country number without its code ("901234567") and returns the E.164 form
+func (p *Parser) Parse(text string) (string, error) {
+	digits := separators.Replace(strings.TrimSpace(text))
+
+	international := false
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits, international = digits[1:], true
+	case strings.HasPrefix(digits, "00"):
+		digits, international = digits[2:], true
+	}
+
+	if digits == "" || !isDigits(digits) {
+		return "", ErrInvalidNumber
+	}
+
+	number, err := p.parseInternational(digits)
+	if err == nil || international {
+		return number, err
+	}
+
+	// Mamlakat kodisiz yozilgan raqam: asosiy mamlakatniki deb hisoblaymiz
+	home := p.countryCodes[0]
+	if local, localErr := p.validate(home, digits); localErr == nil {
+		return local, nil
+	}
+	return "", err
+}
+
+// parseInternational splits digits that start with a calling code
+func (p *Parser) parseInternational(digits string) (string, error) {
+	// Eng uzun mos kodni tanlaymiz: "998" va "9" bir vaqtda ruxsat etilishi mumkin
+	code := ""
+	for _, c := range p.countryCodes {
+		if strings.HasPrefix(digits, c) && len(c) > len(code) {
+			code = c
+		}
+	}
+	if code == "" {
+		return "", ErrCountryNotAllowed
+	}
+	return p.validate(code, digits[len(code):])
+}
+
+func (p *Parser) validate(code, national string) (string, error) {
+	lengths, ok := nationalLengths[code]
+	if !ok {
+		lengths = looseLengths
+	}
+	if len(national) < lengths[0] || len(national) > lengths[1] || len(code)+len(national) > maxDigits {
+		return "", ErrInvalidNumber
+	}
+	return "+" + code + national, nil
+}
+
+// Mask hides the middle of a number for messages: "+998901234567" -> "+998901****67".
+// Exactly four digits are hidden whatever the length of the number.
+func Mask(number string) string {
+	digits := strings.TrimPrefix(number, "+")
+	prefix := number[:len(number)-len(digits)]
+
+	if len(digits) <= 4 {
+		return prefix + strings.Repeat("*", len(digits))
+	}
+
+	tail := 2
+	if len(digits) < 7 {
+		tail = 1
+	}
+	head := len(digits) - 4 - tail
+	return prefix + digits[:head] + "****" + digits[len(digits)-tail:]
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	p, err := NewParser([]string{"998", "+7", "992"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr error
+	}{
+		{"+998 90 123-45-67", "+998901234567", nil},
+		{"998901234567", "+998901234567", nil},
+		{"00998901234567", "+998901234567", nil},
+		{"(90) 123 45 67", "+998901234567", nil},
+		{"901234567", "+998901234567", nil},
+		{"+7 701 123 45 67", "+77011234567", nil},
+		{"+992 93 123 4567", "+992931234567", nil},
+		{"+1 202 555 0123", "", ErrCountryNotAllowed},
+		{"+99890123456", "", ErrInvalidNumber},
+		{"+9989012345678", "", ErrInvalidNumber},
+		{"90123", "", ErrCountryNotAllowed},
+		{"+998 90 abc", "", ErrInvalidNumber},
+		{"", "", ErrInvalidNumber},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := p.Parse(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewParser(t *testing.T) {
+	for _, codes := range [][]string{nil, {"0998"}, {"99a"}, {"07"}} {
+		if _, err := NewParser(codes); !errors.Is(err, ErrInvalidCountryCode) {
+			t.Errorf("NewParser(%q) error = %v, want %v", codes, err, ErrInvalidCountryCode)
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"+998901234567", "+998901****67"},
+		{"+77011234567", "+77011****67"},
+		{"+123456", "+1****6"},
+		{"1234", "****"},
+	}
+
+	for _, tt := range tests {
+		if got := Mask(tt.in); got != tt.want {
+			t.Errorf("Mask(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
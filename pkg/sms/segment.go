@@ -0,0 +1,63 @@
+// pkg/sms/segment.go
+package sms
+
+import "unicode/utf16"
+
+// Single SMS limits: GSM-7 fits 160 septets, anything outside the GSM
+// alphabet (e.g. Cyrillic or ʻ) switches the whole message to UCS-2 with 70
+// UTF-16 code units
+const (
+	GSM7Limit = 160
+	UCS2Limit = 70
+)
+
+// Encodings
+const (
+	EncodingGSM7 = "GSM-7"
+	EncodingUCS2 = "UCS-2"
+)
+
+// gsm7Basic is the GSM 03.38 default alphabet
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension characters take two septets (an escape plus the character)
+const gsm7Extension = "^{}\\[~]|€\f"
+
+var gsm7Runes, gsm7ExtRunes = runeSet(gsm7Basic), runeSet(gsm7Extension)
+
+// Segment describes how a text is encoded in a single SMS
+type Segment struct {
+	Encoding string
+	Length   int // septets for GSM-7, UTF-16 code units for UCS-2
+	Limit    int
+}
+
+// Fits reports whether the text goes out as one SMS
+func (s Segment) Fits() bool {
+	return s.Length <= s.Limit
+}
+
+// Measure works out the encoding the text needs and its length in that encoding
+func Measure(text string) Segment {
+	septets := 0
+	for _, r := range text {
+		switch {
+		case gsm7Runes[r]:
+			septets++
+		case gsm7ExtRunes[r]:
+			septets += 2
+		default:
+			return Segment{Encoding: EncodingUCS2, Length: len(utf16.Encode([]rune(text))), Limit: UCS2Limit}
+		}
+	}
+	return Segment{Encoding: EncodingGSM7, Length: septets, Limit: GSM7Limit}
+}
+
+func runeSet(s string) map[rune]bool {
+	set := make(map[rune]bool)
+	for _, r := range s {
+		set[r] = true
+	}
+	return set
+}
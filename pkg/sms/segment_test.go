@@ -0,0 +1,41 @@
+package sms
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMeasure(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		encoding string
+		length   int
+		fits     bool
+	}{
+		{"empty", "", EncodingGSM7, 0, true},
+		{"latin", "Amity: kod 123456", EncodingGSM7, 17, true},
+		{"gsm7 accents", "é à ü Ñ", EncodingGSM7, 7, true},
+		{"extension takes two septets", "[1]€", EncodingGSM7, 7, true},
+		{"gsm7 at limit", strings.Repeat("a", 160), EncodingGSM7, 160, true},
+		{"gsm7 over limit", strings.Repeat("a", 161), EncodingGSM7, 161, false},
+		{"extension pushes over limit", strings.Repeat("a", 159) + "{", EncodingGSM7, 161, false},
+		{"cyrillic", "Ваш код 123456", EncodingUCS2, 14, true},
+		{"uzbek okina", "Tasdiqlash kodi: 1234. Oʻzgartirmang", EncodingUCS2, 36, true},
+		{"one non-gsm rune switches all", strings.Repeat("a", 69) + "ʻ", EncodingUCS2, 70, true},
+		{"ucs2 over limit", strings.Repeat("я", 71), EncodingUCS2, 71, false},
+		{"surrogate pair counts twice", "kod 😀", EncodingUCS2, 6, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Measure(tt.in)
+			if got.Encoding != tt.encoding || got.Length != tt.length {
+				t.Fatalf("Measure(%q) = %s/%d, want %s/%d", tt.in, got.Encoding, got.Length, tt.encoding, tt.length)
+			}
+			if got.Fits() != tt.fits {
+				t.Errorf("Measure(%q).Fits() = %v, want %v", tt.in, got.Fits(), tt.fits)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// pkg/translit/name.go
+package translit
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+const maxNameLength = 64
+
+var (
+	ErrEmptyName       = errors.New("name is empty")
+	ErrNameTooLong     = errors.New("name is too long")
+	ErrInvalidNameRune = errors.New("name contains digits or symbols")
+)
+
+// strayPunctuation is dropped silently: "Anvar." or "(Karimov)" are still names
+var strayPunctuation = strings.NewReplacer(
+	".", "", ",", "", ";", "", ":", "", "!", "", "?", "", "\"", "", "(", "", ")", "",
+	"«", "", "»", "",
+)
+
+// NormalizeName cleans up a single name word typed by a user:
+// stray punctuation is removed, apostrophes are unified, every part of a
+// hyphenated name is capitalised and digits, emoji and other symbols are rejected.
+// A word that mixes alphabets (usually a lookalike letter) is converted to Latin.
+func NormalizeName(s string) (string, error) {
+	s = strings.Join(strings.Fields(strayPunctuation.Replace(s)), " ")
+	s = strings.Trim(s, "-'`‘’ʻʼ´ ")
+	if s == "" {
+		return "", ErrEmptyName
+	}
+	if len([]rune(s)) > maxNameLength {
+		return "", ErrNameTooLong
+	}
+
+	letters := 0
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Latin, r), unicode.Is(unicode.Cyrillic, r):
+			letters++
+		case r == '-' || r == ' ' || apostrophes[r]:
+		default:
+			return "", ErrInvalidNameRune
+		}
+	}
+	if letters == 0 {
+		return "", ErrEmptyName
+	}
+
+	if DetectScript(s) == ScriptMixed {
+		s = ToLatin(s)
+	}
+
+	return capitalize(s), nil
+}
+
+// capitalize upper-cases the first letter of each word and hyphen part and
+// lower-cases the rest, so "ANVAR" and "anvar" both become "Anvar"
+func capitalize(s string) string {
+	runes := []rune(strings.ToLower(s))
+	start := true
+	for i, r := range runes {
+		switch {
+		case r == ' ' || r == '-':
+			start = true
+		case apostrophes[r]:
+			// O'g'li: apostrofdan keyin yangi so'z boshlanmaydi
+		case start:
+			runes[i] = unicode.ToUpper(r)
+			start = false
+		}
+	}
+
+	s = string(runes)
+	if DetectScript(s) == ScriptLatin {
+		s = normalizeApostrophes(s)
+	}
+	return s
+}
+
+func normalizeApostrophes(s string) string {
+	runes := []rune(s)
+	var sb strings.Builder
+	for i, r := range runes {
+		if apostrophes[r] {
+			sb.WriteString(latinApostrophe(runes, i))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,133 @@
+// pkg/translit/translit.go
+package translit
+
+import (
+	"strings"
+	"unicode"
+)
+
+type Script int
+
+const (
+	ScriptUnknown Script = iota
+	ScriptLatin
+	ScriptCyrillic
+	ScriptMixed
+)
+
+// Official Uzbek Latin apostrophes: oʻ/gʻ use the turned comma,
+// the tutuq belgisi (ъ) uses the modifier apostrophe
+const (
+	Okina      = "ʻ"
+	Apostrophe = "ʼ"
+)
+
+// apostrophes are the characters people type instead of ʻ and ʼ
+var apostrophes = map[rune]bool{
+	'\'': true, '`': true, '‘': true, '’': true, 'ʻ': true, 'ʼ': true, '´': true,
+}
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+	'ж': "j", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "x", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sh", 'ъ': Apostrophe,
+	'ы': "i", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'ў': "o" + Okina, 'қ': "q", 'ғ': "g" + Okina, 'ҳ': "h",
+}
+
+// DetectScript reports which alphabet the letters of s belong to
+func DetectScript(s string) Script {
+	var latin, cyrillic bool
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic = true
+		case unicode.Is(unicode.Latin, r):
+			latin = true
+		}
+	}
+
+	switch {
+	case latin && cyrillic:
+		return ScriptMixed
+	case cyrillic:
+		return ScriptCyrillic
+	case latin:
+		return ScriptLatin
+	default:
+		return ScriptUnknown
+	}
+}
+
+// ToLatin transliterates Uzbek (and common Russian) Cyrillic to the official
+// Uzbek Latin alphabet. Latin input only gets its apostrophes normalized.
+func ToLatin(s string) string {
+	runes := []rune(s)
+	var sb strings.Builder
+
+	for i, r := range runes {
+		lower := unicode.ToLower(r)
+
+		if apostrophes[r] {
+			sb.WriteString(latinApostrophe(runes, i))
+			continue
+		}
+
+		latin, ok := cyrillicToLatin[lower]
+		if !ok {
+			sb.WriteRune(r)
+			continue
+		}
+
+		// "е" so'z boshida va unlidan keyin "ye" o'qiladi: Ергаш -> Yergash
+		if lower == 'е' && (i == 0 || !isLetter(runes[i-1]) || isCyrillicVowel(runes[i-1])) {
+			latin = "ye"
+		}
+
+		if unicode.IsUpper(r) && latin != "" {
+			latin = upperLatin(latin, runes, i)
+		}
+		sb.WriteString(latin)
+	}
+
+	return sb.String()
+}
+
+// latinApostrophe picks ʻ after o/g (oʻ, gʻ) and ʼ everywhere else
+func latinApostrophe(runes []rune, i int) string {
+	if i > 0 {
+		switch unicode.ToLower(runes[i-1]) {
+		case 'o', 'g':
+			return Okina
+		}
+	}
+	return Apostrophe
+}
+
+// upperLatin capitalises a multi-letter mapping: "Sh" inside normal words,
+// "SH" when the neighbouring letter is also upper case (ШАРИПОВ -> SHARIPOV)
+func upperLatin(latin string, runes []rune, i int) string {
+	allCaps := false
+	if i+1 < len(runes) && isLetter(runes[i+1]) {
+		allCaps = unicode.IsUpper(runes[i+1])
+	} else if i > 0 && isLetter(runes[i-1]) {
+		allCaps = unicode.IsUpper(runes[i-1])
+	}
+
+	if allCaps {
+		return strings.ToUpper(latin)
+	}
+
+	lr := []rune(latin)
+	lr[0] = unicode.ToUpper(lr[0])
+	return string(lr)
+}
+
+func isLetter(r rune) bool {
+	return unicode.IsLetter(r)
+}
+
+func isCyrillicVowel(r rune) bool {
+	return strings.ContainsRune("аеёиоуўэюяъь", unicode.ToLower(r))
+}
@@ -0,0 +1,90 @@
+package translit
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr error
+	}{
+		{"anvar", "Anvar", nil},
+		{"ANVAR", "Anvar", nil},
+		{"  anvar. ", "Anvar", nil},
+		{"(Karimov)", "Karimov", nil},
+		{"abdul-aziz", "Abdul-Aziz", nil},
+		{"o'g'iljon", "Oʻgʻiljon", nil},
+		{"O`ktam", "Oʻktam", nil},
+		{"ma'mur", "Maʼmur", nil},
+		{"шерзод", "Шерзод", nil},
+		{"ШАРИПОВ", "Шарипов", nil},
+		// Kirill "о" lotin so'z ichida: aralash yozuv lotinga o'tkaziladi
+		{"Anvоr", "Anvor", nil},
+		{"", "", ErrEmptyName},
+		{" - ' ", "", ErrEmptyName},
+		{"Anvar2", "", ErrInvalidNameRune},
+		{"Anvar 😀", "", ErrInvalidNameRune},
+		{"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "", ErrNameTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := NormalizeName(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NormalizeName(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToLatin(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Шерзод", "Sherzod"},
+		{"ШАРИПОВ", "SHARIPOV"},
+		{"Ергаш", "Yergash"},
+		{"Алексеев", "Alekseyev"},
+		{"Ўткир", "Oʻtkir"},
+		{"Ғайрат", "Gʻayrat"},
+		{"Қодиров", "Qodirov"},
+		{"Ҳасан", "Hasan"},
+		{"Маъмур", "Maʼmur"},
+		{"Юлдуз", "Yulduz"},
+		{"Латиф", "Latif"},
+		{"o'g'il", "oʻgʻil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := ToLatin(tt.in); got != tt.want {
+				t.Errorf("ToLatin(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectScript(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Script
+	}{
+		{"Anvar", ScriptLatin},
+		{"Анвар", ScriptCyrillic},
+		{"Anvаr", ScriptMixed},
+		{"123", ScriptUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := DetectScript(tt.in); got != tt.want {
+			t.Errorf("DetectScript(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}